@@ -4,8 +4,12 @@ import (
 	"log"
 	"os"
 
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
 	"github.com/ThailanTec/challenger/pousada/infra/database"
 	"github.com/ThailanTec/challenger/pousada/infra/database/migrations"
+	"github.com/ThailanTec/challenger/pousada/infra/notifier"
+	"github.com/ThailanTec/challenger/pousada/infra/sms"
 	"github.com/ThailanTec/challenger/pousada/src/config"
 	"github.com/ThailanTec/challenger/pousada/src/routes"
 	"github.com/gin-gonic/gin"
@@ -13,9 +17,12 @@ import (
 
 func main() {
 	cfg := config.LoadConfig()
+	if !domain.ValidPhoneCountry(cfg.PhoneDefaultCountry) {
+		log.Fatalf("Unsupported PhoneDefaultCountry: %q", cfg.PhoneDefaultCountry)
+	}
 	logger, err := config.InitLogger()
 	if err != nil {
-		log.Fatalf("Failed To init a logger", err)
+		log.Fatalf("Failed To init a logger: %v", err)
 	}
 
 	db, err := database.PostgresClient(cfg)
@@ -25,13 +32,33 @@ func main() {
 
 	redis := database.RedisClient(cfg)
 
+	keys, err := auth.LoadKeySet(cfg)
+	if err != nil {
+		log.Fatalf("Failed to load JWT signing keys: %v", err)
+	}
+
+	notify, err := notifier.New(cfg, logger)
+	if err != nil {
+		log.Fatalf("Failed to create notifier: %v", err)
+	}
+
+	sender, err := sms.New(cfg, logger)
+	if err != nil {
+		log.Fatalf("Failed to create sms sender: %v", err)
+	}
+
 	err = migrations.Migrate(db)
 	if err != nil {
 		log.Fatalf("Failed to migrate database: %v", err)
 	}
 
+	err = migrations.NormalizePhones(db, cfg.PhoneDefaultCountry)
+	if err != nil {
+		log.Fatalf("Failed to normalize phones: %v", err)
+	}
+
 	r := gin.Default()
-	routes.RegisterRoutes(r, db, redis, cfg, logger)
+	routes.RegisterRoutes(r, db, redis, keys, notify, sender, cfg, logger)
 
 	port := os.Getenv("PORT")
 	if port == "" {
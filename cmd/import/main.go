@@ -0,0 +1,113 @@
+// Command import loads users into a tenant from a CSV or NDJSON file, like
+// POST /users/import, and writes the JSON report of every row, even when the
+// file cannot be read to the end.
+//
+//	go run ./cmd/import -tenant default -file guests.csv -dry-run
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/database"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+)
+
+// formats maps the -format values and file extensions to media types.
+var formats = map[string]string{
+	"csv":    domain.CSVType,
+	"ndjson": domain.NDJSONType,
+	"jsonl":  domain.NDJSONType,
+}
+
+func main() {
+	tenantID := flag.String("tenant", domain.DefaultTenant, "tenant to import into")
+	file := flag.String("file", "", "file to import, - for stdin")
+	format := flag.String("format", "", "csv or ndjson, by default taken from the file extension")
+	dryRun := flag.Bool("dry-run", false, "validate and report without saving")
+	output := flag.String("report", "-", "where to write the report, - for stdout")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		*format = strings.TrimPrefix(filepath.Ext(*file), ".")
+	}
+	contentType, ok := formats[strings.ToLower(*format)]
+	if !ok {
+		log.Fatalf("Unknown format %q, use -format csv or -format ndjson", *format)
+	}
+
+	cfg := config.LoadConfig()
+	if !tenantAllowed(cfg, *tenantID) {
+		log.Fatalf("Unknown tenant %q", *tenantID)
+	}
+	if !domain.ValidPhoneCountry(cfg.PhoneDefaultCountry) {
+		log.Fatalf("Unsupported PhoneDefaultCountry: %q", cfg.PhoneDefaultCountry)
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatalf("Failed to open %s: %v", *file, err)
+		}
+		defer f.Close()
+		input = f
+	}
+
+	db, err := database.PostgresClient(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	redis := database.RedisClient(cfg)
+
+	importUsecase := usecases.NewImportUsecase(repositories.NewUserRepository(db), repositories.NewRedisRepository(redis), cfg)
+	// A file that cannot be read to the end still reports the rows before
+	// that point, which were saved.
+	report, importErr := importUsecase.Import(*tenantID, contentType, input, *dryRun)
+	if report == nil {
+		log.Fatalf("Import failed: %v", importErr)
+	}
+
+	out := os.Stdout
+	if *output != "-" {
+		if out, err = os.Create(*output); err != nil {
+			log.Fatalf("Failed to create %s: %v", *output, err)
+		}
+		defer out.Close()
+	}
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(domain.OutputImportReport(report)); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "created %d, updated %d, rejected %d (dry run: %t)\n",
+		report.Created, report.Updated, report.Rejected, report.DryRun)
+	if importErr != nil {
+		log.Fatalf("Import stopped: %v", importErr)
+	}
+}
+
+// tenantAllowed reports whether tenantID is listed in cfg.Tenants.
+func tenantAllowed(cfg config.Config, tenantID string) bool {
+	for _, allowed := range strings.Split(cfg.Tenants, ",") {
+		if allowed = strings.TrimSpace(allowed); allowed == tenantID && domain.ValidTenant(allowed) {
+			return true
+		}
+	}
+
+	return false
+}
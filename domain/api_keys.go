@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+)
+
+// APIKey lets a machine client call the API without a user session. Only the
+// prefix, which identifies the key, and a hash of the full key are stored.
+type APIKey struct {
+	ID         uuid.UUID
+	TenantID   string
+	Name       string
+	Prefix     string
+	SecretHash string       `json:"-"`
+	Scopes     []Permission `gorm:"serializer:json"`
+	CreatedBy  uuid.UUID
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (k *APIKey) Can(permission Permission) bool {
+	for _, scope := range k.Scopes {
+		if scope == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func OutputAPIKey(key *APIKey) *dto.APIKeyResponseDTO {
+	scopes := make([]string, len(key.Scopes))
+	for i, scope := range key.Scopes {
+		scopes[i] = string(scope)
+	}
+
+	return &dto.APIKeyResponseDTO{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     scopes,
+		CreatedBy:  key.CreatedBy,
+		CreatedAt:  key.CreatedAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
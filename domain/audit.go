@@ -0,0 +1,60 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+)
+
+const (
+	// AuditImpersonationStart is recorded when an admin obtains an
+	// impersonation token; Detail holds the reason they gave.
+	AuditImpersonationStart = "impersonation.start"
+	// AuditImpersonatedRequest is recorded for every request made with an
+	// impersonation token.
+	AuditImpersonatedRequest = "impersonation.request"
+	// AuditUserExported and AuditUserAnonymized record the answers to LGPD
+	// access and erasure requests.
+	AuditUserExported   = "privacy.export"
+	AuditUserAnonymized = "privacy.anonymize"
+)
+
+// AuditEntry records something ActorID did on behalf of, or to, UserID.
+type AuditEntry struct {
+	ID        uuid.UUID
+	TenantID  string
+	ActorID   uuid.UUID
+	UserID    uuid.UUID
+	Action    string
+	Method    string
+	Path      string
+	Status    int
+	IP        string
+	Detail    string
+	CreatedAt time.Time
+}
+
+// AuditFilter narrows an audit log listing of one tenant. Zero fields other
+// than TenantID match everything.
+type AuditFilter struct {
+	TenantID string
+	ActorID  uuid.UUID
+	UserID   uuid.UUID
+	Limit    int
+}
+
+func OutputAuditEntry(entry *AuditEntry) *dto.AuditEntryResponseDTO {
+	return &dto.AuditEntryResponseDTO{
+		ID:        entry.ID,
+		ActorID:   entry.ActorID,
+		UserID:    entry.UserID,
+		Action:    entry.Action,
+		Method:    entry.Method,
+		Path:      entry.Path,
+		Status:    entry.Status,
+		IP:        entry.IP,
+		Detail:    entry.Detail,
+		CreatedAt: entry.CreatedAt,
+	}
+}
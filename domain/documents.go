@@ -0,0 +1,219 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+// documentSeparators are the punctuation marks of formatted CPFs
+// (123.456.789-09) and CNPJs (11.222.333/0001-81).
+var documentSeparators = strings.NewReplacer(".", "", "-", "", "/", "", " ", "")
+
+// ParseDocument checks the check digits of a CPF or CNPJ and returns it in
+// canonical form: no punctuation and, for alphanumeric CNPJs, upper case.
+func ParseDocument(document string) (string, error) {
+	canonical := strings.ToUpper(documentSeparators.Replace(document))
+
+	switch len(canonical) {
+	case 11:
+		if !validCPF(canonical) {
+			return "", ErrInvalidCPF
+		}
+	case 14:
+		if !validCNPJ(canonical) {
+			return "", ErrInvalidCNPJ
+		}
+	default:
+		return "", ErrInvalidDocument
+	}
+
+	return canonical, nil
+}
+
+// NormalizeDocument returns the canonical form of document for lookups.
+// Values that are not a valid CPF or CNPJ are returned untouched, so they
+// simply match nothing stored after validation was introduced.
+func NormalizeDocument(document string) string {
+	if canonical, err := ParseDocument(document); err == nil {
+		return canonical
+	}
+
+	return document
+}
+
+func validCPF(cpf string) bool {
+	if !allDigits(cpf) || repeated(cpf) {
+		return false
+	}
+
+	return checkDigit(cpf[:9], 10, 11) == cpf[9] && checkDigit(cpf[:10], 11, 11) == cpf[10]
+}
+
+// validCNPJ also accepts the alphanumeric CNPJ, whose first twelve characters
+// may be letters weighing their ASCII code minus 48.
+func validCNPJ(cnpj string) bool {
+	for _, r := range cnpj[:12] {
+		if (r < '0' || r > '9') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	if !allDigits(cnpj[12:]) || repeated(cnpj) {
+		return false
+	}
+
+	return checkDigit(cnpj[:12], 5, 9) == cnpj[12] && checkDigit(cnpj[:13], 6, 9) == cnpj[13]
+}
+
+// checkDigit computes the modulo 11 check digit of value. Weights start at
+// first and count down to 2, wrapping back to top for CNPJs.
+func checkDigit(value string, first, top int) byte {
+	sum, weight := 0, first
+	for _, r := range value {
+		sum += int(r-'0') * weight
+		if weight--; weight < 2 {
+			weight = top
+		}
+	}
+
+	if rest := sum % 11; rest >= 2 {
+		return byte('0' + 11 - rest)
+	}
+
+	return '0'
+}
+
+func allDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// repeated reports numbers like 000.000.000-00, which pass the check digits.
+func repeated(value string) bool {
+	return strings.Count(value, value[:1]) == len(value)
+}
+
+// DocumentType is the kind of identity document a user registered with.
+type DocumentType string
+
+const (
+	DocumentCPF      DocumentType = "cpf"
+	DocumentCNPJ     DocumentType = "cnpj"
+	DocumentPassport DocumentType = "passport"
+	// DocumentRNE and DocumentCRNM are the registration cards of foreigners
+	// living in Brazil; the CRNM replaced the RNE in 2017.
+	DocumentRNE  DocumentType = "rne"
+	DocumentCRNM DocumentType = "crnm"
+)
+
+// Brazil is the issuing country of CPFs, CNPJs, RNEs and CRNMs.
+const Brazil = "BR"
+
+var (
+	passportNumber = regexp.MustCompile(`^[A-Z0-9]{5,9}$`)
+	// foreignerNumber is a letter, six digits and a check character, as
+	// printed on both the RNE and the CRNM.
+	foreignerNumber = regexp.MustCompile(`^[A-Z][0-9]{6}[0-9A-Z]$`)
+	countryCode     = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+func (t DocumentType) Valid() bool {
+	switch t {
+	case DocumentCPF, DocumentCNPJ, DocumentPassport, DocumentRNE, DocumentCRNM:
+		return true
+	}
+
+	return false
+}
+
+// IdentityDocument is a document number together with its type and the ISO
+// 3166-1 alpha-2 code of the issuing country.
+type IdentityDocument struct {
+	Type    DocumentType
+	Number  string
+	Country string
+}
+
+// NewIdentityDocument validates and normalizes a document. An empty docType
+// means a CPF or CNPJ, told apart by length. Brazilian documents always get
+// country BR; passports require the country. Problems are reported as a
+// *FieldError on "document", "document_type" or "document_country".
+func NewIdentityDocument(docType DocumentType, number, country string) (IdentityDocument, error) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+
+	switch docType {
+	case "", DocumentCPF, DocumentCNPJ:
+		canonical, err := ParseDocument(number)
+		if err != nil {
+			return IdentityDocument{}, &FieldError{Field: "document", Err: err}
+		}
+		inferred := DocumentCPF
+		if len(canonical) == 14 {
+			inferred = DocumentCNPJ
+		}
+		if docType != "" && docType != inferred {
+			return IdentityDocument{}, &FieldError{Field: "document", Err: ErrDocumentTypeMismatch}
+		}
+		if country != "" && country != Brazil {
+			return IdentityDocument{}, &FieldError{Field: "document_country", Err: ErrInvalidDocumentCountry}
+		}
+		return IdentityDocument{Type: inferred, Number: canonical, Country: Brazil}, nil
+	case DocumentRNE, DocumentCRNM:
+		canonical := strings.ToUpper(documentSeparators.Replace(number))
+		if !foreignerNumber.MatchString(canonical) {
+			return IdentityDocument{}, &FieldError{Field: "document", Err: ErrInvalidForeignerNumber}
+		}
+		if country != "" && country != Brazil {
+			return IdentityDocument{}, &FieldError{Field: "document_country", Err: ErrInvalidDocumentCountry}
+		}
+		return IdentityDocument{Type: docType, Number: canonical, Country: Brazil}, nil
+	case DocumentPassport:
+		canonical := strings.ToUpper(documentSeparators.Replace(number))
+		if !passportNumber.MatchString(canonical) {
+			return IdentityDocument{}, &FieldError{Field: "document", Err: ErrInvalidPassportNumber}
+		}
+		if !countryCode.MatchString(country) {
+			return IdentityDocument{}, &FieldError{Field: "document_country", Err: ErrInvalidDocumentCountry}
+		}
+		return IdentityDocument{Type: docType, Number: canonical, Country: country}, nil
+	}
+
+	return IdentityDocument{}, &FieldError{Field: "document_type", Err: ErrInvalidDocumentType}
+}
+
+// NormalizeDocumentNumber returns number as NewIdentityDocument would store
+// it for docType, for lookups. Without a type, a number that is no CPF or
+// CNPJ can only be a foreign document and is normalized like one. Invalid
+// numbers are returned untouched.
+func NormalizeDocumentNumber(docType DocumentType, number string) string {
+	if docType == "" {
+		docType = InferDocumentType(number)
+	}
+
+	switch docType {
+	case DocumentCPF, DocumentCNPJ:
+		return NormalizeDocument(number)
+	case "", DocumentPassport, DocumentRNE, DocumentCRNM:
+		return strings.ToUpper(documentSeparators.Replace(number))
+	}
+
+	return number
+}
+
+// InferDocumentType returns the type of an untyped document: CPF or CNPJ
+// when its check digits match, else "".
+func InferDocumentType(number string) DocumentType {
+	canonical, err := ParseDocument(number)
+	if err != nil {
+		return ""
+	}
+	if len(canonical) == 14 {
+		return DocumentCNPJ
+	}
+
+	return DocumentCPF
+}
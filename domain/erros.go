@@ -1,13 +1,67 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 var (
 	ErrUserNotFound             = errors.New("user not found")
 	ErrInvalidPassword          = errors.New("invalid password")
+	ErrInvalidCredentials       = errors.New("invalid credentials")
+	ErrInvalidRefreshToken      = errors.New("invalid refresh token")
+	ErrRefreshTokenReused       = errors.New("refresh token reused")
+	ErrTokenRevoked             = errors.New("token revoked")
+	ErrInvalidRole              = errors.New("invalid role")
+	ErrNotAuthenticated         = errors.New("not authenticated")
+	ErrTooManyAttempts          = errors.New("too many attempts")
+	ErrAccountLocked            = errors.New("account temporarily locked")
+	ErrMFARequired              = errors.New("second factor required")
+	ErrInvalidMFAToken          = errors.New("invalid mfa token")
+	ErrInvalidMFACode           = errors.New("invalid mfa code")
+	ErrMFANotEnrolled           = errors.New("mfa not enrolled")
+	ErrMFAAlreadyEnabled        = errors.New("mfa already enabled")
+	ErrInvalidAPIKey            = errors.New("invalid api key")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrInvalidScope             = errors.New("invalid scope")
+	ErrInvalidOIDCState         = errors.New("invalid or expired oidc state")
+	ErrOIDCMissingClaim         = errors.New("id token lacks a required claim")
+	ErrInvalidResetToken        = errors.New("invalid or expired password reset token")
+	ErrInvalidOTP               = errors.New("invalid or expired code")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrImpersonationForbidden   = errors.New("impersonation not allowed")
+	ErrOIDCLinkRequired         = errors.New("an account with this document exists: sign in and link the provider account to it")
+	ErrOIDCAlreadyLinked        = errors.New("account already linked to another provider account")
+	ErrTargetOutranks           = errors.New("cannot act on a user of a higher role")
+	ErrUnknownTenant            = errors.New("unknown tenant")
+	ErrTenantMismatch           = errors.New("token belongs to another tenant")
+	ErrInvalidCursor            = errors.New("invalid cursor")
+	ErrInvalidDocument          = errors.New("document must be a valid CPF or CNPJ")
+	ErrInvalidCPF               = errors.New("invalid CPF check digits")
+	ErrInvalidCNPJ              = errors.New("invalid CNPJ check digits")
+	ErrInvalidDocumentType      = errors.New("document type must be cpf, cnpj, passport, rne or crnm")
+	ErrDocumentTypeMismatch     = errors.New("document does not match its type")
+	ErrInvalidPassportNumber    = errors.New("passport number must have 5 to 9 letters or digits")
+	ErrInvalidForeignerNumber   = errors.New("RNE/CRNM number must be a letter, six digits and a check character")
+	ErrInvalidDocumentCountry   = errors.New("invalid issuing country")
+	ErrInvalidPhone             = errors.New("phone must be a valid number, with country code or from the default country")
+	ErrInvalidPatch             = errors.New("invalid patch")
+	ErrPatchTestFailed          = errors.New("patch test operation failed")
+	ErrUnsupportedPatchType     = errors.New("unsupported patch media type")
+	ErrUserNotDeleted           = errors.New("user is not deleted")
+	ErrUserConflict             = errors.New("another active user has the same document or phone")
+	ErrUserAnonymized           = errors.New("anonymized users cannot be restored")
+	ErrVersionMismatch          = errors.New("user was changed since the given version")
+	ErrInvalidETag              = errors.New("If-Match must be * or a single entity tag")
+	ErrUnsupportedImportType    = errors.New("import must be CSV or NDJSON")
+	ErrInvalidImport            = errors.New("invalid import file")
+	ErrDuplicateImportRow       = errors.New("an earlier row has the same document or phone")
+	ErrImportNonGuest           = errors.New("only guests can be updated by an import")
+	ErrInvalidExportColumn      = errors.New("unknown export column")
 	ErrDatabaseConnectionFailed = errors.New("database connection failed")
 	ErrIDNotFound               = errors.New("id not found")
 	ErrGetUserByData            = errors.New("error getting user by data")
+	ErrAmbiguousDocument        = errors.New("document number matches more than one user: give its type and country")
 	ErrFindUser                 = errors.New("error user not found")
 	ErrToCreateUser             = errors.New("error creating user")
 )
@@ -16,3 +70,33 @@ type Errors struct {
 	Code    int    `json:"code"`
 	Message string `json:"message"`
 }
+
+// RetryError wraps an error that goes away on its own, telling the caller how
+// long to wait before trying again.
+type RetryError struct {
+	Err        error
+	RetryAfter time.Duration
+}
+
+func (e *RetryError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError reports that one input field failed a check, so the response can
+// point at it. Field is the JSON name of the field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,39 @@
+package domain
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag returns the user's entity tag, its version in quotes.
+func (u *User) ETag() string {
+	return strconv.Quote(strconv.FormatInt(u.Version, 10))
+}
+
+// ParseIfMatch returns the version an If-Match header requires, 0 when the
+// header is empty or * and so requires none. Weak tags never match, as
+// If-Match compares strongly, and neither do tags that are not one of ours:
+// both are reported as ErrVersionMismatch. Lists of tags are not supported
+// and are reported as ErrInvalidETag, like malformed headers.
+func ParseIfMatch(header string) (int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+	if strings.Contains(header, ",") {
+		return 0, ErrInvalidETag
+	}
+
+	weak := strings.HasPrefix(header, "W/")
+	tag := strings.TrimPrefix(header, "W/")
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, ErrInvalidETag
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if weak || err != nil || version < 1 {
+		return 0, ErrVersionMismatch
+	}
+
+	return version, nil
+}
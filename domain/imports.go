@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+)
+
+// Media types of the files accepted by a user import.
+const (
+	CSVType    = "text/csv"
+	NDJSONType = "application/x-ndjson"
+)
+
+// ImportAction tells what an import did with one row.
+type ImportAction string
+
+const (
+	ImportCreated  ImportAction = "created"
+	ImportUpdated  ImportAction = "updated"
+	ImportRejected ImportAction = "rejected"
+)
+
+// ImportRow is the outcome of one row of an import file. Line is the line it
+// starts on; Errors, keyed by field or "row", is only set when it was
+// rejected.
+type ImportRow struct {
+	Line     int
+	Document string
+	Action   ImportAction
+	UserID   uuid.UUID
+	Errors   map[string]string
+}
+
+// ImportReport sums up an import, row by row. In a dry run nothing is saved
+// but the rows are reported as they would have been.
+type ImportReport struct {
+	DryRun   bool
+	Created  int
+	Updated  int
+	Rejected int
+	Rows     []*ImportRow
+}
+
+// Add records the outcome of a row.
+func (r *ImportReport) Add(row *ImportRow) {
+	switch row.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportRejected:
+		r.Rejected++
+	}
+	r.Rows = append(r.Rows, row)
+}
+
+// UpsertResult is what UserRepository.UpsertUsers did with one user: Err is
+// set when it was rejected, Created tells an insert from an update.
+type UpsertResult struct {
+	Created bool
+	Err     error
+}
+
+func OutputImportReport(report *ImportReport) *dto.ImportReportDTO {
+	output := &dto.ImportReportDTO{
+		DryRun:   report.DryRun,
+		Created:  report.Created,
+		Updated:  report.Updated,
+		Rejected: report.Rejected,
+		Rows:     make([]*dto.ImportRowDTO, len(report.Rows)),
+	}
+	for i, row := range report.Rows {
+		output.Rows[i] = &dto.ImportRowDTO{
+			Line:     row.Line,
+			Document: row.Document,
+			Action:   string(row.Action),
+			Errors:   row.Errors,
+		}
+		if row.UserID != uuid.Nil {
+			output.Rows[i].UserID = &row.UserID
+		}
+	}
+
+	return output
+}
@@ -0,0 +1,304 @@
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Media types of the PATCH bodies understood by ApplyPatch.
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// ApplyPatch applies patch, of media type patchType, to the JSON document
+// doc. Malformed patches and paths that do not exist are reported as
+// ErrInvalidPatch, a failed JSON Patch test as ErrPatchTestFailed.
+func ApplyPatch(patchType string, doc, patch []byte) ([]byte, error) {
+	switch patchType {
+	case MergePatchType:
+		return ApplyMergePatch(doc, patch)
+	case JSONPatchType:
+		return ApplyJSONPatch(doc, patch)
+	}
+
+	return nil, ErrUnsupportedPatchType
+}
+
+// ApplyMergePatch applies an RFC 7386 JSON Merge Patch to doc: objects are
+// merged member by member, null removes a member and any other value
+// replaces what was there.
+func ApplyMergePatch(doc, patch []byte) ([]byte, error) {
+	var target, changes interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(patch, &changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	return json.Marshal(mergePatch(target, changes))
+}
+
+func mergePatch(target, patch interface{}) interface{} {
+	members, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		object = map[string]interface{}{}
+	}
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+		} else {
+			object[name] = mergePatch(object[name], value)
+		}
+	}
+
+	return object
+}
+
+// patchOperation is one step of a JSON Patch. Value is kept raw so a missing
+// value can be told apart from null.
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// ApplyJSONPatch applies an RFC 6902 JSON Patch to doc. The operations run in
+// order and the patch is all or nothing.
+func ApplyJSONPatch(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var operations []patchOperation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+
+	for i, operation := range operations {
+		var err error
+		if target, err = applyOperation(target, operation); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func applyOperation(doc interface{}, operation patchOperation) (interface{}, error) {
+	if operation.Path == nil {
+		return nil, fmt.Errorf("%w: missing path", ErrInvalidPatch)
+	}
+	path, err := parsePointer(*operation.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case "add", "replace", "test":
+		if operation.Value == nil {
+			return nil, fmt.Errorf("%w: %s without value", ErrInvalidPatch, operation.Op)
+		}
+		var value interface{}
+		if err := json.Unmarshal(operation.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+
+		switch operation.Op {
+		case "add":
+			return addValue(doc, path, value)
+		case "replace":
+			if doc, err = removeValue(doc, path); err != nil {
+				return nil, err
+			}
+			return addValue(doc, path, value)
+		}
+
+		current, err := getValue(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, fmt.Errorf("%w: %s", ErrPatchTestFailed, *operation.Path)
+		}
+		return doc, nil
+	case "remove":
+		return removeValue(doc, path)
+	case "move", "copy":
+		if operation.From == nil {
+			return nil, fmt.Errorf("%w: %s without from", ErrInvalidPatch, operation.Op)
+		}
+		from, err := parsePointer(*operation.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := getValue(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation.Op == "copy" {
+			return addValue(doc, path, deepCopy(value))
+		}
+		if *operation.Path == *operation.From {
+			return doc, nil
+		}
+		if strings.HasPrefix(*operation.Path, *operation.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move a value into itself", ErrInvalidPatch)
+		}
+		if doc, err = removeValue(doc, from); err != nil {
+			return nil, err
+		}
+		return addValue(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, operation.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into its unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+
+	return tokens, nil
+}
+
+func getValue(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+
+	return doc, nil
+}
+
+func child(doc interface{}, token string) (interface{}, error) {
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if value, ok := node[token]; ok {
+			return value, nil
+		}
+	case []interface{}:
+		if i, err := arrayIndex(token, len(node)-1); err == nil {
+			return node[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %q not found", ErrInvalidPatch, token)
+}
+
+// arrayIndex parses an array index token no greater than max.
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("%w: bad array index %q", ErrInvalidPatch, token)
+	}
+
+	return i, nil
+}
+
+// updateParent calls update on the container holding the last token of path
+// and stores the container it returns back into doc.
+func updateParent(doc interface{}, path []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+
+	node, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if node, err = updateParent(node, path[1:], update); err != nil {
+		return nil, err
+	}
+
+	switch parent := doc.(type) {
+	case map[string]interface{}:
+		parent[path[0]] = node
+	case []interface{}:
+		i, _ := arrayIndex(path[0], len(parent)-1)
+		parent[i] = node
+	}
+
+	return doc, nil
+}
+
+func addValue(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			node[token] = value
+			return node, nil
+		case []interface{}:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			i, err := arrayIndex(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		}
+
+		return nil, fmt.Errorf("%w: %q has no parent container", ErrInvalidPatch, token)
+	})
+}
+
+func removeValue(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	return updateParent(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch node := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := node[token]; ok {
+				delete(node, token)
+				return node, nil
+			}
+		case []interface{}:
+			if i, err := arrayIndex(token, len(node)-1); err == nil {
+				return append(node[:i], node[i+1:]...), nil
+			}
+		}
+
+		return nil, fmt.Errorf("%w: %q not found", ErrInvalidPatch, token)
+	})
+}
+
+func deepCopy(value interface{}) interface{} {
+	data, _ := json.Marshal(value)
+	var copied interface{}
+	_ = json.Unmarshal(data, &copied)
+
+	return copied
+}
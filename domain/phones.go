@@ -0,0 +1,155 @@
+package domain
+
+import "strings"
+
+// phoneCountry is the calling code of a country and how many digits its
+// national numbers have, without the trunk prefix.
+type phoneCountry struct {
+	code     string
+	min, max int
+}
+
+// phoneCountries covers Brazil, its neighbours and the usual origins of
+// foreign guests. Numbers of other countries are accepted in E.164 form
+// with only the general length check.
+var phoneCountries = map[string]phoneCountry{
+	"BR": {code: "55", min: 10, max: 11},
+	"AR": {code: "54", min: 10, max: 11},
+	"UY": {code: "598", min: 8, max: 8},
+	"PY": {code: "595", min: 9, max: 9},
+	"CL": {code: "56", min: 9, max: 9},
+	"BO": {code: "591", min: 8, max: 8},
+	"PE": {code: "51", min: 8, max: 9},
+	"CO": {code: "57", min: 10, max: 10},
+	"VE": {code: "58", min: 10, max: 10},
+	"EC": {code: "593", min: 8, max: 9},
+	"US": {code: "1", min: 10, max: 10},
+	"CA": {code: "1", min: 10, max: 10},
+	"MX": {code: "52", min: 10, max: 10},
+	"PT": {code: "351", min: 9, max: 9},
+	"ES": {code: "34", min: 9, max: 9},
+	"FR": {code: "33", min: 9, max: 9},
+	"IT": {code: "39", min: 6, max: 11},
+	"DE": {code: "49", min: 6, max: 13},
+	"GB": {code: "44", min: 9, max: 10},
+	"NL": {code: "31", min: 9, max: 9},
+	"CH": {code: "41", min: 9, max: 9},
+	"IL": {code: "972", min: 8, max: 9},
+	"CN": {code: "86", min: 10, max: 11},
+	"JP": {code: "81", min: 9, max: 10},
+}
+
+// brazilianAreaCodes are the DDDs in use.
+var brazilianAreaCodes = map[string]bool{}
+
+func init() {
+	for _, ddd := range strings.Fields(`11 12 13 14 15 16 17 18 19 21 22 24 27 28
+		31 32 33 34 35 37 38 41 42 43 44 45 46 47 48 49 51 53 54 55
+		61 62 63 64 65 66 67 68 69 71 73 74 75 77 79
+		81 82 83 84 85 86 87 88 89 91 92 93 94 95 96 97 98 99`) {
+		brazilianAreaCodes[ddd] = true
+	}
+}
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "", "/", "")
+
+// ValidPhoneCountry reports whether country can be used as the default
+// country of national phone numbers.
+func ValidPhoneCountry(country string) bool {
+	_, ok := phoneCountries[strings.ToUpper(country)]
+	return ok
+}
+
+// NormalizePhone parses a phone number typed in any usual way and returns it
+// in E.164 form (+5548999990000). Numbers without a country code, with or
+// without the trunk 0, are taken to be from defaultCountry.
+func NormalizePhone(phone, defaultCountry string) (string, error) {
+	digits := phoneSeparators.Replace(strings.TrimSpace(phone))
+
+	switch {
+	case strings.HasPrefix(digits, "+"):
+		digits = digits[1:]
+	case strings.HasPrefix(digits, "00"):
+		digits = digits[2:]
+	default:
+		country, ok := phoneCountries[strings.ToUpper(defaultCountry)]
+		if !ok || !allDigits(digits) {
+			return "", ErrInvalidPhone
+		}
+		digits = nationalToInternational(digits, country)
+	}
+
+	if digits == "" || !allDigits(digits) || digits[0] == '0' || len(digits) < 8 || len(digits) > 15 {
+		return "", ErrInvalidPhone
+	}
+	if !plausiblePhone(digits) {
+		return "", ErrInvalidPhone
+	}
+
+	return "+" + digits, nil
+}
+
+// NormalizePhoneLookup returns phone in E.164 form for lookups, or phone
+// itself, trimmed, when it cannot be parsed, which then simply matches
+// nothing.
+func NormalizePhoneLookup(phone, defaultCountry string) string {
+	if normalized, err := NormalizePhone(phone, defaultCountry); err == nil {
+		return normalized
+	}
+
+	return strings.TrimSpace(phone)
+}
+
+// nationalToInternational prefixes a national number with the calling code.
+// It drops the trunk 0 and, in Brazil, the carrier selection code that may
+// follow it (0 XX 48 99999-0000). Numbers that already start with the
+// calling code and are too long to be national are left as they are.
+func nationalToInternational(digits string, country phoneCountry) string {
+	if strings.HasPrefix(digits, "0") {
+		digits = digits[1:]
+		if country.code == "55" && len(digits) > country.max {
+			digits = digits[2:]
+		}
+	}
+	if len(digits) > country.max && strings.HasPrefix(digits, country.code) {
+		return digits
+	}
+
+	return country.code + digits
+}
+
+// plausiblePhone checks the length of the national number for the countries
+// it knows and, for Brazil, the area code and the first digit. The calling
+// codes in phoneCountries are prefix-free, so at most one of them matches.
+func plausiblePhone(digits string) bool {
+	if strings.HasPrefix(digits, "55") {
+		return plausibleBrazilianPhone(digits[2:])
+	}
+
+	for _, country := range phoneCountries {
+		if strings.HasPrefix(digits, country.code) {
+			national := len(digits) - len(country.code)
+			return national >= country.min && national <= country.max
+		}
+	}
+
+	return true
+}
+
+// plausibleBrazilianPhone accepts mobiles (9 digits starting with 9) and
+// landlines (8 digits starting with 2 to 5) after a DDD in use.
+func plausibleBrazilianPhone(national string) bool {
+	if !brazilianAreaCodes[national[:2]] {
+		return false
+	}
+
+	subscriber := national[2:]
+	switch len(subscriber) {
+	case 9:
+		return subscriber[0] == '9'
+	case 8:
+		return subscriber[0] >= '2' && subscriber[0] <= '5'
+	}
+
+	return false
+}
@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+)
+
+// UserDataExport gathers everything stored about a user: the profile, the
+// audit entries they are the actor or subject of, their live sessions and
+// the API keys they created.
+type UserDataExport struct {
+	User         *User
+	AuditEntries []*AuditEntry
+	Sessions     []*Session
+	APIKeys      []*APIKey
+	ExportedAt   time.Time
+}
+
+func OutputUserDataExport(export *UserDataExport) *dto.UserDataExportDTO {
+	output := &dto.UserDataExportDTO{
+		ExportedAt: export.ExportedAt,
+		Profile: &dto.UserProfileExportDTO{
+			UserResponseDTO: *OutputUser(export.User),
+			MFAEnabled:      export.User.MFAEnabled,
+			OIDCLinked:      export.User.OIDCSubject != "",
+			UpdatedAt:       export.User.UpdatedAt,
+		},
+		AuditEntries: make([]*dto.AuditEntryResponseDTO, len(export.AuditEntries)),
+		Sessions:     make([]*dto.SessionResponseDTO, len(export.Sessions)),
+		APIKeys:      make([]*dto.APIKeyResponseDTO, len(export.APIKeys)),
+	}
+	for i, entry := range export.AuditEntries {
+		output.AuditEntries[i] = OutputAuditEntry(entry)
+	}
+	for i, session := range export.Sessions {
+		output.Sessions[i] = OutputSession(session, false)
+	}
+	for i, key := range export.APIKeys {
+		output.APIKeys[i] = OutputAPIKey(key)
+	}
+
+	return output
+}
@@ -0,0 +1,69 @@
+package domain
+
+type Role string
+
+const (
+	RoleAdmin Role = "admin"
+	RoleStaff Role = "staff"
+	RoleGuest Role = "guest"
+)
+
+type Permission string
+
+const (
+	PermUsersRead      Permission = "users:read"
+	PermUsersWrite     Permission = "users:write"
+	PermUsersDelete    Permission = "users:delete"
+	PermSessionsRevoke Permission = "sessions:revoke"
+	PermUsersUnlock    Permission = "users:unlock"
+	PermRolesManage    Permission = "roles:manage"
+	PermAPIKeysManage  Permission = "api_keys:manage"
+	PermUsersPurge     Permission = "users:purge"
+	PermImpersonate    Permission = "users:impersonate"
+	PermAuditRead      Permission = "audit:read"
+	PermUsersPrivacy   Permission = "users:privacy"
+	PermUsersImport    Permission = "users:import"
+	PermUsersExport    Permission = "users:export"
+)
+
+// rolePermissions lists what each role may do on records other than its own.
+// Guests get nothing here: they can only act on themselves.
+var rolePermissions = map[Role][]Permission{
+	RoleAdmin: {PermUsersRead, PermUsersWrite, PermUsersDelete, PermUsersPurge, PermSessionsRevoke, PermUsersUnlock, PermRolesManage, PermAPIKeysManage, PermImpersonate, PermAuditRead, PermUsersPrivacy, PermUsersImport, PermUsersExport},
+	RoleStaff: {PermUsersRead, PermUsersWrite},
+	RoleGuest: {},
+}
+
+// roleRanks orders the roles from the least to the most trusted.
+var roleRanks = map[Role]int{
+	RoleGuest: 1,
+	RoleStaff: 2,
+	RoleAdmin: 3,
+}
+
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Manages reports whether r may act on the records of users with the target
+// role, which must not rank above it: permissions like users:write reach
+// other users of the caller's rank or lower, never those above.
+func (r Role) Manages(target Role) bool {
+	return roleRanks[r] >= roleRanks[target]
+}
+
+// Valid reports whether p is a permission some role can hold.
+func (p Permission) Valid() bool {
+	return RoleAdmin.Can(p)
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+)
+
+// ClientInfo describes where a request comes from.
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+// Session is one login on one device. Its ID is the refresh token family, so
+// every access and refresh token issued from that login belongs to it.
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	TenantID   string    `json:"tenant_id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+func OutputSession(session *Session, current bool) *dto.SessionResponseDTO {
+	return &dto.SessionResponseDTO{
+		ID:         session.ID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
+		Current:    current,
+	}
+}
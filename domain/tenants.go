@@ -0,0 +1,15 @@
+package domain
+
+import "regexp"
+
+// DefaultTenant is the property that rows created before multi-tenancy belong
+// to, and the one a request is served for when it names none.
+const DefaultTenant = "default"
+
+var tenantPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ValidTenant reports whether id is a well-formed tenant identifier: a
+// lowercase slug such as "pousada-do-sol".
+func ValidTenant(id string) bool {
+	return tenantPattern.MatchString(id)
+}
@@ -0,0 +1,87 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// userExportColumns gives the value of each column a user export can hold.
+// Dates are RFC 3339 in UTC; values a user does not have are nil.
+var userExportColumns = map[string]func(*User) interface{}{
+	"id":               func(u *User) interface{} { return u.ID.String() },
+	"name":             func(u *User) interface{} { return u.Name },
+	"phone":            func(u *User) interface{} { return u.Phone },
+	"document":         func(u *User) interface{} { return u.Document },
+	"document_type":    func(u *User) interface{} { return string(u.DocumentType) },
+	"document_country": func(u *User) interface{} { return u.DocumentCountry },
+	"role":             func(u *User) interface{} { return string(u.Role) },
+	"version":          func(u *User) interface{} { return u.Version },
+	"created_at":       func(u *User) interface{} { return exportTime(&u.CreatedAt) },
+	"updated_at":       func(u *User) interface{} { return exportTime(&u.UpdatedAt) },
+	"deleted_at": func(u *User) interface{} {
+		if !u.DeletedAt.Valid {
+			return nil
+		}
+		return exportTime(&u.DeletedAt.Time)
+	},
+	"anonymized_at": func(u *User) interface{} { return exportTime(u.AnonymizedAt) },
+}
+
+// generatedUserExportColumns hold values the service generates or validates
+// itself, such as ids, E.164 phones and dates, rather than text as typed.
+var generatedUserExportColumns = map[string]bool{
+	"id": true, "phone": true, "document_type": true, "document_country": true, "role": true,
+	"version": true, "created_at": true, "updated_at": true, "deleted_at": true, "anonymized_at": true,
+}
+
+// UserExportColumnGenerated reports whether the values of the export column
+// are generated or validated by the service, so they cannot hold a formula.
+func UserExportColumnGenerated(column string) bool {
+	return generatedUserExportColumns[column]
+}
+
+// DefaultUserExportColumns are exported when no columns are asked for.
+var DefaultUserExportColumns = []string{"id", "name", "phone", "document", "document_type", "document_country", "role", "created_at"}
+
+func exportTime(at *time.Time) interface{} {
+	if at == nil || at.IsZero() {
+		return nil
+	}
+
+	return at.UTC().Format(time.RFC3339)
+}
+
+// ParseUserExportColumns reads a comma-separated list of export columns,
+// DefaultUserExportColumns when it is empty.
+func ParseUserExportColumns(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return DefaultUserExportColumns, nil
+	}
+
+	var columns []string
+	seen := map[string]bool{}
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if _, ok := userExportColumns[column]; !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidExportColumn, column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: %q repeated", ErrInvalidExportColumn, column)
+		}
+		seen[column] = true
+		columns = append(columns, column)
+	}
+
+	return columns, nil
+}
+
+// UserExportRow returns the values of the columns for user, in order.
+func UserExportRow(user *User, columns []string) []interface{} {
+	row := make([]interface{}, len(columns))
+	for i, column := range columns {
+		row[i] = userExportColumns[column](user)
+	}
+
+	return row
+}
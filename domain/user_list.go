@@ -0,0 +1,102 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Fields a user listing can be sorted by. Ties are always broken by ID so the
+// order, and therefore the cursors, stay stable.
+const (
+	UserSortCreatedAt = "created_at"
+	UserSortName      = "name"
+)
+
+// Which users a listing returns depending on whether they were deleted.
+const (
+	DeletedExclude = "exclude"
+	DeletedInclude = "include"
+	DeletedOnly    = "only"
+)
+
+// UserFilter selects a page of the users of one tenant. Zero fields other
+// than TenantID match everything; After continues a previous listing.
+type UserFilter struct {
+	TenantID    string
+	NamePrefix  string
+	Phone       string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Deleted     string
+	Sort        string
+	Desc        bool
+	Limit       int
+	After       *UserCursor
+	// WithTotal asks for the number of users matching the filter, which
+	// costs an extra count query.
+	WithTotal bool
+}
+
+// UserPage is one page of a user listing. NextCursor is empty on the last
+// page and Total is only set when the filter asked for it.
+type UserPage struct {
+	Users      []*User
+	NextCursor string
+	Total      *int64
+}
+
+// UserCursor points just past the last user of a page. It records the sort it
+// was taken from, since it means nothing under another one.
+type UserCursor struct {
+	Sort  string    `json:"s"`
+	Desc  bool      `json:"d,omitempty"`
+	Value string    `json:"v"`
+	ID    uuid.UUID `json:"id"`
+}
+
+// NewUserCursor returns the cursor that continues a listing sorted by sort
+// after user.
+func NewUserCursor(user *User, sort string, desc bool) *UserCursor {
+	cursor := &UserCursor{Sort: sort, Desc: desc, ID: user.ID}
+	if sort == UserSortName {
+		cursor.Value = user.Name
+	} else {
+		cursor.Value = user.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+
+	return cursor
+}
+
+// Encode returns the opaque form of the cursor handed to clients.
+func (c *UserCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeUserCursor parses a cursor produced by Encode.
+func DecodeUserCursor(value string) (*UserCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor UserCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	switch cursor.Sort {
+	case UserSortName:
+	case UserSortCreatedAt:
+		if _, err := time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	default:
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
@@ -9,31 +9,147 @@ import (
 )
 
 type User struct {
-	ID        uuid.UUID
-	Name      string
-	Phone     string
-	Document  string
+	ID uuid.UUID
+	// TenantID is the property (pousada) the user belongs to. Documents and
+	// phones are unique per tenant.
+	TenantID string
+	Name     string
+	Phone    string
+	// Document is the number of the user's identity document, of type
+	// DocumentType issued by DocumentCountry. Numbers are unique per tenant
+	// and type.
+	Document        string
+	DocumentType    DocumentType
+	DocumentCountry string
+	Role            Role
+	PasswordHash    string `json:"-"`
+	// TOTPSecret is set on enrollment and only enforced once MFAEnabled.
+	TOTPSecret         string `json:"-"`
+	MFAEnabled         bool
+	RecoveryCodeHashes []string `json:"-" gorm:"serializer:json"`
+	// OIDCSubject links the user to an account at the OIDC provider.
+	OIDCSubject string `json:"-"`
+	// AnonymizedAt is set once the personal data was scrubbed, see Anonymize.
+	AnonymizedAt *time.Time
+	// Version starts at 1 and goes up with every change to the user. It is
+	// the user's ETag, see ETag and ParseIfMatch.
+	Version   int64 `gorm:"default:1"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	DeletedAt gorm.DeletedAt
 }
 
-func NewUser(user *dto.UserDTO) (*User, error) {
+// NewUser builds a user from the input, storing the document in canonical
+// form and the phone in E.164, national numbers being from phoneCountry. An
+// invalid document or phone is reported as a *FieldError, see
+// NewIdentityDocument.
+func NewUser(user *dto.UserDTO, phoneCountry string) (*User, error) {
+	usr, err := NewUserChanges(&dto.UserPatchDTO{
+		Name:            user.Name,
+		Phone:           user.Phone,
+		Document:        user.Document,
+		DocumentType:    user.DocumentType,
+		DocumentCountry: user.DocumentCountry,
+	}, phoneCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	usr.ID = uuid.New()
+	usr.CreatedAt = time.Now()
+	usr.UpdatedAt = time.Now()
+
+	return usr, nil
+}
+
+// NewUserChanges validates and normalizes the editable fields of a user like
+// NewUser does, returning a User that holds only those fields, as expected
+// by UserRepository.UpdateUser.
+func NewUserChanges(changes *dto.UserPatchDTO, phoneCountry string) (*User, error) {
+	document, err := NewIdentityDocument(DocumentType(changes.DocumentType), changes.Document, changes.DocumentCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	phone, err := NormalizePhone(changes.Phone, phoneCountry)
+	if err != nil {
+		return nil, &FieldError{Field: "phone", Err: err}
+	}
+
 	return &User{
-		ID:        uuid.New(),
-		Name:      user.Name,
-		Phone:     user.Phone,
-		Document:  user.Document,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		Name:            changes.Name,
+		Phone:           phone,
+		Document:        document.Number,
+		DocumentType:    document.Type,
+		DocumentCountry: document.Country,
 	}, nil
 }
 
+// AnonymizedName replaces the name of anonymized users.
+const AnonymizedName = "Anonymized"
+
+// Anonymize irreversibly scrubs the personal data and credentials of the user
+// and deletes it, if it was not already. The ID, tenant, role, document type
+// and country and the dates are kept, so references and statistics still
+// hold. Phone and document get placeholders of the user's own so the unique
+// indexes are not tripped.
+func (u *User) Anonymize(at time.Time) {
+	placeholder := "anon-" + u.ID.String()
+	u.Name = AnonymizedName
+	u.Phone = placeholder
+	u.Document = placeholder
+	u.PasswordHash = ""
+	u.TOTPSecret = ""
+	u.MFAEnabled = false
+	u.RecoveryCodeHashes = nil
+	u.OIDCSubject = ""
+	u.AnonymizedAt = &at
+	if !u.DeletedAt.Valid {
+		u.DeletedAt = gorm.DeletedAt{Time: at, Valid: true}
+	}
+}
+
+// PatchableUser returns the editable fields of user, the document a PATCH is
+// applied to.
+func PatchableUser(user *User) *dto.UserPatchDTO {
+	return &dto.UserPatchDTO{
+		Name:            user.Name,
+		Phone:           user.Phone,
+		Document:        user.Document,
+		DocumentType:    string(user.DocumentType),
+		DocumentCountry: user.DocumentCountry,
+	}
+}
+
 func OutputUser(user *User) *dto.UserResponseDTO {
-	return &dto.UserResponseDTO{
-		ID:       user.ID,
-		Name:     user.Name,
-		Document: user.Document,
-		Phone:    user.Phone,
+	output := &dto.UserResponseDTO{
+		ID:              user.ID,
+		TenantID:        user.TenantID,
+		Name:            user.Name,
+		Document:        user.Document,
+		DocumentType:    string(user.DocumentType),
+		DocumentCountry: user.DocumentCountry,
+		Phone:           user.Phone,
+		Role:            string(user.Role),
+		CreatedAt:       user.CreatedAt,
+		AnonymizedAt:    user.AnonymizedAt,
+	}
+	if user.DeletedAt.Valid {
+		output.DeletedAt = &user.DeletedAt.Time
 	}
+
+	return output
+}
+
+func OutputUserPage(page *UserPage) *dto.UserPageResponseDTO {
+	output := &dto.UserPageResponseDTO{
+		Data:       make([]*dto.UserResponseDTO, len(page.Users)),
+		NextCursor: page.NextCursor,
+		Total:      page.Total,
+	}
+	for i, user := range page.Users {
+		output.Data[i] = OutputUser(user)
+	}
+
+	return output
 }
@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+const apiKeyPrefix = "pk_"
+
+// GenerateAPIKey returns a new key in the form "pk_<prefix>_<secret>" and its
+// prefix. The prefix is stored in clear to find the key; the full key is only
+// kept as a HashToken digest.
+func GenerateAPIKey() (key, prefix string, err error) {
+	id := make([]byte, 6)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix = hex.EncodeToString(id)
+	return apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+// ParseAPIKey returns the prefix of a key made by GenerateAPIKey, and false
+// when key does not have that shape.
+func ParseAPIKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+
+	return prefix, true
+}
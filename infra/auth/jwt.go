@@ -1,30 +1,61 @@
 package auth
 
 import (
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/ThailanTec/challenger/pousada/src/config"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
-	"github.com/spf13/viper"
 )
 
 type Claims struct {
-	UserID uuid.UUID `json:"user_id"`
+	UserID uuid.UUID   `json:"user_id"`
+	Role   domain.Role `json:"role"`
+	// TenantID is the property the token is valid for; UserID and Role only
+	// have meaning inside it.
+	TenantID string `json:"tenant_id"`
+	// MFAPending marks the intermediate token of a two-step login. It proves
+	// the password was right but must never be accepted as an access token.
+	MFAPending bool `json:"mfa_pending,omitempty"`
+	// SessionID is the login session (refresh token family) the token was
+	// issued for.
+	SessionID uuid.UUID `json:"sid"`
+	// ActorID is set on impersonation tokens: UserID and Role are those of
+	// the impersonated user and ActorID is the admin acting as them.
+	ActorID uuid.UUID `json:"actor_id"`
+	// APIKeyID and Scopes are only set, in process, when the caller
+	// authenticated with an API key instead of a JWT. Such a caller has no
+	// user and no role; it can do exactly what its scopes list.
+	APIKeyID uuid.UUID           `json:"-"`
+	Scopes   []domain.Permission `json:"-"`
+	// IssuedAtNano is iat in Unix nanoseconds, so a token issued right after
+	// a revocation can be told apart from those issued before it.
+	IssuedAtNano int64 `json:"iat_ns,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateJWT(userID uuid.UUID, cfg config.Config) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(cfg.JWTExpirationMinutes) * time.Minute)
-	claims := &Claims{
-		UserID: userID,
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
-		},
+// GenerateJWT signs the given claims with the active key. The jti and iat
+// standard claims are always set here, and exp too unless the caller already
+// chose one; any other field is up to the caller.
+func GenerateJWT(claims Claims, keys *KeySet, cfg config.Config) (string, error) {
+	now := time.Now()
+	claims.Id = uuid.NewString()
+	claims.IssuedAt = now.Unix()
+	claims.IssuedAtNano = now.UnixNano()
+	if claims.ExpiresAt == 0 {
+		claims.ExpiresAt = now.Add(time.Duration(cfg.JWTExpirationMinutes) * time.Minute).Unix()
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(cfg.JWTSecret))
+	key := keys.signingKey()
+	token := jwt.NewWithClaims(key.Method, &claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
 		return "", err
 	}
@@ -32,12 +63,24 @@ func GenerateJWT(userID uuid.UUID, cfg config.Config) (string, error) {
 	return tokenString, nil
 }
 
-func ValidateJWT(tokenString string, cfg config.Config) (*Claims, error) {
+func ValidateJWT(tokenString string, keys *KeySet) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenString, claims, func(_ *jwt.Token) (interface{}, error) {
-		return []byte(cfg.JWTSecret), nil
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.verificationKey(kid, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrAlgKeyMismatch
+		}
+		return key.Public, nil
 	})
 
+	var validationErr *jwt.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Inner != nil {
+		return nil, validationErr.Inner
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -49,12 +92,65 @@ func ValidateJWT(tokenString string, cfg config.Config) (*Claims, error) {
 	return claims, nil
 }
 
-func ParseToken(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.NewValidationError("Invalid signing method", jwt.ValidationErrorSignatureInvalid)
+// ExtractBearerToken returns the token from an "Authorization: Bearer <token>"
+// header value, and false when the header is not in that form.
+func ExtractBearerToken(header string) (string, bool) {
+	token := strings.TrimPrefix(header, "Bearer ")
+	if token == header || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+func (c *Claims) Can(permission domain.Permission) bool {
+	if c.IsAPIKey() {
+		for _, scope := range c.Scopes {
+			if scope == permission {
+				return true
+			}
 		}
-		secret := viper.GetString("JWTSecret")
-		return []byte(secret), nil
-	})
+		return false
+	}
+
+	return c.Role.Can(permission)
+}
+
+// Manages reports whether the caller may act on a user with the target role.
+// API keys have no role of their own and rank as staff, so no key reaches an
+// admin.
+func (c *Claims) Manages(target domain.Role) bool {
+	if c.IsAPIKey() {
+		return domain.RoleStaff.Manages(target)
+	}
+
+	return c.Role.Manages(target)
+}
+
+// IssuedAtUnixNano returns when the token was issued, in Unix nanoseconds.
+// Tokens without iat_ns date from the start of their iat second.
+func (c *Claims) IssuedAtUnixNano() int64 {
+	if c.IssuedAtNano != 0 {
+		return c.IssuedAtNano
+	}
+
+	return time.Unix(c.IssuedAt, 0).UnixNano()
+}
+
+func (c *Claims) IsAPIKey() bool {
+	return c.APIKeyID != uuid.Nil
+}
+
+func (c *Claims) IsImpersonation() bool {
+	return c.ActorID != uuid.Nil
+}
+
+// RemainingLifetime is how long until the token expires, never negative.
+func (c *Claims) RemainingLifetime() time.Duration {
+	remaining := time.Until(time.Unix(c.ExpiresAt, 0))
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining
 }
@@ -0,0 +1,324 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrUnknownKeyID    = errors.New("unknown signing key id")
+	ErrKeyExpired      = errors.New("signing key is past its grace period")
+	ErrNoSigningKey    = errors.New("no active signing key configured")
+	ErrUnsupportedKey  = errors.New("unsupported key type")
+	ErrAlgKeyMismatch  = errors.New("token alg does not match signing key")
+	ErrInvalidKeyEntry = errors.New("invalid key entry, expected id=value")
+)
+
+type SigningKey struct {
+	ID     string
+	Method jwt.SigningMethod
+	// Private is nil for keys that are only kept around for verification.
+	Private crypto.PrivateKey
+	Public  crypto.PublicKey
+	// RetiredAt is zero while the key is active. Retired keys no longer sign
+	// but are still accepted (and published) until the grace period ends.
+	RetiredAt time.Time
+	symmetric bool
+}
+
+// KeySet holds every key tokens may be signed or verified with. It is built
+// once at startup by LoadKeySet.
+type KeySet struct {
+	keys        map[string]*SigningKey
+	activeID    string
+	gracePeriod time.Duration
+}
+
+// LoadKeySet builds the key set from configuration. When cfg.JWTKeys is empty
+// tokens keep being signed with HS256 and cfg.JWTSecret.
+//
+// cfg.JWTKeys and cfg.JWTRetiredKeys are comma separated "kid=value" lists, the
+// value being a PEM file path and an RFC 3339 retirement time respectively.
+func LoadKeySet(cfg config.Config) (*KeySet, error) {
+	if cfg.JWTKeys == "" {
+		return NewKeySet("", 0, &SigningKey{
+			Method:    jwt.SigningMethodHS256,
+			Private:   []byte(cfg.JWTSecret),
+			Public:    []byte(cfg.JWTSecret),
+			symmetric: true,
+		})
+	}
+
+	paths, err := parseKeyEntries(cfg.JWTKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*SigningKey, len(paths))
+	for kid, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %q: %w", kid, err)
+		}
+
+		keys[kid], err = ParsePEMKey(kid, data)
+		if err != nil {
+			return nil, fmt.Errorf("parsing key %q: %w", kid, err)
+		}
+	}
+
+	retired, err := parseKeyEntries(cfg.JWTRetiredKeys)
+	if err != nil {
+		return nil, err
+	}
+	for kid, at := range retired {
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("retiring key %q: %w", kid, ErrUnknownKeyID)
+		}
+		key.RetiredAt, err = time.Parse(time.RFC3339, at)
+		if err != nil {
+			return nil, fmt.Errorf("retiring key %q: %w", kid, err)
+		}
+	}
+
+	list := make([]*SigningKey, 0, len(keys))
+	for _, key := range keys {
+		list = append(list, key)
+	}
+
+	return NewKeySet(cfg.JWTActiveKeyID, cfg.JWTKeyGracePeriod, list...)
+}
+
+// NewKeySet builds a key set from already parsed keys, mostly for tests and
+// tooling. activeID must name one of keys holding a private key.
+func NewKeySet(activeID string, gracePeriod time.Duration, keys ...*SigningKey) (*KeySet, error) {
+	set := &KeySet{
+		keys:        make(map[string]*SigningKey, len(keys)),
+		activeID:    activeID,
+		gracePeriod: gracePeriod,
+	}
+	for _, key := range keys {
+		set.keys[key.ID] = key
+	}
+
+	active, ok := set.keys[activeID]
+	if !ok || active.Private == nil || !active.RetiredAt.IsZero() {
+		return nil, ErrNoSigningKey
+	}
+
+	return set, nil
+}
+
+// ParsePEMKey reads a private key (PKCS#8, PKCS#1 or SEC 1) or a PKIX public
+// key and picks the JWT algorithm matching its type.
+func ParsePEMKey(kid string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var (
+		private crypto.PrivateKey
+		public  crypto.PublicKey
+		err     error
+	)
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%w: PEM type %q", ErrUnsupportedKey, block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if signer, ok := private.(crypto.Signer); ok {
+		public = signer.Public()
+	}
+
+	method, err := methodFor(public)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SigningKey{ID: kid, Method: method, Private: private, Public: public}, nil
+}
+
+func methodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch key := public.(type) {
+	case *rsa.PublicKey:
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch key.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PublicKey:
+		return SigningMethodEdDSA, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+func (s *KeySet) signingKey() *SigningKey {
+	return s.keys[s.activeID]
+}
+
+func (s *KeySet) verificationKey(kid string, now time.Time) (*SigningKey, error) {
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	if s.expired(key, now) {
+		return nil, ErrKeyExpired
+	}
+
+	return key, nil
+}
+
+func (s *KeySet) expired(key *SigningKey, now time.Time) bool {
+	return !key.RetiredAt.IsZero() && now.After(key.RetiredAt.Add(s.gracePeriod))
+}
+
+// JWK is a public key in RFC 7517 format.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKey decodes the key material, the inverse of what KeySet.JWKS does.
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	decode := base64.RawURLEncoding.DecodeString
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		curves := map[string]elliptic.Curve{"P-256": elliptic.P256(), "P-384": elliptic.P384(), "P-521": elliptic.P521()}
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, ErrUnsupportedKey
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		x, err := decode(k.X)
+		if err != nil || k.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return ed25519.PublicKey(x), nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+// JWKS returns the public half of every key that can still verify tokens.
+// Symmetric keys are never published.
+func (s *KeySet) JWKS() JWKS {
+	now := time.Now()
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range s.keys {
+		if key.symmetric || s.expired(key, now) {
+			continue
+		}
+
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(pub.Y.FillBytes(make([]byte, size)))
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		default:
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	return jwks
+}
+
+func parseKeyEntries(raw string) (map[string]string, error) {
+	entries := map[string]string{}
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, value, ok := strings.Cut(entry, "=")
+		if !ok || kid == "" || value == "" {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidKeyEntry, entry)
+		}
+		entries[strings.TrimSpace(kid)] = strings.TrimSpace(value)
+	}
+
+	return entries, nil
+}
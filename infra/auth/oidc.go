@@ -0,0 +1,312 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/dgrijalva/jwt-go"
+)
+
+var (
+	ErrOIDCDisabled       = errors.New("oidc is not configured")
+	ErrOIDCIssuerMismatch = errors.New("oidc discovery issuer does not match configuration")
+	ErrInvalidIDToken     = errors.New("invalid id token")
+)
+
+const oidcHTTPTimeout = 10 * time.Second
+
+// OIDCProvider is a minimal OpenID Connect relying party for the
+// authorization code flow with PKCE. Provider metadata and signing keys are
+// discovered on first use and cached; keys are fetched again when an ID token
+// names a kid we have not seen, which is how providers roll their keys.
+type OIDCProvider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	scopes       []string
+	client       *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*SigningKey
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDTokenClaims are the verified claims of an ID token. Raw keeps every claim
+// so callers can read provider specific ones.
+type IDTokenClaims struct {
+	Issuer      string `json:"iss"`
+	Subject     string `json:"sub"`
+	Nonce       string `json:"nonce"`
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	PhoneNumber string `json:"phone_number"`
+	Raw         map[string]interface{}
+}
+
+// NewOIDCProvider returns nil when no issuer is configured, meaning OIDC login
+// is disabled.
+func NewOIDCProvider(cfg config.Config) *OIDCProvider {
+	if cfg.OIDCIssuer == "" {
+		return nil
+	}
+
+	return &OIDCProvider{
+		issuer:       strings.TrimSuffix(cfg.OIDCIssuer, "/"),
+		clientID:     cfg.OIDCClientID,
+		clientSecret: cfg.OIDCClientSecret,
+		redirectURL:  cfg.OIDCRedirectURL,
+		scopes:       strings.Fields(cfg.OIDCScopes),
+		client:       &http.Client{Timeout: oidcHTTPTimeout},
+	}
+}
+
+// GeneratePKCE returns a random code verifier and its S256 challenge.
+func GeneratePKCE() (verifier, challenge string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	verifier = base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(verifier))
+
+	return verifier, base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// AuthCodeURL is where the browser is sent to sign in at the provider.
+func (p *OIDCProvider) AuthCodeURL(state, nonce, codeChallenge string) (string, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid"}
+	}
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.clientID)
+	query.Set("redirect_uri", p.redirectURL)
+	query.Set("scope", strings.Join(scopes, " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange redeems an authorization code and returns the verified ID token
+// claims. nonce must be the one sent in AuthCodeURL.
+func (p *OIDCProvider) Exchange(code, codeVerifier, nonce string) (*IDTokenClaims, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.redirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := p.do(req, &tokens); err != nil {
+		return nil, fmt.Errorf("oidc token exchange: %w", err)
+	}
+	if tokens.IDToken == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return p.VerifyIDToken(tokens.IDToken, nonce)
+}
+
+// VerifyIDToken checks the signature, issuer, audience, expiry and nonce of an
+// ID token.
+func (p *OIDCProvider) VerifyIDToken(rawIDToken, nonce string) (*IDTokenClaims, error) {
+	mapClaims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, mapClaims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, err := p.signingKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, ErrAlgKeyMismatch
+		}
+		return key.Public, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	raw, err := json.Marshal(mapClaims)
+	if err != nil {
+		return nil, err
+	}
+	claims := &IDTokenClaims{Raw: mapClaims}
+	if err := json.Unmarshal(raw, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	if claims.Issuer != p.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidIDToken, claims.Issuer)
+	}
+	if !mapClaims.VerifyAudience(p.clientID, true) && !audienceContains(mapClaims["aud"], p.clientID) {
+		return nil, fmt.Errorf("%w: audience mismatch", ErrInvalidIDToken)
+	}
+	if _, ok := mapClaims["exp"]; !ok {
+		return nil, fmt.Errorf("%w: missing exp", ErrInvalidIDToken)
+	}
+	if claims.Subject == "" || claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	return claims, nil
+}
+
+// String returns a claim as a string, or "" when it is missing or not a
+// string.
+func (c *IDTokenClaims) String(name string) string {
+	value, _ := c.Raw[name].(string)
+	return value
+}
+
+// audienceContains handles the array form of aud, which jwt-go v3 does not.
+func audienceContains(aud interface{}, clientID string) bool {
+	list, ok := aud.([]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, value := range list {
+		if value == clientID {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *OIDCProvider) discover() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var discovery oidcDiscovery
+	if err := p.do(req, &discovery); err != nil {
+		return nil, fmt.Errorf("oidc discovery: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.issuer {
+		return nil, ErrOIDCIssuerMismatch
+	}
+
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) signingKey(kid string) (*SigningKey, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks JWKS
+	if err := p.do(req, &jwks); err != nil {
+		return nil, fmt.Errorf("oidc jwks: %w", err)
+	}
+
+	keys := map[string]*SigningKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		method, err := methodFor(public)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &SigningKey{ID: jwk.Kid, Method: method, Public: public}
+	}
+	p.keys = keys
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, ErrUnknownKeyID
+	}
+
+	return key, nil
+}
+
+func (p *OIDCProvider) do(req *http.Request, out interface{}) error {
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, req.URL.Path)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
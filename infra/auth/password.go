@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyHash is compared against when the user has no stored hash so that a
+// failed login takes roughly the same time whether or not the user exists.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func CheckPassword(hash, password string) error {
+	if hash == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return bcrypt.ErrMismatchedHashAndPassword
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"time"
+)
+
+type APIKey struct {
+	ID         string `gorm:"type:uuid;primary_key;"`
+	TenantID   string `gorm:"not null;default:'default';index"`
+	Name       string `gorm:"not null"`
+	Prefix     string `gorm:"uniqueIndex;not null"`
+	SecretHash string `gorm:"not null"`
+	Scopes     string `gorm:"type:text;not null"`
+	CreatedBy  string `gorm:"type:uuid;not null"`
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"time"
+)
+
+type AuditEntry struct {
+	ID        string    `gorm:"type:uuid;primary_key;"`
+	TenantID  string    `gorm:"not null;default:'default';index"`
+	ActorID   string    `gorm:"type:uuid;not null;index"`
+	UserID    string    `gorm:"type:uuid;not null;index"`
+	Action    string    `gorm:"not null"`
+	Method    string    `gorm:"not null;default:''"`
+	Path      string    `gorm:"not null;default:''"`
+	Status    int       `gorm:"not null;default:0"`
+	IP        string    `gorm:"not null;default:''"`
+	Detail    string    `gorm:"not null;default:''"`
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,33 @@
+package migrations
+
+import (
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"gorm.io/gorm"
+)
+
+// NormalizePhones rewrites phones stored before they were normalized on input
+// to E.164, reading national numbers as from defaultCountry. Numbers that do
+// not parse, or whose normalized form another user of the tenant already
+// has, are left as they are for a manual fix.
+func NormalizePhones(db *gorm.DB, defaultCountry string) error {
+	var users []User
+	if err := db.Unscoped().Select("id", "tenant_id", "phone").Where("phone NOT LIKE '+%'").Find(&users).Error; err != nil {
+		return err
+	}
+
+	for _, user := range users {
+		phone, err := domain.NormalizePhone(user.Phone, defaultCountry)
+		if err != nil {
+			continue
+		}
+
+		err = db.Exec(`UPDATE users SET phone = ? WHERE id = ?
+			AND NOT EXISTS (SELECT 1 FROM users o WHERE o.tenant_id = ? AND o.phone = ?)`,
+			phone, user.ID, user.TenantID, phone).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
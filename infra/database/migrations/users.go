@@ -8,15 +8,89 @@ import (
 
 type User struct {
 	gorm.Model
-	ID        string `gorm:"type:uuid;primary_key;"`
-	Name      string `gorm:"not null"`
-	Phone     string `gorm:"unique;not null"`
-	Document  string `gorm:"unique;not null"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ID                 string `gorm:"type:uuid;primary_key;index:idx_users_tenant_created,priority:3;index:idx_users_tenant_name,priority:3"`
+	TenantID           string `gorm:"not null;default:'default';index:idx_users_active_identity,unique,priority:1,where:deleted_at IS NULL;index:idx_users_active_phone,unique,priority:1,where:deleted_at IS NULL;index:idx_users_tenant_oidc_subject,unique,priority:1,where:oidc_subject <> '';index:idx_users_tenant_created,priority:1;index:idx_users_tenant_name,priority:1"`
+	Name               string `gorm:"not null;index:idx_users_tenant_name,priority:2"`
+	Phone              string `gorm:"not null;index:idx_users_active_phone,unique,priority:2,where:deleted_at IS NULL"`
+	Document           string `gorm:"not null;index:idx_users_active_identity,unique,priority:4,where:deleted_at IS NULL"`
+	DocumentType       string `gorm:"not null;default:'';index:idx_users_active_identity,unique,priority:2,where:deleted_at IS NULL"`
+	DocumentCountry    string `gorm:"not null;default:'';index:idx_users_active_identity,unique,priority:3,where:deleted_at IS NULL"`
+	Role               string `gorm:"not null;default:'guest'"`
+	PasswordHash       string `gorm:"not null;default:''"`
+	TOTPSecret         string `gorm:"not null;default:''"`
+	MFAEnabled         bool   `gorm:"not null;default:false"`
+	RecoveryCodeHashes string `gorm:"type:text"`
+	OIDCSubject        string `gorm:"not null;default:'';index:idx_users_tenant_oidc_subject,unique,priority:2,where:oidc_subject <> ''"`
+	AnonymizedAt       *time.Time
+	Version            int64     `gorm:"not null;default:1"`
+	CreatedAt          time.Time `gorm:"index:idx_users_tenant_created,priority:2"`
+	UpdatedAt          time.Time
+	DeletedAt          gorm.DeletedAt `gorm:"index"`
+}
+
+// globalUniqueConstraints were created before users were scoped to a tenant
+// and would stop the same document or phone from existing in two tenants.
+// Both the constraint names of older and newer GORM versions are listed.
+var globalUniqueConstraints = []string{
+	"users_document_key",
+	"users_phone_key",
+	"uni_users_document",
+	"uni_users_phone",
 }
 
 func Migrate(db *gorm.DB) error {
-	return db.AutoMigrate(&User{})
+	if err := db.AutoMigrate(&User{}, &APIKey{}, &AuditEntry{}); err != nil {
+		return err
+	}
+
+	for _, name := range globalUniqueConstraints {
+		if err := db.Exec("ALTER TABLE users DROP CONSTRAINT IF EXISTS " + name).Error; err != nil {
+			return err
+		}
+	}
+
+	// idx_users_tenant_document predates document types and was replaced by
+	// idx_users_tenant_document_type, which like idx_users_tenant_phone also
+	// counted deleted users and gave way to the partial idx_users_active_*.
+	// idx_users_active_document left out the country, so passports with the
+	// same number from two countries collided; idx_users_active_identity has it.
+	for _, name := range []string{"idx_users_oidc_subject", "idx_users_tenant_document", "idx_users_tenant_document_type", "idx_users_tenant_phone", "idx_users_active_document"} {
+		if err := db.Exec("DROP INDEX IF EXISTS " + name).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := db.Exec(normalizeDocuments).Error; err != nil {
+		return err
+	}
+
+	return db.Exec(typeDocuments).Error
 }
+
+// typeDocuments fills in the type of documents stored before they had one.
+// Anything that is not shaped like a CPF or CNPJ keeps an empty type and is
+// only found by untyped lookups until it is corrected.
+const typeDocuments = `
+UPDATE users SET
+	document_type = CASE WHEN length(document) = 11 THEN 'cpf' ELSE 'cnpj' END,
+	document_country = 'BR'
+WHERE document_type = ''
+	AND (document ~ '^[0-9]{11}$' OR document ~ '^[0-9A-Z]{12}[0-9]{2}$')`
+
+// normalizeDocuments strips the punctuation of CPFs and CNPJs stored before
+// they were normalized on input. When several rows of a tenant collapse into the
+// same document only the oldest is rewritten, and none is when the canonical
+// document already exists: those duplicates are left for a manual merge.
+const normalizeDocuments = `
+UPDATE users SET document = n.canonical
+FROM (
+	SELECT DISTINCT ON (tenant_id, canonical) id, canonical
+	FROM (
+		SELECT id, tenant_id, created_at, document, upper(regexp_replace(document, '[./ -]', '', 'g')) AS canonical
+		FROM users
+	) formatted
+	WHERE canonical <> document AND length(canonical) IN (11, 14)
+	ORDER BY tenant_id, canonical, created_at
+) n
+WHERE users.id = n.id
+	AND NOT EXISTS (SELECT 1 FROM users o WHERE o.tenant_id = users.tenant_id AND o.document = n.canonical)`
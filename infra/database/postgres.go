@@ -13,7 +13,10 @@ import (
 func PostgresClient(cfg config.Config) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		cfg.DBHost, cfg.DBUsername, cfg.DBPassword, cfg.DBName, cfg.DBPort)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// TranslateError turns unique violations into gorm.ErrDuplicatedKey.
+		TranslateError: true,
+	})
 	if err != nil {
 		return nil, domain.ErrDatabaseConnectionFailed
 	}
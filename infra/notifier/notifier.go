@@ -0,0 +1,91 @@
+package notifier
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+var ErrUnknownNotifier = errors.New("unknown notifier type")
+
+// Message is a notification for a single user. Implementations pick the
+// channel and address from the user's contact data.
+type Message struct {
+	UserID  uuid.UUID `json:"user_id"`
+	Name    string    `json:"name"`
+	Phone   string    `json:"phone"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+}
+
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// New builds the notifier selected by cfg.NotifierType. "log" and "file" are
+// meant for local development: they never reach the user.
+func New(cfg config.Config, logger *zap.Logger) (Notifier, error) {
+	switch cfg.NotifierType {
+	case "", "log":
+		return NewLogNotifier(logger), nil
+	case "file":
+		return NewFileNotifier(cfg.NotifierFile), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownNotifier, cfg.NotifierType)
+	}
+}
+
+type logNotifier struct {
+	logger *zap.Logger
+}
+
+func NewLogNotifier(logger *zap.Logger) Notifier {
+	return &logNotifier{logger: logger}
+}
+
+func (n *logNotifier) Notify(msg Message) error {
+	n.logger.Info("Notification",
+		zap.String("user_id", msg.UserID.String()),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body))
+	return nil
+}
+
+// fileNotifier appends every message to a file as one JSON object per line.
+type fileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) Notifier {
+	return &fileNotifier{path: path}
+}
+
+func (n *fileNotifier) Notify(msg Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
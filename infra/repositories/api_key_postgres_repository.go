@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(key *domain.APIKey) error
+	GetAPIKeys(tenantID string) ([]*domain.APIKey, error)
+	GetAPIKeyByPrefix(prefix string) (*domain.APIKey, error)
+	RevokeAPIKey(tenantID string, id uuid.UUID, at time.Time) error
+	TouchAPIKey(id uuid.UUID, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (repo *apiKeyRepository) CreateAPIKey(key *domain.APIKey) error {
+	key.ID = uuid.New()
+	return repo.db.Create(key).Error
+}
+
+func (repo *apiKeyRepository) GetAPIKeys(tenantID string) ([]*domain.APIKey, error) {
+	var keys []*domain.APIKey
+	result := repo.db.Where("tenant_id = ?", tenantID).Order("created_at").Find(&keys)
+	return keys, result.Error
+}
+
+// GetAPIKeyByPrefix looks the key up in every tenant: prefixes are globally
+// unique and the key itself names its tenant.
+func (repo *apiKeyRepository) GetAPIKeyByPrefix(prefix string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	result := repo.db.Where("prefix = ?", prefix).First(&key)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrAPIKeyNotFound
+	}
+
+	return &key, result.Error
+}
+
+// RevokeAPIKey marks the key revoked. Revoking an already revoked key keeps
+// the original timestamp.
+func (repo *apiKeyRepository) RevokeAPIKey(tenantID string, id uuid.UUID, at time.Time) error {
+	var key domain.APIKey
+	if err := repo.db.Where("tenant_id = ? AND id = ?", tenantID, id).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrAPIKeyNotFound
+		}
+		return err
+	}
+
+	return repo.db.Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+func (repo *apiKeyRepository) TouchAPIKey(id uuid.UUID, at time.Time) error {
+	return repo.db.Model(&domain.APIKey{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
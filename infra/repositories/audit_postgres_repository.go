@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultAuditLimit = 100
+
+type AuditRepository interface {
+	CreateAuditEntry(entry *domain.AuditEntry) error
+	GetAuditEntries(filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+	GetAuditEntriesInvolving(tenantID string, userID uuid.UUID) ([]*domain.AuditEntry, error)
+	ScrubAuditEntries(tenantID string, userID uuid.UUID) error
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (repo *auditRepository) CreateAuditEntry(entry *domain.AuditEntry) error {
+	entry.ID = uuid.New()
+	return repo.db.Create(entry).Error
+}
+
+// GetAuditEntries returns the newest entries matching filter first.
+func (repo *auditRepository) GetAuditEntries(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	query := repo.db.Where("tenant_id = ?", filter.TenantID).Order("created_at DESC")
+	if filter.ActorID != uuid.Nil {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+
+	var entries []*domain.AuditEntry
+	result := query.Limit(limit).Find(&entries)
+	return entries, result.Error
+}
+
+// GetAuditEntriesInvolving returns every entry the user is the actor or the
+// subject of, oldest first.
+func (repo *auditRepository) GetAuditEntriesInvolving(tenantID string, userID uuid.UUID) ([]*domain.AuditEntry, error) {
+	var entries []*domain.AuditEntry
+	result := repo.db.Where("tenant_id = ? AND (actor_id = ? OR user_id = ?)", tenantID, userID, userID).
+		Order("created_at").Find(&entries)
+	return entries, result.Error
+}
+
+// ScrubAuditEntries blanks the IP, path and free-text detail of the entries
+// involving the user, which may hold personal data, keeping who did what and
+// when.
+func (repo *auditRepository) ScrubAuditEntries(tenantID string, userID uuid.UUID) error {
+	return repo.db.Model(&domain.AuditEntry{}).
+		Where("tenant_id = ? AND (actor_id = ? OR user_id = ?)", tenantID, userID, userID).
+		Updates(map[string]interface{}{"ip": "", "path": "", "detail": ""}).Error
+}
@@ -9,6 +9,13 @@ import (
 type RedisRepository interface {
 	Set(key string, value interface{}, expiration time.Duration) error
 	Get(key string) (string, error)
+	SetNX(key string, value interface{}, expiration time.Duration) (bool, error)
+	Delete(keys ...string) error
+	Incr(key string, expiration time.Duration) (int64, error)
+	TTL(key string) (time.Duration, error)
+	SAdd(key string, expiration time.Duration, members ...string) error
+	SMembers(key string) ([]string, error)
+	SRem(key string, members ...string) error
 }
 
 type redisRepository struct {
@@ -30,3 +37,62 @@ func (r *redisRepository) Set(key string, value interface{}, expiration time.Dur
 func (r *redisRepository) Get(key string) (string, error) {
 	return r.client.Get(r.ctx, key).Result()
 }
+
+func (r *redisRepository) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	return r.client.SetNX(r.ctx, key, value, expiration).Result()
+}
+
+func (r *redisRepository) Delete(keys ...string) error {
+	return r.client.Del(r.ctx, keys...).Err()
+}
+
+// Incr increments the counter at key. The expiration is only applied when the
+// counter is created, so the window starts at the first increment.
+func (r *redisRepository) Incr(key string, expiration time.Duration) (int64, error) {
+	count, err := r.client.Incr(r.ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+
+	if count == 1 && expiration > 0 {
+		if err := r.client.Expire(r.ctx, key, expiration).Err(); err != nil {
+			return 0, err
+		}
+	}
+
+	return count, nil
+}
+
+func (r *redisRepository) TTL(key string) (time.Duration, error) {
+	return r.client.TTL(r.ctx, key).Result()
+}
+
+// SAdd adds members to the set at key and resets its expiration, so the set
+// lives as long as its most recent member.
+func (r *redisRepository) SAdd(key string, expiration time.Duration, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+
+	pipe := r.client.TxPipeline()
+	pipe.SAdd(r.ctx, key, values...)
+	if expiration > 0 {
+		pipe.Expire(r.ctx, key, expiration)
+	}
+	_, err := pipe.Exec(r.ctx)
+	return err
+}
+
+func (r *redisRepository) SMembers(key string) ([]string, error) {
+	return r.client.SMembers(r.ctx, key).Result()
+}
+
+func (r *redisRepository) SRem(key string, members ...string) error {
+	values := make([]interface{}, len(members))
+	for i, member := range members {
+		values[i] = member
+	}
+
+	return r.client.SRem(r.ctx, key, values...).Err()
+}
@@ -1,20 +1,40 @@
 package repositories
 
 import (
+	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// UserRepository reads and writes the users of one tenant at a time: every
+// method takes the tenant and never sees rows of another one.
 type UserRepository interface {
 	CreateUser(user *domain.User) error
-	GetUsers() ([]*domain.User, error)
-	GetUserByData(document string) (*domain.User, error)
-	DeleteUser(id uuid.UUID) error
-	UpdateUser(id uuid.UUID, user *domain.User) (*domain.User, error)
-	GetUserByID(id uuid.UUID) (*domain.User, error)
+	GetUsers(filter domain.UserFilter) (*domain.UserPage, error)
+	GetUserByData(tenantID, document string) (*domain.User, error)
+	GetUserByIdentityDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error)
+	GetUserByPhone(tenantID, phone string) (*domain.User, error)
+	DeleteUser(tenantID string, id uuid.UUID, version int64) error
+	UpdateUser(tenantID string, id uuid.UUID, user *domain.User, version int64) (*domain.User, error)
+	GetUserByID(tenantID string, id uuid.UUID) (*domain.User, error)
+	UpdatePassword(tenantID string, id uuid.UUID, passwordHash string) error
+	UpdateRole(tenantID string, id uuid.UUID, role domain.Role) error
+	UpdateMFA(tenantID string, id uuid.UUID, totpSecret string, enabled bool, recoveryCodeHashes []string) error
+	ConsumeRecoveryCode(tenantID string, id uuid.UUID, current, remaining []string) (bool, error)
+	GetUserByOIDCSubject(tenantID, subject string) (*domain.User, error)
+	LinkOIDCSubject(tenantID string, id uuid.UUID, subject string) error
+	RestoreUser(tenantID string, id uuid.UUID) error
+	PurgeUser(tenantID string, id uuid.UUID) error
+	PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error)
+	GetUserByIDWithDeleted(tenantID string, id uuid.UUID) (*domain.User, error)
+	AnonymizeUser(user *domain.User) error
+	UpsertUsers(tenantID string, users []*domain.User, dryRun bool) ([]domain.UpsertResult, error)
+	StreamUsers(filter domain.UserFilter, fn func(*domain.User) error) error
 }
 
 type userRepository struct {
@@ -25,31 +45,188 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
+// tenant starts a query restricted to the tenant's users.
+func (repo *userRepository) tenant(tenantID string) *gorm.DB {
+	return repo.db.Where("tenant_id = ?", tenantID)
+}
+
+// CreateUser inserts the user into user.TenantID, which must be set.
 func (repo *userRepository) CreateUser(user *domain.User) error {
+	if user.TenantID == "" {
+		return domain.ErrUnknownTenant
+	}
+
 	user.ID = uuid.New()
 	return repo.db.Create(user).Error
 }
 
-func (repo *userRepository) GetUsers() ([]*domain.User, error) {
+// GetUsers returns the page of users selected by filter, using keyset
+// pagination on the sort column and the id.
+func (repo *userRepository) GetUsers(filter domain.UserFilter) (*domain.UserPage, error) {
+	query := repo.filterUsers(filter)
+	page := &domain.UserPage{}
+
+	if filter.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Model(&domain.User{}).Count(&total).Error; err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	column, direction, comparison := userOrder(filter)
+	if filter.After != nil {
+		var value interface{} = filter.After.Value
+		if column == "created_at" {
+			at, err := time.Parse(time.RFC3339Nano, filter.After.Value)
+			if err != nil {
+				return nil, domain.ErrInvalidCursor
+			}
+			value = at
+		}
+		query = query.Where("("+column+", id) "+comparison+" (?, ?)", value, filter.After.ID)
+	}
+
+	var users []*domain.User
+	result := query.Order(column + " " + direction).Order("id " + direction).Limit(filter.Limit + 1).Find(&users)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if len(users) > filter.Limit {
+		users = users[:filter.Limit]
+		page.NextCursor = domain.NewUserCursor(users[len(users)-1], filter.Sort, filter.Desc).Encode()
+	}
+	page.Users = users
+
+	return page, nil
+}
+
+// StreamUsers calls fn with each user selected by filter, in its order, as
+// the rows arrive from a single query instead of loading them all. The
+// cursor and limit of filter are ignored. An error from fn stops the query
+// and is returned.
+func (repo *userRepository) StreamUsers(filter domain.UserFilter, fn func(*domain.User) error) error {
+	column, direction, _ := userOrder(filter)
+	query := repo.filterUsers(filter).Model(&domain.User{}).Order(column + " " + direction).Order("id " + direction)
+
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user domain.User
+		if err := repo.db.ScanRows(rows, &user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// userOrder returns the column and direction a listing is sorted by, and the
+// comparison that continues it past a cursor.
+func userOrder(filter domain.UserFilter) (string, string, string) {
+	column, direction, comparison := "created_at", "ASC", ">"
+	if filter.Sort == domain.UserSortName {
+		column = "name"
+	}
+	if filter.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	return column, direction, comparison
+}
+
+// filterUsers applies every condition of filter except the cursor.
+func (repo *userRepository) filterUsers(filter domain.UserFilter) *gorm.DB {
+	query := repo.tenant(filter.TenantID)
+
+	switch filter.Deleted {
+	case domain.DeletedInclude:
+		query = query.Unscoped()
+	case domain.DeletedOnly:
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+
+	if filter.NamePrefix != "" {
+		query = query.Where("name ILIKE ?", likePrefix(filter.NamePrefix))
+	}
+	if filter.Phone != "" {
+		query = query.Where("phone = ?", filter.Phone)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	return query
+}
+
+// likePrefix escapes the LIKE wildcards in prefix so it only matches itself.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(prefix) + "%"
+}
+
+// GetUserByData finds the user holding the document number, whatever its
+// type and country. A number held by several users is reported as
+// ErrAmbiguousDocument rather than resolved to an arbitrary one of them.
+func (repo *userRepository) GetUserByData(tenantID, document string) (*domain.User, error) {
+	return findOneUser(repo.tenant(tenantID).Where("document = ?", document), domain.ErrGetUserByData)
+}
+
+// GetUserByIdentityDocument finds the user holding the document of docType
+// and country with the given, already normalized, number. An empty country
+// matches any: a number held in more than one country, which only happens
+// with passports, is then reported as ErrAmbiguousDocument.
+func (repo *userRepository) GetUserByIdentityDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error) {
+	query := repo.tenant(tenantID).Where("document_type = ? AND document = ?", docType, number)
+	if country != "" {
+		query = query.Where("document_country = ?", country)
+	}
+
+	return findOneUser(query, domain.ErrGetUserByData)
+}
+
+// findOneUser returns the single user matched by query, notFound when there
+// is none and ErrAmbiguousDocument when there are several.
+func findOneUser(query *gorm.DB, notFound error) (*domain.User, error) {
 	var users []*domain.User
-	result := repo.db.Find(&users)
-	return users, result.Error
+	if err := query.Limit(2).Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	switch len(users) {
+	case 0:
+		return nil, notFound
+	case 1:
+		return users[0], nil
+	}
+
+	return nil, domain.ErrAmbiguousDocument
 }
 
-func (repo *userRepository) GetUserByData(document string) (*domain.User, error) {
+func (repo *userRepository) GetUserByPhone(tenantID, phone string) (*domain.User, error) {
 	var user domain.User
-	result := repo.db.Where("document = ?", document).First(&user)
+	result := repo.tenant(tenantID).Where("phone = ?", phone).First(&user)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, domain.ErrGetUserByData
+		return nil, domain.ErrUserNotFound
 	}
 
 	return &user, result.Error
 }
 
-func (repo *userRepository) GetUserByID(id uuid.UUID) (*domain.User, error) {
+func (repo *userRepository) GetUserByID(tenantID string, id uuid.UUID) (*domain.User, error) {
 	var user domain.User
-	result := repo.db.Where("id = ?", id).First(&user)
+	result := repo.tenant(tenantID).Where("id = ?", id).First(&user)
 
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return nil, domain.ErrIDNotFound
@@ -58,20 +235,33 @@ func (repo *userRepository) GetUserByID(id uuid.UUID) (*domain.User, error) {
 	return &user, result.Error
 }
 
-func (repo *userRepository) DeleteUser(id uuid.UUID) error {
-	var user domain.User
-	result := repo.db.Where("id = ?", id).Delete(&user)
-	return result.Error
+// DeleteUser soft-deletes the user if it is still at version, or whatever
+// its version when version is 0.
+func (repo *userRepository) DeleteUser(tenantID string, id uuid.UUID, version int64) error {
+	result := repo.atVersion(repo.tenant(tenantID).Where("id = ?", id), version).Delete(&domain.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return repo.versionError(repo.db, tenantID, id)
+	}
+
+	return nil
 }
 
-func (repo *userRepository) UpdateUser(id uuid.UUID, user *domain.User) (*domain.User, error) {
+// UpdateUser writes the non-zero fields of user, except the ID, tenant, role
+// and version, if the user is still at version, or whatever its version when
+// version is 0. It bumps the version and returns the user as stored.
+func (repo *userRepository) UpdateUser(tenantID string, id uuid.UUID, user *domain.User, version int64) (*domain.User, error) {
 	tx := repo.db.Begin()
 
 	if tx.Error != nil {
 		return nil, tx.Error
 	}
 
-	req := tx.Model(&domain.User{}).Where("id = ?", id).Omit("ID").Updates(user)
+	req := repo.atVersion(tx.Model(&domain.User{}).Where("tenant_id = ? AND id = ?", tenantID, id), version).
+		Omit("ID", "TenantID", "Role", "Version").Updates(user)
 
 	if req.Error != nil {
 		tx.Rollback()
@@ -79,13 +269,291 @@ func (repo *userRepository) UpdateUser(id uuid.UUID, user *domain.User) (*domain
 	}
 
 	if req.RowsAffected == 0 {
+		err := repo.versionError(tx, tenantID, id)
+		tx.Rollback()
+		return nil, err
+	}
+
+	err := tx.Model(&domain.User{}).Where("tenant_id = ? AND id = ?", tenantID, id).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
+	if err != nil {
 		tx.Rollback()
-		return nil, errors.New("err to update a user")
+		return nil, err
+	}
+
+	var updated domain.User
+	if err := tx.Where("tenant_id = ? AND id = ?", tenantID, id).First(&updated).Error; err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
 	if err := tx.Commit().Error; err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	return &updated, nil
+}
+
+// atVersion restricts query to the given version of the user, unless it is 0.
+func (repo *userRepository) atVersion(query *gorm.DB, version int64) *gorm.DB {
+	if version == 0 {
+		return query
+	}
+
+	return query.Where("version = ?", version)
+}
+
+// versionError explains why a write conditioned on a version touched no
+// rows: the user is gone, ErrIDNotFound, or is at another version,
+// ErrVersionMismatch.
+func (repo *userRepository) versionError(db *gorm.DB, tenantID string, id uuid.UUID) error {
+	var count int64
+	if err := db.Model(&domain.User{}).Where("tenant_id = ? AND id = ?", tenantID, id).Count(&count).Error; err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return domain.ErrVersionMismatch
+}
+
+func (repo *userRepository) UpdatePassword(tenantID string, id uuid.UUID, passwordHash string) error {
+	result := repo.tenant(tenantID).Model(&domain.User{}).Where("id = ?", id).Update("password_hash", passwordHash)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+func (repo *userRepository) UpdateRole(tenantID string, id uuid.UUID, role domain.Role) error {
+	result := repo.tenant(tenantID).Model(&domain.User{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"role": role, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+// UpdateMFA overwrites the user's second factor settings, zero values included.
+func (repo *userRepository) UpdateMFA(tenantID string, id uuid.UUID, totpSecret string, enabled bool, recoveryCodeHashes []string) error {
+	result := repo.tenant(tenantID).Model(&domain.User{}).Where("id = ?", id).
+		Select("totp_secret", "mfa_enabled", "recovery_code_hashes").
+		Updates(&domain.User{TOTPSecret: totpSecret, MFAEnabled: enabled, RecoveryCodeHashes: recoveryCodeHashes})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+// ConsumeRecoveryCode replaces the user's recovery codes with remaining only
+// while the row still holds current, so two logins racing on the same code
+// cannot both spend it. It reports whether the swap happened.
+func (repo *userRepository) ConsumeRecoveryCode(tenantID string, id uuid.UUID, current, remaining []string) (bool, error) {
+	stored, err := json.Marshal(current)
+	if err != nil {
+		return false, err
+	}
+
+	result := repo.tenant(tenantID).Model(&domain.User{}).
+		Where("id = ? AND recovery_code_hashes = ?", id, string(stored)).
+		Select("recovery_code_hashes").
+		Updates(&domain.User{RecoveryCodeHashes: remaining})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+func (repo *userRepository) GetUserByOIDCSubject(tenantID, subject string) (*domain.User, error) {
+	var user domain.User
+	result := repo.tenant(tenantID).Where("oidc_subject = ?", subject).First(&user)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return &user, result.Error
+}
+
+func (repo *userRepository) LinkOIDCSubject(tenantID string, id uuid.UUID, subject string) error {
+	result := repo.tenant(tenantID).Model(&domain.User{}).Where("id = ?", id).Update("oidc_subject", subject)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+// RestoreUser undeletes a soft-deleted user. It fails with ErrUserConflict
+// when an active user took the document or phone in the meantime and with
+// ErrUserAnonymized when the user was anonymized.
+func (repo *userRepository) RestoreUser(tenantID string, id uuid.UUID) error {
+	result := repo.tenant(tenantID).Unscoped().Model(&domain.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return domain.ErrUserConflict
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		if user, err := repo.GetUserByIDWithDeleted(tenantID, id); err == nil && user.AnonymizedAt != nil {
+			return domain.ErrUserAnonymized
+		}
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+// PurgeUser permanently removes a soft-deleted user. Active users are not
+// touched and reported as ErrIDNotFound.
+func (repo *userRepository) PurgeUser(tenantID string, id uuid.UUID) error {
+	result := repo.tenant(tenantID).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", id).Delete(&domain.User{})
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers permanently removes the users soft-deleted before
+// deletedBefore and returns how many there were.
+func (repo *userRepository) PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error) {
+	result := repo.tenant(tenantID).Unscoped().Where("deleted_at < ?", deletedBefore).Delete(&domain.User{})
+	return result.RowsAffected, result.Error
+}
+
+// GetUserByIDWithDeleted is GetUserByID that also finds soft-deleted users.
+func (repo *userRepository) GetUserByIDWithDeleted(tenantID string, id uuid.UUID) (*domain.User, error) {
+	var user domain.User
+	result := repo.tenant(tenantID).Unscoped().Where("id = ?", id).First(&user)
+
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrIDNotFound
+	}
+
+	return &user, result.Error
+}
+
+// AnonymizeUser stores the fields scrubbed by domain.User.Anonymize, zero
+// values included, and bumps the version.
+func (repo *userRepository) AnonymizeUser(user *domain.User) error {
+	user.Version++
+	result := repo.tenant(user.TenantID).Unscoped().Model(&domain.User{}).Where("id = ?", user.ID).
+		Select("name", "phone", "document", "password_hash", "totp_secret", "mfa_enabled",
+			"recovery_code_hashes", "oidc_subject", "anonymized_at", "deleted_at", "version").
+		Updates(user)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return domain.ErrIDNotFound
+	}
+
+	return nil
+}
+
+// UpsertUsers saves the users in one transaction, each one updating the
+// active user with its document type and number or else being inserted. A
+// user that would break a unique index is rejected with ErrUserConflict, and
+// one matching a user other than a guest with ErrImportNonGuest, without
+// undoing the others. A dry run rolls everything back once the results are
+// known.
+func (repo *userRepository) UpsertUsers(tenantID string, users []*domain.User, dryRun bool) ([]domain.UpsertResult, error) {
+	tx := repo.db.Begin()
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+
+	results := make([]domain.UpsertResult, len(users))
+	for i, user := range users {
+		if err := tx.SavePoint("upsert_user").Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+
+		created, err := repo.upsertUser(tx, tenantID, user)
+		if errors.Is(err, domain.ErrImportNonGuest) {
+			results[i].Err = err
+			continue
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			if err := tx.RollbackTo("upsert_user").Error; err != nil {
+				tx.Rollback()
+				return nil, err
+			}
+			results[i].Err = domain.ErrUserConflict
+			continue
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		results[i].Created = created
+	}
+
+	if dryRun {
+		return results, tx.Rollback().Error
+	}
+
+	return results, tx.Commit().Error
+}
+
+// upsertUser inserts the user or updates the matching guest. The phone and
+// password are credentials, so an existing guest keeps theirs.
+func (repo *userRepository) upsertUser(tx *gorm.DB, tenantID string, user *domain.User) (bool, error) {
+	user.TenantID = tenantID
+
+	var existing domain.User
+	err := tx.Where("tenant_id = ? AND document_type = ? AND document_country = ? AND document = ?",
+		tenantID, user.DocumentType, user.DocumentCountry, user.Document).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, tx.Create(user).Error
+	}
+	if err != nil {
+		return false, err
+	}
+	if existing.Role != domain.RoleGuest {
+		return false, domain.ErrImportNonGuest
+	}
+
+	user.ID = existing.ID
+	err = tx.Model(&domain.User{}).Where("id = ?", existing.ID).
+		Omit("ID", "TenantID", "Role", "Version", "CreatedAt", "Phone", "PasswordHash").Updates(user).Error
+	if err != nil {
+		return false, err
+	}
+
+	return false, tx.Model(&domain.User{}).Where("id = ?", existing.ID).
+		UpdateColumn("version", gorm.Expr("version + 1")).Error
 }
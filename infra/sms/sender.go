@@ -0,0 +1,52 @@
+package sms
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"go.uber.org/zap"
+)
+
+var ErrUnknownSender = errors.New("unknown sms sender")
+
+// Channel is how a text message reaches the phone.
+type Channel string
+
+const (
+	ChannelSMS      Channel = "sms"
+	ChannelWhatsApp Channel = "whatsapp"
+)
+
+// Sender delivers short text messages to a phone number. Implementations for
+// real providers must support both channels or fail with an error.
+type Sender interface {
+	Send(channel Channel, phone, message string) error
+}
+
+// New builds the sender selected by cfg.SMSSender.
+func New(cfg config.Config, logger *zap.Logger) (Sender, error) {
+	switch cfg.SMSSender {
+	case "", "stub":
+		return NewStubSender(logger), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownSender, cfg.SMSSender)
+	}
+}
+
+// stubSender only logs the message, for local development.
+type stubSender struct {
+	logger *zap.Logger
+}
+
+func NewStubSender(logger *zap.Logger) Sender {
+	return &stubSender{logger: logger}
+}
+
+func (s *stubSender) Send(channel Channel, phone, message string) error {
+	s.logger.Info("Text message",
+		zap.String("channel", string(channel)),
+		zap.String("phone", phone),
+		zap.String("message", message))
+	return nil
+}
@@ -7,38 +7,124 @@ import (
 )
 
 type Config struct {
-	JWTSecret            string
-	JWTExpirationMinutes int
-	DBUsername           string
-	DBPassword           string
-	DBName               string
-	DBHost               string
-	DBPort               string
-	RedisADR             string
-	RedisPassword        string
-	RedisDB              int
-	RedisTLL             time.Duration
+	JWTSecret                   string
+	JWTExpirationMinutes        int
+	JWTKeys                     string
+	JWTActiveKeyID              string
+	JWTRetiredKeys              string
+	JWTKeyGracePeriod           time.Duration
+	RefreshTokenExpirationHours int
+	LoginMaxAttempts            int
+	LoginMaxAttemptsPerIP       int
+	LoginAttemptWindow          time.Duration
+	LoginLockoutDuration        time.Duration
+	LoginDelayBase              time.Duration
+	MFAIssuer                   string
+	MFATokenTTL                 time.Duration
+	OIDCIssuer                  string
+	OIDCClientID                string
+	OIDCClientSecret            string
+	OIDCRedirectURL             string
+	OIDCScopes                  string
+	OIDCDocumentClaim           string
+	OIDCDefaultRole             string
+	PasswordResetTTL            time.Duration
+	PasswordResetURL            string
+	NotifierType                string
+	NotifierFile                string
+	SMSSender                   string
+	OTPTTL                      time.Duration
+	OTPMaxAttempts              int
+	ImpersonationTTL            time.Duration
+	Tenants                     string
+	PhoneDefaultCountry         string
+	RequireIfMatch              bool
+	ImportBatchSize             int
+	ImportMaxBytes              int64
+	DBUsername                  string
+	DBPassword                  string
+	DBName                      string
+	DBHost                      string
+	DBPort                      string
+	RedisADR                    string
+	RedisPassword               string
+	RedisDB                     int
+	RedisTLL                    time.Duration
 }
 
 func LoadConfig() Config {
 	viper.SetConfigFile(".env")
+	viper.SetDefault("LoginMaxAttempts", 5)
+	viper.SetDefault("LoginMaxAttemptsPerIP", 20)
+	viper.SetDefault("LoginAttemptWindow", 15*time.Minute)
+	viper.SetDefault("LoginLockoutDuration", 15*time.Minute)
+	viper.SetDefault("LoginDelayBase", time.Second)
+	viper.SetDefault("MFAIssuer", "Pousada")
+	viper.SetDefault("MFATokenTTL", 5*time.Minute)
+	viper.SetDefault("OIDCScopes", "openid profile email phone")
+	viper.SetDefault("OIDCDocumentClaim", "preferred_username")
+	viper.SetDefault("OIDCDefaultRole", "staff")
+	viper.SetDefault("PasswordResetTTL", 30*time.Minute)
+	viper.SetDefault("NotifierType", "log")
+	viper.SetDefault("NotifierFile", "notifications.log")
+	viper.SetDefault("SMSSender", "stub")
+	viper.SetDefault("OTPTTL", 5*time.Minute)
+	viper.SetDefault("OTPMaxAttempts", 5)
+	viper.SetDefault("ImpersonationTTL", 15*time.Minute)
+	viper.SetDefault("Tenants", "default")
+	viper.SetDefault("PhoneDefaultCountry", "BR")
+	viper.SetDefault("RequireIfMatch", false)
+	viper.SetDefault("ImportBatchSize", 500)
+	viper.SetDefault("ImportMaxBytes", 10<<20)
 
 	if err := viper.ReadInConfig(); err != nil {
 		log.Fatalf("Error reading config file: %v", err)
 	}
 
 	config := Config{
-		JWTSecret:            viper.GetString("JWTSecret"),
-		JWTExpirationMinutes: viper.GetInt("JWTExpirationMinutes"),
-		DBUsername:           viper.GetString("DB_USERNAME"),
-		DBPassword:           viper.GetString("DB_PASSWORD"),
-		DBName:               viper.GetString("DB_NAME"),
-		DBHost:               viper.GetString("DB_HOST"),
-		DBPort:               viper.GetString("DB_PORT"),
-		RedisADR:             viper.GetString("REDIS_ADR"),
-		RedisPassword:        viper.GetString("REDIS_PASSWORD"),
-		RedisDB:              viper.GetInt("REDIS_DB"),
-		RedisTLL:             viper.GetDuration("REDIS_TLL"),
+		JWTSecret:                   viper.GetString("JWTSecret"),
+		JWTExpirationMinutes:        viper.GetInt("JWTExpirationMinutes"),
+		JWTKeys:                     viper.GetString("JWTKeys"),
+		JWTActiveKeyID:              viper.GetString("JWTActiveKeyID"),
+		JWTRetiredKeys:              viper.GetString("JWTRetiredKeys"),
+		JWTKeyGracePeriod:           viper.GetDuration("JWTKeyGracePeriod"),
+		RefreshTokenExpirationHours: viper.GetInt("RefreshTokenExpirationHours"),
+		LoginMaxAttempts:            viper.GetInt("LoginMaxAttempts"),
+		LoginMaxAttemptsPerIP:       viper.GetInt("LoginMaxAttemptsPerIP"),
+		LoginAttemptWindow:          viper.GetDuration("LoginAttemptWindow"),
+		LoginLockoutDuration:        viper.GetDuration("LoginLockoutDuration"),
+		LoginDelayBase:              viper.GetDuration("LoginDelayBase"),
+		MFAIssuer:                   viper.GetString("MFAIssuer"),
+		MFATokenTTL:                 viper.GetDuration("MFATokenTTL"),
+		OIDCIssuer:                  viper.GetString("OIDCIssuer"),
+		OIDCClientID:                viper.GetString("OIDCClientID"),
+		OIDCClientSecret:            viper.GetString("OIDCClientSecret"),
+		OIDCRedirectURL:             viper.GetString("OIDCRedirectURL"),
+		OIDCScopes:                  viper.GetString("OIDCScopes"),
+		OIDCDocumentClaim:           viper.GetString("OIDCDocumentClaim"),
+		OIDCDefaultRole:             viper.GetString("OIDCDefaultRole"),
+		PasswordResetTTL:            viper.GetDuration("PasswordResetTTL"),
+		PasswordResetURL:            viper.GetString("PasswordResetURL"),
+		NotifierType:                viper.GetString("NotifierType"),
+		NotifierFile:                viper.GetString("NotifierFile"),
+		SMSSender:                   viper.GetString("SMSSender"),
+		OTPTTL:                      viper.GetDuration("OTPTTL"),
+		OTPMaxAttempts:              viper.GetInt("OTPMaxAttempts"),
+		ImpersonationTTL:            viper.GetDuration("ImpersonationTTL"),
+		Tenants:                     viper.GetString("Tenants"),
+		PhoneDefaultCountry:         viper.GetString("PhoneDefaultCountry"),
+		RequireIfMatch:              viper.GetBool("RequireIfMatch"),
+		ImportBatchSize:             viper.GetInt("ImportBatchSize"),
+		ImportMaxBytes:              viper.GetInt64("ImportMaxBytes"),
+		DBUsername:                  viper.GetString("DB_USERNAME"),
+		DBPassword:                  viper.GetString("DB_PASSWORD"),
+		DBName:                      viper.GetString("DB_NAME"),
+		DBHost:                      viper.GetString("DB_HOST"),
+		DBPort:                      viper.GetString("DB_PORT"),
+		RedisADR:                    viper.GetString("REDIS_ADR"),
+		RedisPassword:               viper.GetString("REDIS_PASSWORD"),
+		RedisDB:                     viper.GetInt("REDIS_DB"),
+		RedisTLL:                    viper.GetDuration("REDIS_TLL"),
 	}
 
 	return config
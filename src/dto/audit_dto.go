@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AuditEntryResponseDTO struct {
+	ID        uuid.UUID `json:"id"`
+	ActorID   uuid.UUID `json:"actor_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Action    string    `json:"action"`
+	Method    string    `json:"method,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Status    int       `json:"status,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ImpersonateDTO struct {
+	Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonationTokenDTO carries an access token only: impersonation cannot be
+// extended with a refresh token.
+type ImpersonationTokenDTO struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int       `json:"expires_in"`
+	UserID      uuid.UUID `json:"user_id"`
+	ActorID     uuid.UUID `json:"actor_id"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import "github.com/google/uuid"
+
+// UserImportDTO is one row of a user import, a CSV record or an NDJSON line.
+// It is checked like UserDTO.
+type UserImportDTO struct {
+	Name            string `json:"name" validate:"required"`
+	Phone           string `json:"phone" validate:"required"`
+	Document        string `json:"document" validate:"required"`
+	DocumentType    string `json:"document_type,omitempty" validate:"omitempty,oneof=cpf cnpj passport rne crnm"`
+	DocumentCountry string `json:"document_country,omitempty" validate:"omitempty,len=2,alpha"`
+	Password        string `json:"password,omitempty" validate:"required,min=8,max=72"`
+}
+
+type ImportRowDTO struct {
+	Line     int               `json:"line"`
+	Document string            `json:"document"`
+	Action   string            `json:"action"`
+	UserID   *uuid.UUID        `json:"user_id,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+}
+
+type ImportReportDTO struct {
+	DryRun   bool            `json:"dry_run"`
+	Created  int             `json:"created"`
+	Updated  int             `json:"updated"`
+	Rejected int             `json:"rejected"`
+	Rows     []*ImportRowDTO `json:"rows"`
+}
@@ -1,5 +1,56 @@
 package dto
 
+// LoginDTO holds password credentials. Foreigners may also send the type and
+// country of their document, as in UserDTO; without them a document that is
+// no CPF or CNPJ is looked up among all types.
 type LoginDTO struct {
-	Document string `json:"document"`
+	Document        string `json:"document"`
+	DocumentType    string `json:"document_type,omitempty" validate:"omitempty,oneof=cpf cnpj passport rne crnm"`
+	DocumentCountry string `json:"document_country,omitempty" validate:"omitempty,len=2,alpha"`
+	Password        string `json:"password"`
+}
+
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type ForgotPasswordDTO struct {
+	Document        string `json:"document" validate:"required"`
+	DocumentType    string `json:"document_type,omitempty" validate:"omitempty,oneof=cpf cnpj passport rne crnm"`
+	DocumentCountry string `json:"document_country,omitempty" validate:"omitempty,len=2,alpha"`
+}
+
+type ResetPasswordDTO struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type RequestOTPDTO struct {
+	Phone   string `json:"phone" validate:"required"`
+	Channel string `json:"channel" validate:"omitempty,oneof=sms whatsapp"`
+}
+
+type VerifyOTPDTO struct {
+	Phone string `json:"phone" validate:"required"`
+	Code  string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutDTO struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// TokenResponseDTO carries either a full token pair or, when the account has
+// a second factor, only MFAToken to be exchanged at /login/mfa.
+type TokenResponseDTO struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type,omitempty"`
+	ExpiresIn    int    `json:"expires_in"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
 }
@@ -0,0 +1,19 @@
+package dto
+
+type MFALoginDTO struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type MFACodeDTO struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TOTPEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+import "time"
+
+// UserProfileExportDTO is the stored profile of a user, credentials aside.
+type UserProfileExportDTO struct {
+	UserResponseDTO
+	MFAEnabled bool      `json:"mfa_enabled"`
+	OIDCLinked bool      `json:"oidc_linked"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// UserDataExportDTO is the answer to an LGPD access request: everything
+// stored about one user.
+type UserDataExportDTO struct {
+	ExportedAt   time.Time                `json:"exported_at"`
+	Profile      *UserProfileExportDTO    `json:"profile"`
+	AuditEntries []*AuditEntryResponseDTO `json:"audit_entries"`
+	Sessions     []*SessionResponseDTO    `json:"sessions"`
+	APIKeys      []*APIKeyResponseDTO     `json:"api_keys"`
+}
@@ -1,6 +1,8 @@
 package dto
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
@@ -8,11 +10,45 @@ type UserDTO struct {
 	Name     string `json:"name" validate:"required"`
 	Phone    string `json:"phone" validate:"required"`
 	Document string `json:"document" validate:"required"`
+	// DocumentType defaults to a CPF or CNPJ; foreigners send passport, rne
+	// or crnm. DocumentCountry is required for passports.
+	DocumentType    string `json:"document_type,omitempty" validate:"omitempty,oneof=cpf cnpj passport rne crnm"`
+	DocumentCountry string `json:"document_country,omitempty" validate:"omitempty,len=2,alpha"`
+	Password        string `json:"password,omitempty" validate:"required,min=8,max=72"`
+}
+
+// UserPatchDTO holds the fields of a user that PATCH /users/:id can change.
+// Patches are applied to its JSON form, so other fields cannot be added.
+type UserPatchDTO struct {
+	Name            string `json:"name" validate:"required"`
+	Phone           string `json:"phone" validate:"required"`
+	Document        string `json:"document" validate:"required"`
+	DocumentType    string `json:"document_type" validate:"omitempty,oneof=cpf cnpj passport rne crnm"`
+	DocumentCountry string `json:"document_country" validate:"omitempty,len=2,alpha"`
 }
 
 type UserResponseDTO struct {
-	ID       uuid.UUID `json:"id"`
-	Name     string    `json:"name"`
-	Document string    `json:"email"`
-	Phone    string    `json:"phone"`
+	ID              uuid.UUID  `json:"id"`
+	TenantID        string     `json:"tenant_id"`
+	Name            string     `json:"name"`
+	Document        string     `json:"email"`
+	DocumentType    string     `json:"document_type"`
+	DocumentCountry string     `json:"document_country"`
+	Phone           string     `json:"phone"`
+	Role            string     `json:"role"`
+	CreatedAt       time.Time  `json:"created_at"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+	AnonymizedAt    *time.Time `json:"anonymized_at,omitempty"`
+}
+
+// UserPageResponseDTO is one page of GET /users. NextCursor is omitted on the
+// last page and Total unless include_total was asked for.
+type UserPageResponseDTO struct {
+	Data       []*UserResponseDTO `json:"data"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+	Total      *int64             `json:"total,omitempty"`
+}
+
+type UpdateRoleDTO struct {
+	Role string `json:"role" validate:"required,oneof=admin staff guest"`
 }
@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyUsecase *usecases.APIKeyUsecase
+}
+
+func NewAPIKeyHandler(apiKeyUsecase *usecases.APIKeyUsecase) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyUsecase: apiKeyUsecase,
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	var input dto.CreateAPIKeyDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	key, err := h.apiKeyUsecase.CreateAPIKey(claims, &input)
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, key)
+	case errors.As(err, &validationErrs), errors.Is(err, domain.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyUsecase.GetAPIKeys(middleware.GetTenant(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	output := make([]*dto.APIKeyResponseDTO, len(keys))
+	for i, key := range keys {
+		output[i] = domain.OutputAPIKey(key)
+	}
+
+	c.JSON(http.StatusOK, output)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	err = h.apiKeyUsecase.RevokeAPIKey(middleware.GetTenant(c), id)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type AuditHandler struct {
+	auditUsecase *usecases.AuditUsecase
+}
+
+func NewAuditHandler(auditUsecase *usecases.AuditUsecase) *AuditHandler {
+	return &AuditHandler{
+		auditUsecase: auditUsecase,
+	}
+}
+
+// GetAuditEntries lists the audit log, newest first, optionally filtered by
+// the actor_id and user_id query parameters and capped by limit.
+func (h *AuditHandler) GetAuditEntries(c *gin.Context) {
+	filter := domain.AuditFilter{TenantID: middleware.GetTenant(c)}
+	var err error
+
+	if value := c.Query("actor_id"); value != "" {
+		if filter.ActorID, err = uuid.Parse(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid actor_id"})
+			return
+		}
+	}
+	if value := c.Query("user_id"); value != "" {
+		if filter.UserID, err = uuid.Parse(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
+			return
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+			return
+		}
+	}
+
+	entries, err := h.auditUsecase.GetAuditEntries(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	output := make([]*dto.AuditEntryResponseDTO, len(entries))
+	for i, entry := range entries {
+		output[i] = domain.OutputAuditEntry(entry)
+	}
+
+	c.JSON(http.StatusOK, output)
+}
@@ -1,11 +1,19 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
 	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
 	"github.com/ThailanTec/challenger/pousada/src/usecases"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 )
 
 type AuthHandler struct {
@@ -19,33 +27,248 @@ func NewAuthHandler(authUsecase *usecases.AuthUsecase) *AuthHandler {
 }
 
 func (h *AuthHandler) Login(c *gin.Context) {
-	var document dto.LoginDTO
-	if err := c.ShouldBindJSON(&document); err != nil {
+	var credentials dto.LoginDTO
+	if err := c.ShouldBindJSON(&credentials); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	token, err := h.authUsecase.Login(document.Document)
-	if err != nil {
+	tokens, err := h.authUsecase.Login(middleware.GetTenant(c), &credentials, clientInfo(c))
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tokens)
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAccountLocked):
+		respondRetry(c, http.StatusLocked, err)
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		respondRetry(c, http.StatusTooManyRequests, err)
+	case errors.Is(err, domain.ErrInvalidCredentials), errors.Is(err, domain.ErrInvalidPassword):
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) RefreshToken(c *gin.Context) {
+	var input dto.RefreshTokenDTO
+	if err := c.ShouldBindJSON(&input); err != nil || input.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	tokens, err := h.authUsecase.RefreshToken(input.RefreshToken)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tokens)
+	case errors.Is(err, domain.ErrInvalidRefreshToken), errors.Is(err, domain.ErrRefreshTokenReused):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) Logout(c *gin.Context) {
+	token, ok := auth.ExtractBearerToken(c.GetHeader("Authorization"))
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token missing"})
+		return
+	}
+
+	// The refresh token is optional, so an empty body is fine.
+	var input dto.LogoutDTO
+	_ = c.ShouldBindJSON(&input)
+
+	if err := h.authUsecase.Logout(token, input.RefreshToken); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AuthHandler) UnlockUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	err = h.authUsecase.UnlockUser(middleware.GetTenant(c), userID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) RevokeUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	err = h.authUsecase.RevokeUserSessions(middleware.GetTenant(c), userID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) UpdateRole(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var input dto.UpdateRoleDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token})
+	err = h.authUsecase.UpdateRole(claims, middleware.GetTenant(c), userID, &input)
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.As(err, &validationErrs), errors.Is(err, domain.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrTargetOutranks):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
 }
 
-func (h *AuthHandler) Validate(c *gin.Context) {
-	token := c.GetHeader("Authorization")
-	if token == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var input dto.ChangePasswordDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err = h.authUsecase.ChangePassword(middleware.GetTenant(c), userID, &input)
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrInvalidPassword):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authUsecase.JWKS())
+}
+
+// ListUserSessions lets an admin see where a user is logged in.
+func (h *AuthHandler) ListUserSessions(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	sessions, err := h.authUsecase.ListSessions(middleware.GetTenant(c), userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	user, err := h.authUsecase.ValidateToken(token)
+	c.JSON(http.StatusOK, outputSessions(sessions, currentSessionID(c)))
+}
+
+func (h *AuthHandler) RevokeUserSession(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	sessionID, err := uuid.Parse(c.Param("session_id"))
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"user": user})
+	err = h.authUsecase.RevokeSession(middleware.GetTenant(c), userID, sessionID)
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// clientInfo describes the caller for the session inventory.
+func clientInfo(c *gin.Context) domain.ClientInfo {
+	return domain.ClientInfo{IP: c.ClientIP(), UserAgent: c.Request.UserAgent()}
+}
+
+// currentSessionID is the session of the token the request was made with, or
+// uuid.Nil for API keys and anonymous requests.
+func currentSessionID(c *gin.Context) uuid.UUID {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		return uuid.Nil
+	}
+
+	return claims.SessionID
+}
+
+func outputSessions(sessions []*domain.Session, current uuid.UUID) []*dto.SessionResponseDTO {
+	output := make([]*dto.SessionResponseDTO, 0, len(sessions))
+	for _, session := range sessions {
+		output = append(output, domain.OutputSession(session, current != uuid.Nil && session.ID == current))
+	}
+
+	return output
+}
+
+// respondRetry answers with status and a Retry-After header taken from a
+// *domain.RetryError, rounded up to whole seconds.
+func respondRetry(c *gin.Context, status int, err error) {
+	body := gin.H{"error": err.Error()}
+
+	var retryErr *domain.RetryError
+	if errors.As(err, &retryErr) {
+		seconds := int(math.Ceil(retryErr.RetryAfter.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		body["retry_after"] = seconds
+	}
+
+	c.JSON(status, body)
 }
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type ImpersonationHandler struct {
+	impersonationUsecase *usecases.ImpersonationUsecase
+	logger               *zap.Logger
+}
+
+func NewImpersonationHandler(impersonationUsecase *usecases.ImpersonationUsecase, logger *zap.Logger) *ImpersonationHandler {
+	return &ImpersonationHandler{
+		impersonationUsecase: impersonationUsecase,
+		logger:               logger,
+	}
+}
+
+func (h *ImpersonationHandler) Impersonate(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	var input dto.ImpersonateDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	token, err := h.impersonationUsecase.Impersonate(claims, userID, &input, clientInfo(c))
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		h.logger.Info("Impersonation started",
+			zap.String("actor_id", claims.UserID.String()),
+			zap.String("user_id", userID.String()))
+		c.JSON(http.StatusCreated, token)
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrImpersonationForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Error starting impersonation", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
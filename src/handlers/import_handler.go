@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImportHandler serves the bulk import of users.
+type ImportHandler struct {
+	importUsecase *usecases.ImportUsecase
+	// maxBytes bounds the body of an import.
+	maxBytes int64
+	logger   *zap.Logger
+}
+
+func NewImportHandler(importUsecase *usecases.ImportUsecase, maxBytes int64, logger *zap.Logger) *ImportHandler {
+	return &ImportHandler{
+		importUsecase: importUsecase,
+		maxBytes:      maxBytes,
+		logger:        logger,
+	}
+}
+
+// ImportUsers upserts the users in the body, a CSV (text/csv) or NDJSON
+// (application/x-ndjson) file, and answers with the report of every row.
+// With dry_run=true nothing is saved. When the file cannot be read to the
+// end, or is larger than maxBytes, the error comes with the report of the
+// rows before that point, which were saved.
+func (h *ImportHandler) ImportUsers(c *gin.Context) {
+	dryRun := false
+	if value := c.Query("dry_run"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dry_run"})
+			return
+		}
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes)
+	report, err := h.importUsecase.Import(middleware.GetTenant(c), c.ContentType(), body, dryRun)
+	var tooLarge *http.MaxBytesError
+	switch {
+	case err == nil:
+		h.logger.Info("Users imported", zap.Bool("dry_run", dryRun), zap.Int("created", report.Created),
+			zap.Int("updated", report.Updated), zap.Int("rejected", report.Rejected))
+		c.JSON(http.StatusOK, domain.OutputImportReport(report))
+	case errors.Is(err, domain.ErrUnsupportedImportType):
+		c.Header("Accept-Post", domain.CSVType+", "+domain.NDJSONType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.As(err, &tooLarge):
+		h.respondPartial(c, http.StatusRequestEntityTooLarge, report, err)
+	case errors.Is(err, domain.ErrInvalidImport):
+		h.respondPartial(c, http.StatusBadRequest, report, err)
+	default:
+		h.logger.Error("Error importing users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// respondPartial answers with err and, when some rows were read before it,
+// their report.
+func (h *ImportHandler) respondPartial(c *gin.Context, status int, report *domain.ImportReport, err error) {
+	if report == nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.logger.Warn("Users import cut short", zap.Int("created", report.Created),
+		zap.Int("updated", report.Updated), zap.Int("rejected", report.Rejected), zap.Error(err))
+	c.JSON(status, gin.H{"error": err.Error(), "report": domain.OutputImportReport(report)})
+}
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// MeHandler serves the self-service endpoints, always acting on the
+// authenticated user rather than on a user id taken from the path.
+type MeHandler struct {
+	UserUsecase usecases.UserUsecase
+	AuthUsecase *usecases.AuthUsecase
+	Logger      *zap.Logger
+}
+
+func NewMeHandler(uc usecases.UserUsecase, authUsecase *usecases.AuthUsecase, logger *zap.Logger) *MeHandler {
+	return &MeHandler{UserUsecase: uc,
+		AuthUsecase: authUsecase,
+		Logger:      logger}
+}
+
+func (h *MeHandler) GetMe(c *gin.Context) {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		h.respondUserError(c, err)
+		return
+	}
+
+	c.Header("ETag", user.ETag())
+	c.JSON(http.StatusOK, domain.OutputUser(user))
+}
+
+// UpdateMe replaces the editable fields of the current user, honoring
+// If-Match like UserHandler.UpdateUser.
+func (h *MeHandler) UpdateMe(c *gin.Context) {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		h.respondUserError(c, err)
+		return
+	}
+
+	version, err := domain.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		respondVersionError(c, err)
+		return
+	}
+
+	var input dto.UserDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		h.Logger.Error("Error binding JSON", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.UserUsecase.UpdateUser(user.TenantID, user.ID, &input, version)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var fieldErr *domain.FieldError
+	if errors.As(err, &fieldErr) {
+		c.JSON(http.StatusBadRequest, fieldErrorBody(fieldErr))
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error updating current user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.Logger.Info("Current user updated", zap.String("user_id", user.ID.String()))
+	c.Header("ETag", updated.ETag())
+	c.JSON(http.StatusOK, domain.OutputUser(updated))
+}
+
+// DeleteMe deletes the current user and logs them out everywhere, honoring
+// If-Match like UserHandler.DeleteUser.
+func (h *MeHandler) DeleteMe(c *gin.Context) {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		h.respondUserError(c, err)
+		return
+	}
+
+	version, err := domain.ParseIfMatch(c.GetHeader("If-Match"))
+	if err == nil && version != 0 && version != user.Version {
+		err = domain.ErrVersionMismatch
+	}
+	if err != nil {
+		respondVersionError(c, err)
+		return
+	}
+
+	// Delete first, so a failed delete leaves the user logged in.
+	err = h.UserUsecase.DeleteUser(user.TenantID, user.ID, version)
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error deleting current user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.AuthUsecase.RevokeDeletedUserSessions(user.ID); err != nil {
+		h.Logger.Error("Error revoking sessions of deleted user", zap.String("user_id", user.ID.String()), zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.Logger.Info("Current user deleted", zap.String("user_id", user.ID.String()))
+	c.Status(http.StatusNoContent)
+}
+
+// GetSessions lists the devices the user is logged in on, flagging the one
+// making the request.
+func (h *MeHandler) GetSessions(c *gin.Context) {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		h.respondUserError(c, err)
+		return
+	}
+
+	sessions, err := h.AuthUsecase.ListSessions(user.TenantID, user.ID)
+	if err != nil {
+		h.Logger.Error("Error listing sessions", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, outputSessions(sessions, currentSessionID(c)))
+}
+
+// DeleteSession logs the user out of one device. Revoking the current session
+// is allowed and acts like a logout.
+func (h *MeHandler) DeleteSession(c *gin.Context) {
+	user, err := middleware.GetCurrentUser(c)
+	if err != nil {
+		h.respondUserError(c, err)
+		return
+	}
+
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid session id"})
+		return
+	}
+
+	err = h.AuthUsecase.RevokeSession(user.TenantID, user.ID, sessionID)
+	switch {
+	case err == nil:
+		h.Logger.Info("Session revoked", zap.String("user_id", user.ID.String()), zap.String("session_id", sessionID.String()))
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrSessionNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error revoking session", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *MeHandler) respondUserError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, domain.ErrNotAuthenticated), errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error loading current user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
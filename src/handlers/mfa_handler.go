@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var input dto.MFALoginDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.authUsecase.LoginMFA(&input, clientInfo(c))
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tokens)
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAccountLocked):
+		respondRetry(c, http.StatusLocked, err)
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		respondRetry(c, http.StatusTooManyRequests, err)
+	case errors.Is(err, domain.ErrInvalidMFAToken), errors.Is(err, domain.ErrInvalidMFACode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *AuthHandler) EnrollTOTP(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	enrollment, err := h.authUsecase.EnrollTOTP(claims.TenantID, claims.UserID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, enrollment)
+}
+
+func (h *AuthHandler) ConfirmTOTP(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	var input dto.MFACodeDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	codes, err := h.authUsecase.ConfirmTOTP(claims.TenantID, claims.UserID, &input)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, codes)
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	var validationErrs validator.ValidationErrors
+	switch {
+	case errors.As(err, &validationErrs), errors.Is(err, domain.ErrInvalidMFACode):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrMFAAlreadyEnabled), errors.Is(err, domain.ErrMFANotEnrolled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+)
+
+type OIDCHandler struct {
+	oidcUsecase *usecases.OIDCUsecase
+}
+
+func NewOIDCHandler(oidcUsecase *usecases.OIDCUsecase) *OIDCHandler {
+	return &OIDCHandler{
+		oidcUsecase: oidcUsecase,
+	}
+}
+
+// Login redirects the browser to the identity provider.
+func (h *OIDCHandler) Login(c *gin.Context) {
+	redirectURL, err := h.oidcUsecase.BeginLogin(middleware.GetTenant(c))
+	switch {
+	case err == nil:
+		c.Redirect(http.StatusFound, redirectURL)
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+// Link starts linking a provider account to the signed-in user. It answers
+// with the provider URL for the client to open; the provider redirects back to
+// Callback, which links the account and signs in with it.
+func (h *OIDCHandler) Link(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	redirectURL, err := h.oidcUsecase.BeginLink(middleware.GetTenant(c), claims.UserID)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"authorization_url": redirectURL})
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
+
+// Callback is the redirect URI registered at the provider. It answers with
+// the same token pair, or MFA challenge, as the password login.
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": providerErr, "error_description": c.Query("error_description")})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "state and code are required"})
+		return
+	}
+
+	tokens, err := h.oidcUsecase.CompleteLogin(state, code, clientInfo(c))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tokens)
+	case errors.Is(err, auth.ErrOIDCDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrOIDCLinkRequired), errors.Is(err, domain.ErrOIDCAlreadyLinked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrIDNotFound), errors.Is(err, domain.ErrInvalidOIDCState), errors.Is(err, auth.ErrInvalidIDToken),
+		errors.Is(err, domain.ErrOIDCMissingClaim), errors.Is(err, domain.ErrInvalidCredentials),
+		errors.As(err, new(*domain.FieldError)):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type PasswordResetHandler struct {
+	passwordResetUsecase *usecases.PasswordResetUsecase
+	Logger               *zap.Logger
+}
+
+func NewPasswordResetHandler(passwordResetUsecase *usecases.PasswordResetUsecase, logger *zap.Logger) *PasswordResetHandler {
+	return &PasswordResetHandler{
+		passwordResetUsecase: passwordResetUsecase,
+		Logger:               logger,
+	}
+}
+
+// ForgotPassword always answers 202 once the payload is valid, whether or not
+// the document exists or the message could be sent.
+func (h *PasswordResetHandler) ForgotPassword(c *gin.Context) {
+	var input dto.ForgotPasswordDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.passwordResetUsecase.ForgotPassword(middleware.GetTenant(c), &input)
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error sending password reset", zap.Error(err))
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *PasswordResetHandler) ResetPassword(c *gin.Context) {
+	var input dto.ResetPasswordDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.passwordResetUsecase.ResetPassword(&input)
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.As(err, &validationErrs), errors.Is(err, domain.ErrInvalidResetToken):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error resetting password", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
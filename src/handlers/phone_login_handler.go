@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+type PhoneLoginHandler struct {
+	phoneLoginUsecase *usecases.PhoneLoginUsecase
+	Logger            *zap.Logger
+}
+
+func NewPhoneLoginHandler(phoneLoginUsecase *usecases.PhoneLoginUsecase, logger *zap.Logger) *PhoneLoginHandler {
+	return &PhoneLoginHandler{
+		phoneLoginUsecase: phoneLoginUsecase,
+		Logger:            logger,
+	}
+}
+
+// RequestCode always answers 202 once the payload is valid, so it cannot be
+// used to find out which phones are registered.
+func (h *PhoneLoginHandler) RequestCode(c *gin.Context) {
+	var input dto.RequestOTPDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.phoneLoginUsecase.RequestCode(middleware.GetTenant(c), &input)
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error sending login code", zap.Error(err))
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+func (h *PhoneLoginHandler) VerifyCode(c *gin.Context) {
+	var input dto.VerifyOTPDTO
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := h.phoneLoginUsecase.VerifyCode(middleware.GetTenant(c), &input, clientInfo(c))
+	var validationErrs validator.ValidationErrors
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, tokens)
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAccountLocked):
+		respondRetry(c, http.StatusLocked, err)
+	case errors.Is(err, domain.ErrTooManyAttempts):
+		respondRetry(c, http.StatusTooManyRequests, err)
+	case errors.Is(err, domain.ErrInvalidOTP):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error verifying login code", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
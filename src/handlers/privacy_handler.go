@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// PrivacyHandler serves the LGPD data subject requests.
+type PrivacyHandler struct {
+	privacyUsecase *usecases.PrivacyUsecase
+	logger         *zap.Logger
+}
+
+func NewPrivacyHandler(privacyUsecase *usecases.PrivacyUsecase, logger *zap.Logger) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyUsecase: privacyUsecase,
+		logger:         logger,
+	}
+}
+
+// ExportUser downloads everything stored about the user in the path.
+func (h *PrivacyHandler) ExportUser(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	h.export(c, claims.UserID, userID)
+}
+
+// ExportMe downloads everything stored about the authenticated user.
+func (h *PrivacyHandler) ExportMe(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	h.export(c, claims.UserID, claims.UserID)
+}
+
+func (h *PrivacyHandler) export(c *gin.Context, actorID, userID uuid.UUID) {
+	export, err := h.privacyUsecase.Export(middleware.GetTenant(c), actorID, userID)
+	switch {
+	case err == nil:
+		h.logger.Info("User data exported", zap.String("actor_id", actorID.String()), zap.String("user_id", userID.String()))
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%s.json"`, userID))
+		c.JSON(http.StatusOK, domain.OutputUserDataExport(export))
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Error exporting user data", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// AnonymizeUser irreversibly scrubs the personal data of the user in the
+// path and returns what is left of them.
+func (h *PrivacyHandler) AnonymizeUser(c *gin.Context) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": domain.ErrNotAuthenticated.Error()})
+		return
+	}
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	user, err := h.privacyUsecase.Anonymize(middleware.GetTenant(c), claims.UserID, userID)
+	switch {
+	case err == nil:
+		h.logger.Info("User anonymized", zap.String("actor_id", claims.UserID.String()), zap.String("user_id", userID.String()))
+		c.JSON(http.StatusOK, domain.OutputUser(user))
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		h.logger.Error("Error anonymizing user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+)
+
+// userExportWriter encodes the rows of a user export, one call per user.
+// Rows are buffered a few kilobytes at a time, never the whole export;
+// Flush writes what is left.
+type userExportWriter interface {
+	Write(row []interface{}) error
+	Flush() error
+}
+
+// csvExportWriter writes a header with the column names and a record per
+// row. Absent values are empty fields.
+type csvExportWriter struct {
+	writer *csv.Writer
+	// generated tells, per column, whether its values can be written as they
+	// are, see domain.UserExportColumnGenerated.
+	generated []bool
+}
+
+func newCSVExportWriter(w io.Writer, columns []string) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+
+	generated := make([]bool, len(columns))
+	for i, column := range columns {
+		generated[i] = domain.UserExportColumnGenerated(column)
+	}
+
+	return &csvExportWriter{writer: writer, generated: generated}, nil
+}
+
+func (w *csvExportWriter) Write(row []interface{}) error {
+	record := make([]string, len(row))
+	for i, value := range row {
+		switch value := value.(type) {
+		case nil:
+		case string:
+			record[i] = value
+			if !w.generated[i] {
+				record[i] = csvSafe(value)
+			}
+		case int64:
+			record[i] = strconv.FormatInt(value, 10)
+		}
+	}
+
+	return w.writer.Write(record)
+}
+
+func (w *csvExportWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+// csvSafe keeps spreadsheets from running a value typed by a user, such as
+// a name, as a formula: one starting with any of = + - @, a tab or a
+// carriage return gets a leading '.
+func csvSafe(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+
+	return value
+}
+
+// ndjsonExportWriter writes a JSON object per line, with the members in the
+// order of the columns. Absent values are null.
+type ndjsonExportWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+func newNDJSONExportWriter(w io.Writer, columns []string) *ndjsonExportWriter {
+	return &ndjsonExportWriter{writer: bufio.NewWriter(w), columns: columns}
+}
+
+func (w *ndjsonExportWriter) Write(row []interface{}) error {
+	line := []byte{'{'}
+	for i, value := range row {
+		if i > 0 {
+			line = append(line, ',')
+		}
+		key, _ := json.Marshal(w.columns[i])
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		line = append(append(append(line, key...), ':'), data...)
+	}
+	line = append(line, '}', '\n')
+
+	_, err := w.writer.Write(line)
+	return err
+}
+
+func (w *ndjsonExportWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -1,13 +1,20 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
+	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
 	"github.com/ThailanTec/challenger/pousada/src/usecases"
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
@@ -30,7 +37,19 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	u, err := h.UserUsecase.CreateUser(&user)
+	u, err := h.UserUsecase.CreateUser(middleware.GetTenant(c), &user)
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		h.Logger.Error("Invalid user payload", zap.Error(err))
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	var fieldErr *domain.FieldError
+	if errors.As(err, &fieldErr) {
+		h.Logger.Error("Invalid user payload", zap.Error(err))
+		c.JSON(http.StatusBadRequest, fieldErrorBody(fieldErr))
+		return
+	}
 	if err != nil {
 		h.Logger.Error("Error creating user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -42,23 +61,222 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	c.JSON(http.StatusCreated, output)
 }
 
+// GetUser lists the users of the tenant one page at a time. See
+// userFilterFromQuery for the query parameters.
 func (h *UserHandler) GetUser(c *gin.Context) {
-	u, err := h.UserUsecase.GetUsers()
-	h.Logger.Info("GetUser called", zap.String("user_id", c.Param("id")))
+	h.Logger.Info("GetUser called")
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !canSeeDeleted(c, filter) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + string(domain.PermUsersDelete)})
+		return
+	}
+
+	page, err := h.UserUsecase.GetUsers(filter)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.Logger.Error("Error getting users", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, u)
+	c.JSON(http.StatusOK, domain.OutputUserPage(page))
+}
 
+// ExportUsers streams every user matching the listing filters of
+// userFilterFromQuery, in their order, as CSV or NDJSON: the format query
+// parameter (csv or ndjson) picks one, else the Accept header, else CSV.
+// columns is a comma-separated list of the fields to export. A failure once
+// rows were sent can only cut the response short, so it is logged.
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !canSeeDeleted(c, filter) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + string(domain.PermUsersDelete)})
+		return
+	}
+	columns, err := domain.ParseUserExportColumns(c.Query("columns"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var contentType string
+	switch c.Query("format") {
+	case "csv":
+		contentType = domain.CSVType
+	case "ndjson":
+		contentType = domain.NDJSONType
+	case "":
+		switch c.NegotiateFormat(domain.CSVType, domain.NDJSONType, "application/ndjson") {
+		case domain.CSVType:
+			contentType = domain.CSVType
+		case domain.NDJSONType, "application/ndjson":
+			contentType = domain.NDJSONType
+		default:
+			c.JSON(http.StatusNotAcceptable, gin.H{"error": "export is available as " + domain.CSVType + " or " + domain.NDJSONType})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format"})
+		return
+	}
+
+	var writer userExportWriter
+	extension := "ndjson"
+	if contentType == domain.CSVType {
+		if writer, err = newCSVExportWriter(c.Writer, columns); err != nil {
+			h.Logger.Error("Error exporting users", zap.Error(err))
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		extension = "csv"
+	} else {
+		writer = newNDJSONExportWriter(c.Writer, columns)
+	}
+
+	c.Header("Content-Type", contentType+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.%s"`, time.Now().UTC().Format("20060102T150405Z"), extension))
+	c.Status(http.StatusOK)
+
+	count := 0
+	err = h.UserUsecase.ExportUsers(filter, func(user *domain.User) error {
+		count++
+		return writer.Write(domain.UserExportRow(user, columns))
+	})
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err != nil && !c.Writer.Written() {
+		c.Writer.Header().Del("Content-Disposition")
+		c.Writer.Header().Del("Content-Type")
+		h.Logger.Error("Error exporting users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("User export cut short", zap.Int("users", count), zap.Error(err))
+		return
+	}
+
+	h.Logger.Info("Users exported", zap.String("format", extension), zap.Int("users", count))
+}
+
+// canSeeDeleted reports whether the caller may see the deleted users filter
+// asks for: like GET /users/deleted, they need the permission to delete them.
+func canSeeDeleted(c *gin.Context, filter domain.UserFilter) bool {
+	if filter.Deleted == domain.DeletedExclude {
+		return true
+	}
+	claims, ok := middleware.GetClaims(c)
+	return !ok || claims.Can(domain.PermUsersDelete)
+}
+
+// userFilterFromQuery reads a user listing from the query parameters limit,
+// cursor, name (prefix), phone, created_from, created_to, deleted
+// (exclude, include or only), sort (created_at or name), order (asc or desc)
+// and include_total. Dates are RFC 3339 timestamps or plain days; a plain
+// created_to day is included in the range.
+func userFilterFromQuery(c *gin.Context) (domain.UserFilter, error) {
+	filter := domain.UserFilter{
+		TenantID:   middleware.GetTenant(c),
+		NamePrefix: c.Query("name"),
+		Phone:      c.Query("phone"),
+		Deleted:    c.DefaultQuery("deleted", domain.DeletedExclude),
+		Sort:       c.DefaultQuery("sort", domain.UserSortCreatedAt),
+	}
+	var err error
+
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil || filter.Limit < 1 {
+			return filter, errors.New("invalid limit")
+		}
+	}
+	if value := c.Query("cursor"); value != "" {
+		if filter.After, err = domain.DecodeUserCursor(value); err != nil {
+			return filter, err
+		}
+	}
+	if value := c.Query("created_from"); value != "" {
+		if filter.CreatedFrom, _, err = parseQueryTime(value); err != nil {
+			return filter, errors.New("invalid created_from")
+		}
+	}
+	if value := c.Query("created_to"); value != "" {
+		var day bool
+		if filter.CreatedTo, day, err = parseQueryTime(value); err != nil {
+			return filter, errors.New("invalid created_to")
+		}
+		if day {
+			filter.CreatedTo = filter.CreatedTo.AddDate(0, 0, 1)
+		}
+	}
+
+	switch filter.Deleted {
+	case domain.DeletedExclude, domain.DeletedInclude, domain.DeletedOnly:
+	default:
+		return filter, errors.New("invalid deleted")
+	}
+	switch filter.Sort {
+	case domain.UserSortCreatedAt, domain.UserSortName:
+	default:
+		return filter, errors.New("invalid sort")
+	}
+	switch c.DefaultQuery("order", "asc") {
+	case "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("invalid order")
+	}
+	if value := c.Query("include_total"); value != "" {
+		if filter.WithTotal, err = strconv.ParseBool(value); err != nil {
+			return filter, errors.New("invalid include_total")
+		}
+	}
+
+	return filter, nil
+}
+
+// parseQueryTime parses an RFC 3339 timestamp or a day, reporting which.
+func parseQueryTime(value string) (time.Time, bool, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at, false, nil
+	}
+
+	at, err := time.Parse("2006-01-02", value)
+	return at, true, err
 }
 
 func (h *UserHandler) GetUserByDocument(c *gin.Context) {
 	document := c.Param("document")
 	h.Logger.Info("GetUserByDocument called", zap.String("document", document))
-	u, err := h.UserUsecase.GetUserByDocument(document)
+	docType := domain.DocumentType(c.Query("type"))
+	if docType != "" && !docType.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": domain.ErrInvalidDocumentType.Error()})
+		return
+	}
+
+	u, err := h.UserUsecase.GetUserByDocument(middleware.GetTenant(c), docType, c.Query("country"), document)
+	if claims, ok := middleware.GetClaims(c); ok && !claims.Can(domain.PermUsersRead) {
+		if err != nil || u.ID != claims.UserID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "missing permission " + string(domain.PermUsersRead)})
+			return
+		}
+	}
+	if errors.Is(err, domain.ErrAmbiguousDocument) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		h.Logger.Error("Error getting user by document", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -67,28 +285,56 @@ func (h *UserHandler) GetUserByDocument(c *gin.Context) {
 
 	output := domain.OutputUser(u)
 
+	c.Header("ETag", u.ETag())
 	c.JSON(http.StatusOK, output)
 }
 
+// DeleteUser deletes a user. With an If-Match header the user is only
+// deleted if its ETag still matches, 412 otherwise.
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	id := c.Param("id")
-	userID := uuid.MustParse(id)
-	h.Logger.Info("DeleteUser called", zap.String("user_id", userID.String()))
-
-	err := h.UserUsecase.DeleteUser(userID)
+	h.Logger.Info("DeleteUser called", zap.String("user_id", id))
+	userID, err := uuid.Parse(id)
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	version, err := domain.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		respondVersionError(c, err)
+		return
+	}
+
+	err = h.UserUsecase.DeleteUser(middleware.GetTenant(c), userID, version)
+	switch {
+	case err == nil:
+		h.Logger.Info("User deleted successfully", zap.String("user_id", userID.String()))
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	default:
 		h.Logger.Error("Error deleting user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
-
-	h.Logger.Info("User deleted successfully", zap.String("user_id", userID.String()))
-	c.Status(http.StatusNoContent)
 }
 
+// UpdateUser replaces the editable fields of a user. With an If-Match header
+// the user is only updated if its ETag still matches, 412 otherwise.
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	id := c.Param("id")
 	h.Logger.Info("UpdateUser called", zap.String("user_id", id))
-	userID := uuid.MustParse(id)
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+	version, err := domain.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		respondVersionError(c, err)
+		return
+	}
 	var user dto.UserDTO
 	if err := c.ShouldBindJSON(&user); err != nil {
 		h.Logger.Error("Error binding JSON", zap.Error(err))
@@ -96,13 +342,187 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	usr, err := h.UserUsecase.UpdateUser(userID, &user)
-	if err != nil {
+	usr, err := h.UserUsecase.UpdateUser(middleware.GetTenant(c), userID, &user, version)
+	var validationErrs validator.ValidationErrors
+	var fieldErr *domain.FieldError
+	switch {
+	case err == nil:
+		h.Logger.Info("User updated successfully", zap.String("user_id", userID.String()))
+		c.Header("ETag", usr.ETag())
+		c.JSON(http.StatusOK, domain.OutputUser(usr))
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.As(err, &fieldErr):
+		c.JSON(http.StatusBadRequest, fieldErrorBody(fieldErr))
+	case errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
 		h.Logger.Error("Error updating user", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 	}
+}
 
-	output := domain.OutputUser(usr)
-	h.Logger.Info("User updated successfully", zap.String("user_id", userID.String()))
-	c.JSON(http.StatusOK, output)
+// PatchUser changes some fields of a user. The body is a JSON Merge Patch
+// (application/merge-patch+json, or plain application/json) or a JSON Patch
+// (application/json-patch+json) over name, phone, document, document_type and
+// document_country. With an If-Match header the patch is only applied if the
+// user's ETag still matches, 412 otherwise.
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id := c.Param("id")
+	h.Logger.Info("PatchUser called", zap.String("user_id", id))
+	userID, err := uuid.Parse(id)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	version, err := domain.ParseIfMatch(c.GetHeader("If-Match"))
+	if err != nil {
+		respondVersionError(c, err)
+		return
+	}
+
+	patchType := c.ContentType()
+	if patchType == "application/json" {
+		patchType = domain.MergePatchType
+	}
+	patch, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	usr, err := h.UserUsecase.PatchUser(middleware.GetTenant(c), userID, patchType, patch, version)
+	var validationErrs validator.ValidationErrors
+	var fieldErr *domain.FieldError
+	switch {
+	case err == nil:
+		h.Logger.Info("User patched successfully", zap.String("user_id", userID.String()))
+		c.Header("ETag", usr.ETag())
+		c.JSON(http.StatusOK, domain.OutputUser(usr))
+	case errors.Is(err, domain.ErrUnsupportedPatchType):
+		c.Header("Accept-Patch", domain.MergePatchType+", "+domain.JSONPatchType)
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrVersionMismatch):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrPatchTestFailed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.As(err, &fieldErr):
+		c.JSON(http.StatusBadRequest, fieldErrorBody(fieldErr))
+	case errors.Is(err, domain.ErrInvalidPatch), errors.As(err, &validationErrs):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error patching user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GetDeletedUsers lists the soft-deleted users of the tenant, with the same
+// query parameters as GetUser except deleted.
+func (h *UserHandler) GetDeletedUsers(c *gin.Context) {
+	filter, err := userFilterFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	filter.Deleted = domain.DeletedOnly
+
+	page, err := h.UserUsecase.GetUsers(filter)
+	if errors.Is(err, domain.ErrInvalidCursor) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		h.Logger.Error("Error getting deleted users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, domain.OutputUserPage(page))
+}
+
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	usr, err := h.UserUsecase.RestoreUser(middleware.GetTenant(c), userID)
+	switch {
+	case err == nil:
+		h.Logger.Info("User restored", zap.String("user_id", userID.String()))
+		c.Header("ETag", usr.ETag())
+		c.JSON(http.StatusOK, domain.OutputUser(usr))
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrUserConflict), errors.Is(err, domain.ErrUserAnonymized):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error restoring user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return
+	}
+
+	err = h.UserUsecase.PurgeUser(middleware.GetTenant(c), userID)
+	switch {
+	case err == nil:
+		h.Logger.Info("User purged", zap.String("user_id", userID.String()))
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, domain.ErrIDNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrUserNotDeleted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		h.Logger.Error("Error purging user", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// PurgeDeletedUsers permanently removes the users deleted before the required
+// deleted_before query parameter, an RFC 3339 timestamp or a day.
+func (h *UserHandler) PurgeDeletedUsers(c *gin.Context) {
+	deletedBefore, _, err := parseQueryTime(c.Query("deleted_before"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid or missing deleted_before"})
+		return
+	}
+
+	purged, err := h.UserUsecase.PurgeDeletedUsers(middleware.GetTenant(c), deletedBefore)
+	if err != nil {
+		h.Logger.Error("Error purging deleted users", zap.Error(err))
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.Logger.Info("Deleted users purged", zap.Int64("purged", purged))
+	c.JSON(http.StatusOK, gin.H{"purged": purged})
+}
+
+// fieldErrorBody describes a rejected field for a 400 response, so clients
+// can show the message next to it.
+func fieldErrorBody(err *domain.FieldError) gin.H {
+	return gin.H{"error": err.Error(), "fields": gin.H{err.Field: err.Err.Error()}}
+}
+
+// respondVersionError answers a request whose If-Match header could not be
+// honored: 412 for a tag that can never match, 400 for a malformed header.
+func respondVersionError(c *gin.Context, err error) {
+	if errors.Is(err, domain.ErrVersionMismatch) {
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 }
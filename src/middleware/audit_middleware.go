@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// AuditImpersonation records every request made with an impersonation token,
+// including the ones that were rejected. It must wrap the routes, so register
+// it with Use before AuthMiddleware runs: it reads the claims afterwards.
+// Because the entry is written after the handler, a failed insert cannot undo
+// the request, so every route that changes data except POST /logout must also
+// refuse impersonation with DenyImpersonation.
+func AuditImpersonation(auditUsecase *usecases.AuditUsecase, logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		claims, ok := GetClaims(c)
+		if !ok || !claims.IsImpersonation() {
+			return
+		}
+
+		err := auditUsecase.Record(&domain.AuditEntry{
+			TenantID: claims.TenantID,
+			ActorID:  claims.ActorID,
+			UserID:   claims.UserID,
+			Action:   domain.AuditImpersonatedRequest,
+			Method:   c.Request.Method,
+			Path:     c.Request.URL.Path,
+			Status:   c.Writer.Status(),
+			IP:       c.ClientIP(),
+		})
+		if err != nil {
+			logger.Error("Error recording impersonated request",
+				zap.String("actor_id", claims.ActorID.String()),
+				zap.String("user_id", claims.UserID.String()),
+				zap.Error(err))
+		}
+	}
+}
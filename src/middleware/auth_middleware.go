@@ -1,15 +1,91 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
-	"strings"
 
+	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-func JWTAuthMiddleware() gin.HandlerFunc {
+const (
+	claimsContextKey     = "auth_claims"
+	userContextKey       = "auth_user"
+	userLoaderContextKey = "auth_user_loader"
+	apiKeyHeader         = "X-API-Key"
+	// ImpersonatedByHeader is set on every response to an impersonation
+	// token, holding the id of the acting admin.
+	ImpersonatedByHeader = "X-Impersonated-By"
+)
+
+// GetClaims returns the claims JWTAuthMiddleware stored for this request.
+func GetClaims(c *gin.Context) (*auth.Claims, bool) {
+	value, ok := c.Get(claimsContextKey)
+	if !ok {
+		return nil, false
+	}
+
+	claims, ok := value.(*auth.Claims)
+	return claims, ok
+}
+
+// GetCurrentUser returns the authenticated user. It is only loaded from the
+// repository the first time it is asked for in a request. Requests made with
+// an API key have no user and get ErrNotAuthenticated.
+func GetCurrentUser(c *gin.Context) (*domain.User, error) {
+	if value, ok := c.Get(userContextKey); ok {
+		return value.(*domain.User), nil
+	}
+
+	claims, ok := GetClaims(c)
+	if !ok {
+		return nil, domain.ErrNotAuthenticated
+	}
+
+	value, _ := c.Get(userLoaderContextKey)
+	loader, ok := value.(func(string, uuid.UUID) (*domain.User, error))
+	if !ok {
+		return nil, domain.ErrNotAuthenticated
+	}
+
+	user, err := loader(claims.TenantID, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(userContextKey, user)
+	return user, nil
+}
+
+// JWTAuthMiddleware only accepts Bearer JWTs, for routes that act on a user
+// session such as /me and /logout.
+func JWTAuthMiddleware(authUsecase *usecases.AuthUsecase) gin.HandlerFunc {
+	return AuthMiddleware(authUsecase, nil)
+}
+
+// AuthMiddleware accepts either a Bearer JWT or, when apiKeyUsecase is set, an
+// API key in the X-API-Key header.
+func AuthMiddleware(authUsecase *usecases.AuthUsecase, apiKeyUsecase *usecases.APIKeyUsecase) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := c.GetHeader(apiKeyHeader); apiKey != "" && apiKeyUsecase != nil {
+			claims, err := apiKeyUsecase.AuthenticateAPIKey(apiKey)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+			if !bindTenant(c, claims.TenantID) {
+				return
+			}
+
+			c.Set(claimsContextKey, claims)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is missing"})
@@ -17,26 +93,34 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
+		tokenString, ok := auth.ExtractBearerToken(authHeader)
+		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Bearer token missing"})
 			c.Abort()
 			return
 		}
 
-		token, err := auth.ParseToken(tokenString)
+		claims, err := authUsecase.AuthenticateToken(tokenString)
+		if errors.Is(err, domain.ErrTokenRevoked) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token has been revoked"})
+			c.Abort()
+			return
+		}
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token: " + err.Error()})
 			c.Abort()
 			return
 		}
 
-		if !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+		if !bindTenant(c, claims.TenantID) {
 			return
 		}
+		if claims.IsImpersonation() {
+			c.Header(ImpersonatedByHeader, claims.ActorID.String())
+		}
 
+		c.Set(claimsContextKey, claims)
+		c.Set(userLoaderContextKey, authUsecase.GetUser)
 		c.Next()
 	}
 }
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireIfMatch answers 428 to requests without an If-Match header when
+// required is set, so clients cannot overwrite changes they have not seen.
+// When it is not, the header stays optional.
+func RequireIfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if required && c.GetHeader("If-Match") == "" {
+			c.AbortWithStatusJSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header required"})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequireRole lets the request through when the caller has any of the roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...domain.Role) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+			return
+		}
+
+		for _, role := range roles {
+			if claims.Role == role {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "insufficient role"})
+	}
+}
+
+// RequirePermission lets the request through when the caller's role grants
+// every one of the permissions. It must run after AuthMiddleware.
+func RequirePermission(permissions ...domain.Permission) gin.HandlerFunc {
+	return requirePermission("", permissions)
+}
+
+// RequirePermissionOrSelf behaves like RequirePermission but also lets callers
+// act on their own record, identified by the user id in the idParam route
+// parameter.
+func RequirePermissionOrSelf(idParam string, permissions ...domain.Permission) gin.HandlerFunc {
+	return requirePermission(idParam, permissions)
+}
+
+func requirePermission(idParam string, permissions []domain.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+			return
+		}
+
+		if idParam != "" && c.Param(idParam) == claims.UserID.String() {
+			c.Next()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !claims.Can(permission) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing permission " + string(permission)})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireTargetRank refuses requests on the user in the idParam route
+// parameter when its role ranks above the caller's, so that, say, staff with
+// users:write cannot change an admin's phone or password. Callers may always
+// act on themselves. A target loadUser cannot find is left for the handler to
+// report. It must run after AuthMiddleware.
+func RequireTargetRank(idParam string, loadUser func(tenantID string, id uuid.UUID) (*domain.User, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "not authenticated"})
+			return
+		}
+
+		targetID, err := uuid.Parse(c.Param(idParam))
+		if err != nil || targetID == claims.UserID {
+			c.Next()
+			return
+		}
+
+		target, err := loadUser(claims.TenantID, targetID)
+		if errors.Is(err, domain.ErrIDNotFound) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if !claims.Manages(target.Role) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": domain.ErrTargetOutranks.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DenyImpersonation blocks requests made with an impersonation token, for
+// actions only the account owner should take, like changing credentials.
+// It must run after AuthMiddleware.
+func DenyImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := GetClaims(c)
+		if ok && claims.IsImpersonation() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": domain.ErrImpersonationForbidden.Error()})
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tenantContextKey = "tenant_id"
+	// TenantHeader names the property a request is addressed to.
+	TenantHeader = "X-Tenant-ID"
+)
+
+// TenantMiddleware resolves the tenant of the request from the X-Tenant-ID
+// header, or domain.DefaultTenant when there is none, and rejects tenants not
+// listed in cfg.Tenants. AuthMiddleware later replaces it with the tenant of
+// the token.
+func TenantMiddleware(cfg config.Config) gin.HandlerFunc {
+	allowed := map[string]bool{}
+	for _, tenantID := range strings.Split(cfg.Tenants, ",") {
+		if tenantID = strings.TrimSpace(tenantID); domain.ValidTenant(tenantID) {
+			allowed[tenantID] = true
+		}
+	}
+
+	return func(c *gin.Context) {
+		tenantID := strings.TrimSpace(c.GetHeader(TenantHeader))
+		if tenantID == "" {
+			tenantID = domain.DefaultTenant
+		}
+
+		if !allowed[tenantID] {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": domain.ErrUnknownTenant.Error()})
+			return
+		}
+
+		c.Set(tenantContextKey, tenantID)
+		c.Next()
+	}
+}
+
+// GetTenant returns the tenant the request acts on: the one of the token for
+// authenticated requests, else the one TenantMiddleware resolved.
+func GetTenant(c *gin.Context) string {
+	if tenantID := c.GetString(tenantContextKey); tenantID != "" {
+		return tenantID
+	}
+
+	return domain.DefaultTenant
+}
+
+// bindTenant makes the authenticated tenant the request's tenant. A request
+// naming another tenant in its header is refused rather than silently served
+// from the token's tenant.
+func bindTenant(c *gin.Context, tenantID string) bool {
+	if requested := strings.TrimSpace(c.GetHeader(TenantHeader)); requested != "" && requested != tenantID {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": domain.ErrTenantMismatch.Error()})
+		return false
+	}
+
+	c.Set(tenantContextKey, tenantID)
+	return true
+}
@@ -1,7 +1,11 @@
 package routes
 
 import (
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/notifier"
 	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/infra/sms"
 	"github.com/ThailanTec/challenger/pousada/src/config"
 	handler "github.com/ThailanTec/challenger/pousada/src/handlers"
 	"github.com/ThailanTec/challenger/pousada/src/middleware"
@@ -12,23 +16,104 @@ import (
 	"gorm.io/gorm"
 )
 
-func RegisterRoutes(r *gin.Engine, db *gorm.DB, clientRedis *redis.Client, cfg config.Config, logger *zap.Logger) {
+func RegisterRoutes(r *gin.Engine, db *gorm.DB, clientRedis *redis.Client, keys *auth.KeySet, notify notifier.Notifier, sender sms.Sender, cfg config.Config, logger *zap.Logger) {
 	userRepo := repositories.NewUserRepository(db)
 	redisRepo := repositories.NewRedisRepository(clientRedis)
-	userUsecase := usecases.NewUserUsecase(userRepo, redisRepo, cfg.RedisTLL)
+	userUsecase := usecases.NewUserUsecase(userRepo, redisRepo, cfg.RedisTLL, cfg.PhoneDefaultCountry)
 	userHandler := handler.NewUserHandler(userUsecase, logger)
-	authUsecase := usecases.NewAuthUsecase(userRepo, cfg)
+	authUsecase := usecases.NewAuthUsecase(userRepo, redisRepo, keys, cfg)
 	authHandler := handler.NewAuthHandler(authUsecase)
+	meHandler := handler.NewMeHandler(userUsecase, authUsecase, logger)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	apiKeyUsecase := usecases.NewAPIKeyUsecase(apiKeyRepo)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyUsecase)
+	oidcUsecase := usecases.NewOIDCUsecase(auth.NewOIDCProvider(cfg), userRepo, redisRepo, authUsecase, cfg)
+	oidcHandler := handler.NewOIDCHandler(oidcUsecase)
+	passwordResetUsecase := usecases.NewPasswordResetUsecase(userRepo, redisRepo, notify, authUsecase, cfg)
+	passwordResetHandler := handler.NewPasswordResetHandler(passwordResetUsecase, logger)
+	phoneLoginUsecase := usecases.NewPhoneLoginUsecase(userRepo, redisRepo, sender, authUsecase, cfg)
+	phoneLoginHandler := handler.NewPhoneLoginHandler(phoneLoginUsecase, logger)
+	auditRepo := repositories.NewAuditRepository(db)
+	auditUsecase := usecases.NewAuditUsecase(auditRepo)
+	auditHandler := handler.NewAuditHandler(auditUsecase)
+	impersonationUsecase := usecases.NewImpersonationUsecase(userRepo, authUsecase, auditUsecase, cfg)
+	impersonationHandler := handler.NewImpersonationHandler(impersonationUsecase, logger)
+	privacyUsecase := usecases.NewPrivacyUsecase(userRepo, auditRepo, apiKeyRepo, redisRepo, authUsecase, auditUsecase)
+	privacyHandler := handler.NewPrivacyHandler(privacyUsecase, logger)
+	importUsecase := usecases.NewImportUsecase(userRepo, redisRepo, cfg)
+	importHandler := handler.NewImportHandler(importUsecase, cfg.ImportMaxBytes, logger)
+
+	r.Use(middleware.TenantMiddleware(cfg), middleware.AuditImpersonation(auditUsecase, logger))
 
 	r.POST("", userHandler.CreateUser)
 	r.POST("/login", authHandler.Login)
+	r.POST("/login/mfa", authHandler.LoginMFA)
+	r.POST("/login/otp", phoneLoginHandler.RequestCode)
+	r.POST("/login/otp/verify", phoneLoginHandler.VerifyCode)
+	r.GET("/login/oidc", oidcHandler.Login)
+	r.GET("/login/oidc/callback", oidcHandler.Callback)
+	r.GET("/.well-known/jwks.json", authHandler.JWKS)
+	r.POST("/token/refresh", authHandler.RefreshToken)
+	r.POST("/password/forgot", passwordResetHandler.ForgotPassword)
+	r.POST("/password/reset", passwordResetHandler.ResetPassword)
+	r.POST("/logout", middleware.JWTAuthMiddleware(authUsecase), authHandler.Logout)
+
+	meRoutes := r.Group("/me")
+	meRoutes.Use(middleware.JWTAuthMiddleware(authUsecase))
+	{
+		meRoutes.GET("", meHandler.GetMe)
+		meRoutes.PUT("", middleware.DenyImpersonation(), middleware.RequireIfMatch(cfg.RequireIfMatch), meHandler.UpdateMe)
+		meRoutes.DELETE("", middleware.DenyImpersonation(), middleware.RequireIfMatch(cfg.RequireIfMatch), meHandler.DeleteMe)
+		meRoutes.GET("/export", middleware.DenyImpersonation(), privacyHandler.ExportMe)
+		meRoutes.POST("/oidc/link", middleware.DenyImpersonation(), oidcHandler.Link)
+		meRoutes.GET("/sessions", meHandler.GetSessions)
+		meRoutes.DELETE("/sessions/:id", middleware.DenyImpersonation(), meHandler.DeleteSession)
+
+		// TOTP is offered to the accounts that can reach guest data.
+		mfaRoutes := meRoutes.Group("/mfa/totp", middleware.DenyImpersonation(), middleware.RequireRole(domain.RoleStaff, domain.RoleAdmin))
+		mfaRoutes.POST("", authHandler.EnrollTOTP)
+		mfaRoutes.POST("/confirm", authHandler.ConfirmTOTP)
+	}
 
 	userRoutes := r.Group("/users")
-	userRoutes.Use(middleware.JWTAuthMiddleware())
+	userRoutes.Use(middleware.AuthMiddleware(authUsecase, apiKeyUsecase))
+	// Routes that change another active user also need the caller to rank
+	// at least as high as that user.
+	targetRank := middleware.RequireTargetRank("id", authUsecase.GetUser)
 	{
-		userRoutes.GET("", userHandler.GetUser)
+		userRoutes.GET("", middleware.RequirePermission(domain.PermUsersRead), userHandler.GetUser)
+		userRoutes.GET("deleted", middleware.RequirePermission(domain.PermUsersDelete), userHandler.GetDeletedUsers)
+		userRoutes.DELETE("deleted", middleware.RequirePermission(domain.PermUsersPurge), userHandler.PurgeDeletedUsers)
+		userRoutes.POST("import", middleware.RequirePermission(domain.PermUsersImport), importHandler.ImportUsers)
+		userRoutes.GET("export", middleware.RequirePermission(domain.PermUsersExport), userHandler.ExportUsers)
 		userRoutes.GET(":document", userHandler.GetUserByDocument)
-		userRoutes.DELETE(":id", userHandler.DeleteUser)
-		userRoutes.PUT(":id", userHandler.UpdateUser)
+		userRoutes.DELETE(":id", middleware.RequirePermission(domain.PermUsersDelete), targetRank, middleware.RequireIfMatch(cfg.RequireIfMatch), userHandler.DeleteUser)
+		userRoutes.PUT(":id", middleware.DenyImpersonation(), middleware.RequirePermissionOrSelf("id", domain.PermUsersWrite), targetRank, middleware.RequireIfMatch(cfg.RequireIfMatch), userHandler.UpdateUser)
+		userRoutes.PATCH(":id", middleware.DenyImpersonation(), middleware.RequirePermissionOrSelf("id", domain.PermUsersWrite), targetRank, middleware.RequireIfMatch(cfg.RequireIfMatch), userHandler.PatchUser)
+		userRoutes.POST(":id/restore", middleware.RequirePermission(domain.PermUsersDelete), userHandler.RestoreUser)
+		userRoutes.DELETE(":id/purge", middleware.RequirePermission(domain.PermUsersPurge), userHandler.PurgeUser)
+		userRoutes.PUT(":id/password", middleware.DenyImpersonation(), middleware.RequirePermissionOrSelf("id", domain.PermUsersWrite), targetRank, authHandler.ChangePassword)
+		userRoutes.PUT(":id/role", middleware.RequirePermission(domain.PermRolesManage), targetRank, authHandler.UpdateRole)
+		userRoutes.DELETE(":id/lockout", middleware.RequirePermission(domain.PermUsersUnlock), targetRank, authHandler.UnlockUser)
+		userRoutes.GET(":id/sessions", middleware.RequirePermissionOrSelf("id", domain.PermSessionsRevoke), authHandler.ListUserSessions)
+		userRoutes.DELETE(":id/sessions", middleware.DenyImpersonation(), middleware.RequirePermissionOrSelf("id", domain.PermSessionsRevoke), targetRank, authHandler.RevokeUserSessions)
+		userRoutes.DELETE(":id/sessions/:session_id", middleware.DenyImpersonation(), middleware.RequirePermissionOrSelf("id", domain.PermSessionsRevoke), targetRank, authHandler.RevokeUserSession)
+		userRoutes.GET(":id/export", middleware.RequirePermission(domain.PermUsersPrivacy), privacyHandler.ExportUser)
+		userRoutes.POST(":id/anonymize", middleware.DenyImpersonation(), middleware.RequirePermission(domain.PermUsersPrivacy), privacyHandler.AnonymizeUser)
+		userRoutes.POST(":id/impersonate", middleware.RequirePermission(domain.PermImpersonate), impersonationHandler.Impersonate)
+	}
+
+	apiKeyRoutes := r.Group("/api-keys")
+	apiKeyRoutes.Use(middleware.AuthMiddleware(authUsecase, apiKeyUsecase), middleware.RequirePermission(domain.PermAPIKeysManage))
+	{
+		apiKeyRoutes.POST("", apiKeyHandler.CreateAPIKey)
+		apiKeyRoutes.GET("", apiKeyHandler.GetAPIKeys)
+		apiKeyRoutes.DELETE(":id", apiKeyHandler.RevokeAPIKey)
+	}
+
+	auditRoutes := r.Group("/audit-logs")
+	auditRoutes.Use(middleware.AuthMiddleware(authUsecase, apiKeyUsecase), middleware.RequirePermission(domain.PermAuditRead))
+	{
+		auditRoutes.GET("", auditHandler.GetAuditEntries)
 	}
 }
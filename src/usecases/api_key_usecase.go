@@ -0,0 +1,109 @@
+package usecases
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// apiKeyTouchInterval limits how often last-used tracking writes to the
+// database for a busy key.
+const apiKeyTouchInterval = time.Minute
+
+type APIKeyUsecase struct {
+	apiKeyRepo repositories.APIKeyRepository
+	validate   *validator.Validate
+}
+
+func NewAPIKeyUsecase(apiKeyRepo repositories.APIKeyRepository) *APIKeyUsecase {
+	return &APIKeyUsecase{
+		apiKeyRepo: apiKeyRepo,
+		validate:   validator.New(),
+	}
+}
+
+// CreateAPIKey issues a key on behalf of creator. A key can only be granted
+// scopes the creator holds itself. The plain key is in the result and cannot
+// be recovered afterwards.
+func (u *APIKeyUsecase) CreateAPIKey(creator *auth.Claims, input *dto.CreateAPIKeyDTO) (*dto.CreatedAPIKeyDTO, error) {
+	if err := u.validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	scopes := make([]domain.Permission, 0, len(input.Scopes))
+	for _, scope := range input.Scopes {
+		permission := domain.Permission(scope)
+		if !permission.Valid() || !creator.Can(permission) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidScope, scope)
+		}
+		scopes = append(scopes, permission)
+	}
+
+	plain, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	key := &domain.APIKey{
+		Name:       input.Name,
+		Prefix:     prefix,
+		SecretHash: auth.HashToken(plain),
+		Scopes:     scopes,
+		TenantID:   creator.TenantID,
+		CreatedBy:  creator.UserID,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	if err := u.apiKeyRepo.CreateAPIKey(key); err != nil {
+		return nil, err
+	}
+
+	return &dto.CreatedAPIKeyDTO{APIKeyResponseDTO: *domain.OutputAPIKey(key), Key: plain}, nil
+}
+
+func (u *APIKeyUsecase) GetAPIKeys(tenantID string) ([]*domain.APIKey, error) {
+	return u.apiKeyRepo.GetAPIKeys(tenantID)
+}
+
+func (u *APIKeyUsecase) RevokeAPIKey(tenantID string, id uuid.UUID) error {
+	return u.apiKeyRepo.RevokeAPIKey(tenantID, id, time.Now())
+}
+
+// AuthenticateAPIKey resolves an X-API-Key header value to the claims of the
+// key, recording when it was last used.
+func (u *APIKeyUsecase) AuthenticateAPIKey(plain string) (*auth.Claims, error) {
+	prefix, ok := auth.ParseAPIKey(plain)
+	if !ok {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	key, err := u.apiKeyRepo.GetAPIKeyByPrefix(prefix)
+	if errors.Is(err, domain.ErrAPIKeyNotFound) {
+		return nil, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(auth.HashToken(plain))) != 1 || key.RevokedAt != nil {
+		return nil, domain.ErrInvalidAPIKey
+	}
+
+	now := time.Now()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// Last-used tracking is informational; a failed write must not
+		// reject an otherwise valid request.
+		_ = u.apiKeyRepo.TouchAPIKey(key.ID, now)
+	}
+
+	return &auth.Claims{APIKeyID: key.ID, TenantID: tenantOrDefault(key.TenantID), Scopes: key.Scopes}, nil
+}
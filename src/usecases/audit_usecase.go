@@ -0,0 +1,37 @@
+package usecases
+
+import (
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+)
+
+// maxAuditLimit caps how many entries a single listing may return.
+const maxAuditLimit = 500
+
+type AuditUsecase struct {
+	auditRepo repositories.AuditRepository
+}
+
+func NewAuditUsecase(auditRepo repositories.AuditRepository) *AuditUsecase {
+	return &AuditUsecase{auditRepo: auditRepo}
+}
+
+// Record stores an audit entry, stamping it with the current time when the
+// caller left CreatedAt empty.
+func (u *AuditUsecase) Record(entry *domain.AuditEntry) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	return u.auditRepo.CreateAuditEntry(entry)
+}
+
+func (u *AuditUsecase) GetAuditEntries(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+
+	return u.auditRepo.GetAuditEntries(filter)
+}
@@ -1,49 +1,433 @@
 package usecases
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/ThailanTec/challenger/pousada/infra/auth"
 	"github.com/ThailanTec/challenger/pousada/infra/repositories"
 	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	refreshTokenKey  = "refresh_token:%s"
+	refreshUsedKey   = "refresh_used:%s"
+	refreshFamilyKey = "refresh_family:%s"
+	revokedTokenKey  = "revoked_token:%s"
+	revokedBeforeKey = "revoked_before:%s"
 )
 
 type AuthUsecase struct {
-	userRepo repositories.UserRepository
-	cfg      config.Config
+	userRepo  repositories.UserRepository
+	redisRepo repositories.RedisRepository
+	keys      *auth.KeySet
+	limiter   *loginLimiter
+	cfg       config.Config
+	validate  *validator.Validate
 }
 
-func NewAuthUsecase(userRepo repositories.UserRepository, cfg config.Config) *AuthUsecase {
+// refreshTokenRecord is what gets stored in Redis under the hash of a refresh
+// token. Every token issued from the same login shares a FamilyID.
+type refreshTokenRecord struct {
+	UserID   uuid.UUID `json:"user_id"`
+	TenantID string    `json:"tenant_id"`
+	FamilyID uuid.UUID `json:"family_id"`
+}
+
+func NewAuthUsecase(userRepo repositories.UserRepository, redisRepo repositories.RedisRepository, keys *auth.KeySet, cfg config.Config) *AuthUsecase {
 	return &AuthUsecase{
-		userRepo: userRepo,
-		cfg:      cfg,
+		userRepo:  userRepo,
+		redisRepo: redisRepo,
+		keys:      keys,
+		limiter:   newLoginLimiter(redisRepo, cfg),
+		cfg:       cfg,
+		validate:  validator.New(),
+	}
+}
+
+// Login checks the credentials and issues a new token pair, or only a
+// short-lived MFA token when the user has a second factor enabled. Failed
+// attempts are throttled per document and per client IP; while throttled a
+// *domain.RetryError wrapping ErrTooManyAttempts or ErrAccountLocked is
+// returned without looking at the password. The document is found like
+// GET /users/:document finds it, see findUserByDocument.
+func (u *AuthUsecase) Login(tenantID string, credentials *dto.LoginDTO, client domain.ClientInfo) (*dto.TokenResponseDTO, error) {
+	if err := u.validate.Struct(credentials); err != nil {
+		return nil, err
+	}
+
+	docType := domain.DocumentType(credentials.DocumentType)
+	document := domain.NormalizeDocumentNumber(docType, credentials.Document)
+	identifier := loginIdentifier(tenantID, document)
+	if err := u.limiter.Allow(identifier, client.IP); err != nil {
+		return nil, err
+	}
+
+	user, err := findUserByDocument(u.userRepo, tenantID, docType, credentials.DocumentCountry, credentials.Document)
+	if err != nil {
+		_ = auth.CheckPassword("", credentials.Password)
+		return nil, u.loginFailed(identifier, client.IP, domain.ErrInvalidCredentials)
+	}
+
+	if err := auth.CheckPassword(user.PasswordHash, credentials.Password); err != nil {
+		return nil, u.loginFailed(identifier, client.IP, domain.ErrInvalidPassword)
+	}
+
+	if user.MFAEnabled {
+		return u.mfaChallenge(user)
+	}
+
+	if err := u.limiter.Succeed(identifier); err != nil {
+		return nil, err
+	}
+
+	return u.startSession(user, client)
+}
+
+// RefreshToken rotates a refresh token: the presented token is marked as used
+// and a new access+refresh pair from the same family is returned. Presenting a
+// token that was already rotated revokes the whole family, since it means the
+// token leaked and is being replayed.
+func (u *AuthUsecase) RefreshToken(refreshToken string) (*dto.TokenResponseDTO, error) {
+	hash := auth.HashToken(refreshToken)
+
+	stored, err := u.redisRepo.Get(fmt.Sprintf(refreshTokenKey, hash))
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	familyKey := fmt.Sprintf(refreshFamilyKey, record.FamilyID)
+	familyIssuedAt, err := u.redisRepo.Get(familyKey)
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	issuedAt, _ := parseUnixNano(familyIssuedAt, false)
+	revoked, err := u.issuedBeforeRevocation(record.UserID, issuedAt)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(refreshUsedKey, hash), time.Now().Unix(), u.refreshTTL())
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		if err := u.redisRepo.Delete(familyKey); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+
+	// Reload the user so role changes take effect on the next refresh.
+	user, err := u.userRepo.GetUserByID(tenantOrDefault(record.TenantID), record.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	if err := u.touchSession(record.FamilyID); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(user, record.FamilyID)
+}
+
+// AuthenticateToken validates the access token signature and expiry and then
+// checks it against the server-side revocation state kept in Redis, including
+// whether the session it was issued for is still alive.
+func (u *AuthUsecase) AuthenticateToken(token string) (*auth.Claims, error) {
+	claims, err := auth.ValidateJWT(token, u.keys)
+	if err != nil {
+		return nil, err
+	}
+	if claims.MFAPending {
+		return nil, domain.ErrMFARequired
+	}
+	claims.TenantID = tenantOrDefault(claims.TenantID)
+
+	_, err = u.redisRepo.Get(fmt.Sprintf(revokedTokenKey, claims.Id))
+	if err == nil {
+		return nil, domain.ErrTokenRevoked
+	}
+	if !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	revoked, err := u.issuedBeforeRevocation(claims.UserID, claims.IssuedAtUnixNano())
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, domain.ErrTokenRevoked
+	}
+
+	// Revoking the admin's sessions also ends their impersonations.
+	if claims.IsImpersonation() {
+		revoked, err := u.issuedBeforeRevocation(claims.ActorID, claims.IssuedAtUnixNano())
+		if err != nil {
+			return nil, err
+		}
+		if revoked {
+			return nil, domain.ErrTokenRevoked
+		}
+	}
+
+	// Tokens issued before sessions were tracked carry no session id.
+	if claims.SessionID != uuid.Nil {
+		active, err := u.sessionActive(claims.UserID, claims.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		if !active {
+			return nil, domain.ErrTokenRevoked
+		}
+		if err := u.touchSession(claims.SessionID); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// Logout denylists the access token for the rest of its lifetime and, when a
+// refresh token is given, revokes the refresh token family it belongs to.
+func (u *AuthUsecase) Logout(token, refreshToken string) error {
+	claims, err := auth.ValidateJWT(token, u.keys)
+	if err != nil {
+		return err
+	}
+
+	if ttl := claims.RemainingLifetime(); ttl > 0 {
+		if err := u.redisRepo.Set(fmt.Sprintf(revokedTokenKey, claims.Id), claims.UserID.String(), ttl); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+
+	stored, err := u.redisRepo.Get(fmt.Sprintf(refreshTokenKey, auth.HashToken(refreshToken)))
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var record refreshTokenRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil || record.UserID != claims.UserID {
+		return nil
+	}
+
+	return u.forgetSession(record.UserID, record.FamilyID)
+}
+
+// RevokeUserSessions invalidates every access and refresh token issued to the
+// user up to now. Tokens issued afterwards are not affected.
+func (u *AuthUsecase) RevokeUserSessions(tenantID string, userID uuid.UUID) error {
+	if _, err := u.userRepo.GetUserByID(tenantID, userID); err != nil {
+		return err
+	}
+
+	return u.RevokeDeletedUserSessions(userID)
+}
+
+// RevokeDeletedUserSessions revokes like RevokeUserSessions without looking
+// the user up, for a user that was just deleted.
+func (u *AuthUsecase) RevokeDeletedUserSessions(userID uuid.UUID) error {
+	ttl := u.refreshTTL()
+	if accessTTL := time.Duration(u.cfg.JWTExpirationMinutes) * time.Minute; accessTTL > ttl {
+		ttl = accessTTL
 	}
+
+	return u.redisRepo.Set(fmt.Sprintf(revokedBeforeKey, userID), time.Now().UnixNano(), ttl)
+}
+
+// JWKS lists the public keys other services can verify our tokens with.
+func (u *AuthUsecase) JWKS() auth.JWKS {
+	return u.keys.JWKS()
 }
 
-func (u *AuthUsecase) Login(document string) (string, error) {
-	user, err := u.userRepo.GetUserByData(document)
+// UpdateRole assigns a new role and revokes the user's current tokens so the
+// old role cannot outlive the change. The caller must rank at least as high
+// as both the user and the role granted, see auth.Claims.Manages, so nobody
+// hands out more than they hold.
+func (u *AuthUsecase) UpdateRole(caller *auth.Claims, tenantID string, id uuid.UUID, input *dto.UpdateRoleDTO) error {
+	if err := u.validate.Struct(input); err != nil {
+		return err
+	}
+
+	role := domain.Role(input.Role)
+	if !role.Valid() {
+		return domain.ErrInvalidRole
+	}
+	if !caller.Manages(role) {
+		return domain.ErrTargetOutranks
+	}
+
+	user, err := u.userRepo.GetUserByID(tenantID, id)
+	if err != nil {
+		return err
+	}
+	if !caller.Manages(user.Role) {
+		return domain.ErrTargetOutranks
+	}
+	if err := u.userRepo.UpdateRole(tenantID, id, role); err != nil {
+		return err
+	}
+	// The cached profile holds the old role and version.
+	if err := u.redisRepo.Delete(userCacheKeyOf(tenantID, user)); err != nil {
+		return err
+	}
+
+	return u.RevokeUserSessions(tenantID, id)
+}
+
+// UnlockUser lifts a brute-force lockout on the user's login before it
+// expires on its own.
+func (u *AuthUsecase) UnlockUser(tenantID string, id uuid.UUID) error {
+	user, err := u.userRepo.GetUserByID(tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	return u.limiter.Unlock(loginIdentifier(user.TenantID, user.Document))
+}
+
+func (u *AuthUsecase) ChangePassword(tenantID string, id uuid.UUID, input *dto.ChangePasswordDTO) error {
+	if err := u.validate.Struct(input); err != nil {
+		return err
+	}
+
+	user, err := u.userRepo.GetUserByID(tenantID, id)
 	if err != nil {
-		return "", errors.New("invalid credentials")
+		return err
+	}
+
+	if err := auth.CheckPassword(user.PasswordHash, input.CurrentPassword); err != nil {
+		return domain.ErrInvalidPassword
 	}
 
-	token, err := auth.GenerateJWT(user.ID, u.cfg)
+	hash, err := auth.HashPassword(input.NewPassword)
 	if err != nil {
-		return "", err
+		return err
+	}
+
+	return u.userRepo.UpdatePassword(tenantID, id, hash)
+}
+
+func (u *AuthUsecase) GetUser(tenantID string, id uuid.UUID) (*domain.User, error) {
+	return u.userRepo.GetUserByID(tenantID, id)
+}
+
+func (u *AuthUsecase) loginFailed(identifier, clientIP string, cause error) error {
+	if err := u.limiter.Fail(identifier, clientIP); err != nil {
+		return err
 	}
 
-	return token, nil
+	return cause
 }
 
-func (u *AuthUsecase) ValidateToken(token string) (*domain.User, error) {
-	claims, err := auth.ValidateJWT(token, u.cfg)
+func (u *AuthUsecase) issueTokens(user *domain.User, familyID uuid.UUID) (*dto.TokenResponseDTO, error) {
+	accessToken, err := auth.GenerateJWT(auth.Claims{UserID: user.ID, Role: user.Role, TenantID: user.TenantID, SessionID: familyID}, u.keys, u.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := json.Marshal(refreshTokenRecord{UserID: user.ID, TenantID: user.TenantID, FamilyID: familyID})
 	if err != nil {
 		return nil, err
 	}
-	user, err := u.userRepo.GetUserByID(claims.UserID)
+
+	err = u.redisRepo.Set(fmt.Sprintf(refreshTokenKey, auth.HashToken(refreshToken)), record, u.refreshTTL())
 	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
+	return &dto.TokenResponseDTO{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    u.cfg.JWTExpirationMinutes * 60,
+	}, nil
+}
+
+// issuedBeforeRevocation reports whether something issued at issuedAt, in
+// Unix nanoseconds, predates the user's last "revoke all".
+func (u *AuthUsecase) issuedBeforeRevocation(userID uuid.UUID, issuedAt int64) (bool, error) {
+	revokedBefore, err := u.redisRepo.Get(fmt.Sprintf(revokedBeforeKey, userID))
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	cutoff, err := parseUnixNano(revokedBefore, true)
+	if err != nil {
+		return false, err
+	}
+
+	return issuedAt <= cutoff, nil
+}
+
+// legacyUnixSeconds bounds the times stored in Unix seconds, before they were
+// stored in nanoseconds: no nanosecond timestamp since 1970-01-12 is lower.
+const legacyUnixSeconds = 1e15
+
+// parseUnixNano reads a time stored in Redis in Unix nanoseconds. One stored
+// in seconds is read as the last nanosecond of its second when last is set,
+// else as the first, so revocations written before still cover their second.
+func parseUnixNano(value string, last bool) (int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n >= legacyUnixSeconds {
+		return n, err
+	}
+	if last {
+		return time.Unix(n+1, 0).UnixNano() - 1, nil
+	}
+
+	return time.Unix(n, 0).UnixNano(), nil
+}
+
+func (u *AuthUsecase) refreshTTL() time.Duration {
+	return time.Duration(u.cfg.RefreshTokenExpirationHours) * time.Hour
+}
+
+// tenantOrDefault maps the empty tenant of tokens issued before multi-tenancy
+// to the tenant their users were migrated into.
+func tenantOrDefault(tenantID string) string {
+	if tenantID == "" {
+		return domain.DefaultTenant
+	}
+
+	return tenantID
 }
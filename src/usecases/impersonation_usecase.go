@@ -0,0 +1,94 @@
+package usecases
+
+import (
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+const defaultImpersonationTTL = 15 * time.Minute
+
+// ImpersonationUsecase lets support admins see the API as a given user does.
+// The token it issues carries the user's id and role plus the admin's id in
+// ActorID, has no refresh token and no session, and is audited from the moment
+// it is issued.
+type ImpersonationUsecase struct {
+	userRepo     repositories.UserRepository
+	authUsecase  *AuthUsecase
+	auditUsecase *AuditUsecase
+	cfg          config.Config
+	validate     *validator.Validate
+}
+
+func NewImpersonationUsecase(userRepo repositories.UserRepository, authUsecase *AuthUsecase, auditUsecase *AuditUsecase, cfg config.Config) *ImpersonationUsecase {
+	return &ImpersonationUsecase{
+		userRepo:     userRepo,
+		authUsecase:  authUsecase,
+		auditUsecase: auditUsecase,
+		cfg:          cfg,
+		validate:     validator.New(),
+	}
+}
+
+// Impersonate issues a token for acting as the target user. Only a signed-in
+// user can impersonate, never an API key or another impersonation, and admins
+// cannot be impersonated, so the token never grants more than the actor has.
+func (u *ImpersonationUsecase) Impersonate(actor *auth.Claims, targetID uuid.UUID, input *dto.ImpersonateDTO, client domain.ClientInfo) (*dto.ImpersonationTokenDTO, error) {
+	if err := u.validate.Struct(input); err != nil {
+		return nil, err
+	}
+	if actor.IsAPIKey() || actor.IsImpersonation() || actor.UserID == targetID {
+		return nil, domain.ErrImpersonationForbidden
+	}
+
+	target, err := u.userRepo.GetUserByID(actor.TenantID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.Role == domain.RoleAdmin {
+		return nil, domain.ErrImpersonationForbidden
+	}
+
+	// Audit before issuing: no token may exist without its trail.
+	err = u.auditUsecase.Record(&domain.AuditEntry{
+		TenantID: actor.TenantID,
+		ActorID:  actor.UserID,
+		UserID:   target.ID,
+		Action:   domain.AuditImpersonationStart,
+		IP:       client.IP,
+		Detail:   input.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := u.ttl()
+	claims := auth.Claims{UserID: target.ID, Role: target.Role, TenantID: target.TenantID, ActorID: actor.UserID}
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	token, err := auth.GenerateJWT(claims, u.authUsecase.keys, u.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ImpersonationTokenDTO{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		UserID:      target.ID,
+		ActorID:     actor.UserID,
+	}, nil
+}
+
+func (u *ImpersonationUsecase) ttl() time.Duration {
+	if u.cfg.ImpersonationTTL <= 0 {
+		return defaultImpersonationTTL
+	}
+
+	return u.cfg.ImpersonationTTL
+}
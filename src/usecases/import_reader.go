@@ -0,0 +1,155 @@
+package usecases
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+)
+
+// maxImportLine bounds an NDJSON line, far above any real user.
+const maxImportLine = 64 * 1024
+
+// importColumns are the CSV columns understood by an import, named like the
+// fields of dto.UserImportDTO.
+var importColumns = map[string]func(*dto.UserImportDTO, string){
+	"name":             func(input *dto.UserImportDTO, value string) { input.Name = value },
+	"phone":            func(input *dto.UserImportDTO, value string) { input.Phone = value },
+	"document":         func(input *dto.UserImportDTO, value string) { input.Document = value },
+	"document_type":    func(input *dto.UserImportDTO, value string) { input.DocumentType = value },
+	"document_country": func(input *dto.UserImportDTO, value string) { input.DocumentCountry = value },
+	"password":         func(input *dto.UserImportDTO, value string) { input.Password = value },
+}
+
+// importReader yields the rows of an import file with the line each starts
+// on, and io.EOF after the last one. A row that cannot be read is reported as
+// a *domain.FieldError on "row" and the next one can still be read; any other
+// error ends the import.
+type importReader interface {
+	Next() (int, *dto.UserImportDTO, error)
+}
+
+func newImportReader(contentType string, r io.Reader) (importReader, error) {
+	switch contentType {
+	case domain.CSVType:
+		return newCSVImportReader(r)
+	case domain.NDJSONType, "application/ndjson":
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 0, 4096), maxImportLine)
+		return &ndjsonImportReader{scanner: scanner}, nil
+	}
+
+	return nil, domain.ErrUnsupportedImportType
+}
+
+type csvImportReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// newCSVImportReader reads the header, which must name the name, phone and
+// document columns and may name the others in importColumns, in any order.
+// Fields are separated by commas, or by semicolons when the header has no
+// comma, as spreadsheets set to Portuguese save them.
+func newCSVImportReader(r io.Reader) (*csvImportReader, error) {
+	buffered := bufio.NewReader(r)
+	header, err := buffered.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	reader := csv.NewReader(io.MultiReader(strings.NewReader(header), buffered))
+	if !strings.Contains(header, ",") && strings.Contains(header, ";") {
+		reader.Comma = ';'
+	}
+	reader.TrimLeadingSpace = true
+
+	columns, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: missing header", domain.ErrInvalidImport)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+	}
+
+	seen := map[string]bool{}
+	for i, column := range columns {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if _, ok := importColumns[column]; !ok {
+			return nil, fmt.Errorf("%w: unknown column %q", domain.ErrInvalidImport, column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("%w: repeated column %q", domain.ErrInvalidImport, column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+	for _, column := range []string{"name", "phone", "document", "password"} {
+		if !seen[column] {
+			return nil, fmt.Errorf("%w: missing column %q", domain.ErrInvalidImport, column)
+		}
+	}
+
+	return &csvImportReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvImportReader) Next() (int, *dto.UserImportDTO, error) {
+	record, err := r.reader.Read()
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) && errors.Is(err, csv.ErrFieldCount) {
+		return parseErr.StartLine, nil, &domain.FieldError{Field: "row", Err: fmt.Errorf("expected %d fields, got %d", len(r.columns), len(record))}
+	}
+	if errors.As(err, &parseErr) {
+		return parseErr.StartLine, nil, fmt.Errorf("%w: %v", domain.ErrInvalidImport, err)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	line, _ := r.reader.FieldPos(0)
+	input := &dto.UserImportDTO{}
+	for i, value := range record {
+		importColumns[r.columns[i]](input, strings.TrimSpace(value))
+	}
+
+	return line, input, nil
+}
+
+type ndjsonImportReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// Next skips blank lines. Members other than the fields of
+// dto.UserImportDTO reject the row.
+func (r *ndjsonImportReader) Next() (int, *dto.UserImportDTO, error) {
+	for r.scanner.Scan() {
+		r.line++
+		data := bytes.TrimSpace(r.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		var input dto.UserImportDTO
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&input); err != nil {
+			return r.line, nil, &domain.FieldError{Field: "row", Err: err}
+		}
+
+		return r.line, &input, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return r.line + 1, nil, fmt.Errorf("%w: line %d: %w", domain.ErrInvalidImport, r.line+1, err)
+	}
+
+	return r.line, nil, io.EOF
+}
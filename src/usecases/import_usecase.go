@@ -0,0 +1,242 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/go-playground/validator/v10"
+)
+
+const defaultImportBatchSize = 500
+
+// ImportUsecase brings users over from another system in bulk, from a CSV or
+// NDJSON file. Rows are checked like CreateUser checks its input and saved
+// by document: the active guest with the same document has their name
+// updated, else a guest is created. Rows matching staff or admins are
+// rejected.
+type ImportUsecase struct {
+	userRepo  repositories.UserRepository
+	redisRepo repositories.RedisRepository
+	cfg       config.Config
+	validate  *validator.Validate
+}
+
+func NewImportUsecase(userRepo repositories.UserRepository, redisRepo repositories.RedisRepository, cfg config.Config) *ImportUsecase {
+	return &ImportUsecase{
+		userRepo:  userRepo,
+		redisRepo: redisRepo,
+		cfg:       cfg,
+		validate:  validator.New(),
+	}
+}
+
+// importBatch holds the rows read since the last save, rejected ones
+// included so the report keeps the order of the file, and the users of the
+// valid ones.
+type importBatch struct {
+	rows  []*domain.ImportRow
+	users []*domain.User
+	// userRows maps each user to its row.
+	userRows []*domain.ImportRow
+}
+
+// Import reads the rows of r, a file of media type contentType, and saves
+// them in batches of cfg.ImportBatchSize users, each in its own transaction.
+// Invalid rows, and rows repeating the document or phone of an earlier one,
+// are reported as rejected and do not stop the others. With dryRun nothing
+// is saved, and passwords are not hashed. A file that cannot be read past
+// some row, such as one failing with ErrInvalidImport, stops the import
+// there: the rows before it are saved and the report of them is returned
+// along with the error.
+func (u *ImportUsecase) Import(tenantID, contentType string, r io.Reader, dryRun bool) (*domain.ImportReport, error) {
+	rows, err := newImportReader(contentType, r)
+	if err != nil {
+		return nil, err
+	}
+
+	batchSize := u.cfg.ImportBatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+
+	report := &domain.ImportReport{DryRun: dryRun, Rows: []*domain.ImportRow{}}
+	batch := &importBatch{}
+	seen := map[string]int{}
+	for {
+		line, input, err := rows.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var fieldErr *domain.FieldError
+		if err != nil && !errors.As(err, &fieldErr) {
+			if saveErr := u.save(tenantID, batch, report); saveErr != nil {
+				return nil, saveErr
+			}
+			return report, err
+		}
+
+		row := &domain.ImportRow{Line: line}
+		batch.rows = append(batch.rows, row)
+		if err != nil {
+			rejectRow(row, err)
+			continue
+		}
+		row.Document = input.Document
+
+		user, err := u.prepare(tenantID, input, dryRun)
+		if err != nil {
+			rejectRow(row, err)
+			continue
+		}
+		row.Document = user.Document
+		if earlier := u.claim(seen, user, line); earlier != 0 {
+			rejectRow(row, fmt.Errorf("%w (line %d)", domain.ErrDuplicateImportRow, earlier))
+			continue
+		}
+
+		batch.users = append(batch.users, user)
+		batch.userRows = append(batch.userRows, row)
+		if len(batch.users) == batchSize {
+			if err := u.save(tenantID, batch, report); err != nil {
+				return nil, err
+			}
+			batch = &importBatch{}
+		}
+	}
+
+	if err := u.save(tenantID, batch, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+// prepare checks and normalizes a row into a new guest, the way CreateUser
+// does. A dry run rolls every row back, so its passwords are left unhashed.
+func (u *ImportUsecase) prepare(tenantID string, input *dto.UserImportDTO, dryRun bool) (*domain.User, error) {
+	if err := u.validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	user, err := domain.NewUser(&dto.UserDTO{
+		Name:            input.Name,
+		Phone:           input.Phone,
+		Document:        input.Document,
+		DocumentType:    input.DocumentType,
+		DocumentCountry: input.DocumentCountry,
+		Password:        input.Password,
+	}, u.cfg.PhoneDefaultCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	user.TenantID = tenantID
+	user.Role = domain.RoleGuest
+	if dryRun {
+		return user, nil
+	}
+	if user.PasswordHash, err = auth.HashPassword(input.Password); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// claim records the user's document and phone as taken by line, returning the
+// line that took either of them first, or 0 when none did.
+func (u *ImportUsecase) claim(seen map[string]int, user *domain.User, line int) int {
+	keys := []string{
+		"document:" + string(user.DocumentType) + ":" + user.DocumentCountry + ":" + user.Document,
+		"phone:" + user.Phone,
+	}
+	for _, key := range keys {
+		if earlier, ok := seen[key]; ok {
+			return earlier
+		}
+	}
+	for _, key := range keys {
+		seen[key] = line
+	}
+
+	return 0
+}
+
+// save upserts the users of the batch and adds its rows to the report.
+func (u *ImportUsecase) save(tenantID string, batch *importBatch, report *domain.ImportReport) error {
+	if len(batch.users) > 0 {
+		results, err := u.userRepo.UpsertUsers(tenantID, batch.users, report.DryRun)
+		if err != nil {
+			return err
+		}
+
+		var updated []string
+		for i, result := range results {
+			row, user := batch.userRows[i], batch.users[i]
+			switch {
+			case result.Err != nil:
+				rejectRow(row, result.Err)
+			case result.Created:
+				row.Action, row.UserID = domain.ImportCreated, user.ID
+			default:
+				row.Action, row.UserID = domain.ImportUpdated, user.ID
+				updated = append(updated, userCacheKeyOf(tenantID, user))
+			}
+		}
+
+		// The batch is committed by now: a cache that could not be cleared
+		// only serves the old copies until they expire.
+		if len(updated) > 0 && !report.DryRun {
+			_ = u.redisRepo.Delete(updated...)
+		}
+	}
+
+	for _, row := range batch.rows {
+		report.Add(row)
+	}
+
+	return nil
+}
+
+// rejectRow marks the row rejected, describing err by field: validation
+// errors under the JSON name of each field, a *domain.FieldError under its
+// field and anything else under "row".
+func rejectRow(row *domain.ImportRow, err error) {
+	row.Action = domain.ImportRejected
+	row.Errors = map[string]string{}
+
+	var validationErrs validator.ValidationErrors
+	var fieldErr *domain.FieldError
+	switch {
+	case errors.As(err, &validationErrs):
+		for _, fe := range validationErrs {
+			message := "failed on " + fe.Tag()
+			if fe.Param() != "" {
+				message += " " + fe.Param()
+			}
+			row.Errors[importFieldName(fe.StructField())] = message
+		}
+	case errors.As(err, &fieldErr):
+		row.Errors[fieldErr.Field] = fieldErr.Err.Error()
+	default:
+		row.Errors["row"] = err.Error()
+	}
+}
+
+// importFieldName returns the JSON name of a field of dto.UserImportDTO.
+func importFieldName(field string) string {
+	structField, ok := reflect.TypeOf(dto.UserImportDTO{}).FieldByName(field)
+	if !ok {
+		return field
+	}
+
+	name, _, _ := strings.Cut(structField.Tag.Get("json"), ",")
+	return name
+}
@@ -0,0 +1,161 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	loginFailuresKey   = "login_failures:%s:%s"
+	loginNextKey       = "login_next:%s"
+	loginLockKey       = "login_lock:%s"
+	maxLoginDelay      = 30 * time.Second
+	loginScopeDocument = "doc"
+	loginScopeIP       = "ip"
+)
+
+// loginLimiter slows down and eventually locks out repeated failed logins.
+// Failures are counted per identifier and per client IP in Redis: each failure
+// on an identifier doubles the wait before the next attempt, and reaching the
+// limit locks the identifier for a while. Too many failures from one IP block
+// that IP for the rest of the window.
+type loginLimiter struct {
+	redisRepo repositories.RedisRepository
+	cfg       config.Config
+}
+
+// loginIdentifier is what failures are counted against: the same document
+// can belong to different users in different tenants.
+func loginIdentifier(tenantID, document string) string {
+	return tenantID + ":" + document
+}
+
+func newLoginLimiter(redisRepo repositories.RedisRepository, cfg config.Config) *loginLimiter {
+	return &loginLimiter{redisRepo: redisRepo, cfg: cfg}
+}
+
+// Allow returns a *domain.RetryError when a login for identifier from ip must
+// not be attempted right now.
+func (l *loginLimiter) Allow(identifier, ip string) error {
+	if wait, err := l.ttl(fmt.Sprintf(loginLockKey, identifier)); err != nil || wait > 0 {
+		return retryOrErr(domain.ErrAccountLocked, wait, err)
+	}
+
+	if l.cfg.LoginMaxAttemptsPerIP > 0 && ip != "" {
+		ipKey := fmt.Sprintf(loginFailuresKey, loginScopeIP, ip)
+		count, err := l.count(ipKey)
+		if err != nil {
+			return err
+		}
+		if count >= l.cfg.LoginMaxAttemptsPerIP {
+			wait, err := l.ttl(ipKey)
+			return retryOrErr(domain.ErrTooManyAttempts, wait, err)
+		}
+	}
+
+	if wait, err := l.ttl(fmt.Sprintf(loginNextKey, identifier)); err != nil || wait > 0 {
+		return retryOrErr(domain.ErrTooManyAttempts, wait, err)
+	}
+
+	return nil
+}
+
+func (l *loginLimiter) Fail(identifier, ip string) error {
+	if ip != "" {
+		if _, err := l.redisRepo.Incr(fmt.Sprintf(loginFailuresKey, loginScopeIP, ip), l.cfg.LoginAttemptWindow); err != nil {
+			return err
+		}
+	}
+
+	failuresKey := fmt.Sprintf(loginFailuresKey, loginScopeDocument, identifier)
+	failures, err := l.redisRepo.Incr(failuresKey, l.cfg.LoginAttemptWindow)
+	if err != nil {
+		return err
+	}
+
+	if l.cfg.LoginMaxAttempts > 0 && l.cfg.LoginLockoutDuration > 0 && failures >= int64(l.cfg.LoginMaxAttempts) {
+		if err := l.redisRepo.Set(fmt.Sprintf(loginLockKey, identifier), time.Now().Unix(), l.cfg.LoginLockoutDuration); err != nil {
+			return err
+		}
+		return l.redisRepo.Delete(failuresKey, fmt.Sprintf(loginNextKey, identifier))
+	}
+
+	if delay := l.delay(failures); delay > 0 {
+		return l.redisRepo.Set(fmt.Sprintf(loginNextKey, identifier), time.Now().Unix(), delay)
+	}
+
+	return nil
+}
+
+// Succeed clears the identifier's failure history. IP counters are left alone
+// so a valid login cannot be used to reset a credential-stuffing run.
+func (l *loginLimiter) Succeed(identifier string) error {
+	return l.redisRepo.Delete(
+		fmt.Sprintf(loginFailuresKey, loginScopeDocument, identifier),
+		fmt.Sprintf(loginNextKey, identifier),
+	)
+}
+
+func (l *loginLimiter) Unlock(identifier string) error {
+	return l.redisRepo.Delete(
+		fmt.Sprintf(loginLockKey, identifier),
+		fmt.Sprintf(loginFailuresKey, loginScopeDocument, identifier),
+		fmt.Sprintf(loginNextKey, identifier),
+	)
+}
+
+func (l *loginLimiter) delay(failures int64) time.Duration {
+	if l.cfg.LoginDelayBase <= 0 || failures <= 0 {
+		return 0
+	}
+
+	delay := l.cfg.LoginDelayBase
+	for i := int64(1); i < failures && delay < maxLoginDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxLoginDelay {
+		delay = maxLoginDelay
+	}
+
+	return delay
+}
+
+func (l *loginLimiter) count(key string) (int, error) {
+	value, err := l.redisRepo.Get(key)
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.Atoi(value)
+}
+
+// ttl returns how long key still lives, or zero when it does not exist.
+func (l *loginLimiter) ttl(key string) (time.Duration, error) {
+	ttl, err := l.redisRepo.TTL(key)
+	if err != nil {
+		return 0, err
+	}
+	if ttl < 0 {
+		return 0, nil
+	}
+
+	return ttl, nil
+}
+
+func retryOrErr(sentinel error, wait time.Duration, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return &domain.RetryError{Err: sentinel, RetryAfter: wait}
+}
@@ -0,0 +1,203 @@
+package usecases
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+)
+
+const (
+	totpUsedKey        = "totp_used:%s:%d"
+	recoveryCodeCount  = 10
+	defaultMFATokenTTL = 5 * time.Minute
+	// totpReplayWindow covers every step ValidateTOTP may still accept.
+	totpReplayWindow = 2 * time.Minute
+)
+
+// EnrollTOTP generates a new TOTP secret for the user. It is stored but not
+// enforced until ConfirmTOTP proves the authenticator app was set up.
+// Enrolling again before confirming replaces the pending secret.
+func (u *AuthUsecase) EnrollTOTP(tenantID string, userID uuid.UUID) (*dto.TOTPEnrollmentDTO, error) {
+	user, err := u.userRepo.GetUserByID(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.userRepo.UpdateMFA(user.TenantID, user.ID, secret, false, nil); err != nil {
+		return nil, err
+	}
+
+	return &dto.TOTPEnrollmentDTO{
+		Secret:     secret,
+		OTPAuthURI: auth.TOTPURI(u.mfaIssuer(), user.Document, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables the second factor once the user submits a valid code
+// for the pending secret. The recovery codes are returned only here; just
+// their hashes are kept.
+func (u *AuthUsecase) ConfirmTOTP(tenantID string, userID uuid.UUID, input *dto.MFACodeDTO) (*dto.RecoveryCodesDTO, error) {
+	if err := u.validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetUserByID(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, domain.ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.ErrMFANotEnrolled
+	}
+
+	ok, err := u.checkTOTP(user, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.ErrInvalidMFACode
+	}
+
+	codes, err := auth.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashToken(code)
+	}
+
+	if err := u.userRepo.UpdateMFA(user.TenantID, user.ID, user.TOTPSecret, true, hashes); err != nil {
+		return nil, err
+	}
+
+	return &dto.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// LoginMFA is the second step of a login: it exchanges the MFA token returned
+// by Login plus a TOTP or recovery code for a full token pair. Wrong codes
+// count as failed logins for the user's document, and each MFA token can be
+// exchanged only once.
+func (u *AuthUsecase) LoginMFA(input *dto.MFALoginDTO, client domain.ClientInfo) (*dto.TokenResponseDTO, error) {
+	if err := u.validate.Struct(input); err != nil {
+		return nil, err
+	}
+
+	claims, err := auth.ValidateJWT(input.MFAToken, u.keys)
+	if err != nil || !claims.MFAPending {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	user, err := u.userRepo.GetUserByID(tenantOrDefault(claims.TenantID), claims.UserID)
+	if err != nil || !user.MFAEnabled {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	identifier := loginIdentifier(user.TenantID, user.Document)
+	if err := u.limiter.Allow(identifier, client.IP); err != nil {
+		return nil, err
+	}
+
+	ok, err := u.checkTOTP(user, input.Code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		ok, err = u.useRecoveryCode(user, input.Code)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		return nil, u.loginFailed(identifier, client.IP, domain.ErrInvalidMFACode)
+	}
+
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(revokedTokenKey, claims.Id), user.ID.String(), claims.RemainingLifetime()+time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, domain.ErrInvalidMFAToken
+	}
+
+	if err := u.limiter.Succeed(identifier); err != nil {
+		return nil, err
+	}
+
+	return u.startSession(user, client)
+}
+
+func (u *AuthUsecase) mfaChallenge(user *domain.User) (*dto.TokenResponseDTO, error) {
+	ttl := u.cfg.MFATokenTTL
+	if ttl <= 0 {
+		ttl = defaultMFATokenTTL
+	}
+
+	claims := auth.Claims{UserID: user.ID, Role: user.Role, TenantID: user.TenantID, MFAPending: true}
+	claims.ExpiresAt = time.Now().Add(ttl).Unix()
+	token, err := auth.GenerateJWT(claims, u.keys, u.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.TokenResponseDTO{
+		ExpiresIn:   int(ttl.Seconds()),
+		MFARequired: true,
+		MFAToken:    token,
+	}, nil
+}
+
+// checkTOTP validates code and burns its time step, so an observed code
+// cannot be replayed while it is still within the accepted window.
+func (u *AuthUsecase) checkTOTP(user *domain.User, code string) (bool, error) {
+	step, ok := auth.ValidateTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+
+	return u.redisRepo.SetNX(fmt.Sprintf(totpUsedKey, user.ID, step), time.Now().Unix(), totpReplayWindow)
+}
+
+// useRecoveryCode consumes code if it is one of the user's unused recovery
+// codes. The repository only swaps the codes while they are still the ones
+// read here, so a concurrent login with the same code loses.
+func (u *AuthUsecase) useRecoveryCode(user *domain.User, code string) (bool, error) {
+	hash := auth.HashToken(auth.NormalizeRecoveryCode(code))
+
+	for i, stored := range user.RecoveryCodeHashes {
+		if subtle.ConstantTimeCompare([]byte(stored), []byte(hash)) != 1 {
+			continue
+		}
+
+		remaining := make([]string, 0, len(user.RecoveryCodeHashes)-1)
+		remaining = append(remaining, user.RecoveryCodeHashes[:i]...)
+		remaining = append(remaining, user.RecoveryCodeHashes[i+1:]...)
+		return u.userRepo.ConsumeRecoveryCode(user.TenantID, user.ID, user.RecoveryCodeHashes, remaining)
+	}
+
+	return false, nil
+}
+
+func (u *AuthUsecase) mfaIssuer() string {
+	if u.cfg.MFAIssuer == "" {
+		return "Pousada"
+	}
+
+	return u.cfg.MFAIssuer
+}
@@ -0,0 +1,249 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	oidcStateKey = "oidc_state:%s"
+	oidcStateTTL = 10 * time.Minute
+)
+
+// oidcLoginState is kept in Redis between redirecting to the provider and the
+// callback, keyed by the state parameter.
+type oidcLoginState struct {
+	TenantID     string `json:"tenant_id"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+	// LinkUserID is set when a signed-in user started the flow to link the
+	// provider account to their own.
+	LinkUserID uuid.UUID `json:"link_user_id,omitempty"`
+}
+
+// OIDCUsecase signs users in through an external OpenID Connect provider and
+// then issues our own tokens, exactly like a password login would.
+type OIDCUsecase struct {
+	provider    *auth.OIDCProvider
+	userRepo    repositories.UserRepository
+	redisRepo   repositories.RedisRepository
+	authUsecase *AuthUsecase
+	cfg         config.Config
+}
+
+// NewOIDCUsecase accepts a nil provider, in which case every call returns
+// auth.ErrOIDCDisabled.
+func NewOIDCUsecase(provider *auth.OIDCProvider, userRepo repositories.UserRepository, redisRepo repositories.RedisRepository, authUsecase *AuthUsecase, cfg config.Config) *OIDCUsecase {
+	return &OIDCUsecase{
+		provider:    provider,
+		userRepo:    userRepo,
+		redisRepo:   redisRepo,
+		authUsecase: authUsecase,
+		cfg:         cfg,
+	}
+}
+
+// BeginLogin returns the provider URL to redirect the browser to. The tenant
+// is remembered with the state, so the callback signs in to the same one.
+func (u *OIDCUsecase) BeginLogin(tenantID string) (string, error) {
+	return u.begin(oidcLoginState{TenantID: tenantID})
+}
+
+// BeginLink returns the provider URL for the signed-in user to link the
+// provider account they sign in with to their own. This is the only way an
+// existing account gets linked: ID token claims such as the document are
+// editable at most providers and never prove who owns an account here.
+func (u *OIDCUsecase) BeginLink(tenantID string, userID uuid.UUID) (string, error) {
+	return u.begin(oidcLoginState{TenantID: tenantID, LinkUserID: userID})
+}
+
+func (u *OIDCUsecase) begin(record oidcLoginState) (string, error) {
+	if u.provider == nil {
+		return "", auth.ErrOIDCDisabled
+	}
+
+	state, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	if record.Nonce, err = auth.GenerateRefreshToken(); err != nil {
+		return "", err
+	}
+	verifier, challenge, err := auth.GeneratePKCE()
+	if err != nil {
+		return "", err
+	}
+	record.CodeVerifier = verifier
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", err
+	}
+	if err := u.redisRepo.Set(fmt.Sprintf(oidcStateKey, auth.HashToken(state)), data, oidcStateTTL); err != nil {
+		return "", err
+	}
+
+	return u.provider.AuthCodeURL(state, record.Nonce, challenge)
+}
+
+// CompleteLogin handles the provider callback: the state is consumed, the code
+// redeemed and the ID token verified, and the user linked to the provider
+// account, or linked to it now when the flow came from BeginLink, gets a token
+// pair. Users with TOTP enabled get the MFA challenge instead, as with Login.
+func (u *OIDCUsecase) CompleteLogin(state, code string, client domain.ClientInfo) (*dto.TokenResponseDTO, error) {
+	if u.provider == nil {
+		return nil, auth.ErrOIDCDisabled
+	}
+
+	key := fmt.Sprintf(oidcStateKey, auth.HashToken(state))
+	stored, err := u.redisRepo.Get(key)
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrInvalidOIDCState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := u.redisRepo.Delete(key); err != nil {
+		return nil, err
+	}
+
+	var record oidcLoginState
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		return nil, domain.ErrInvalidOIDCState
+	}
+
+	claims, err := u.provider.Exchange(code, record.CodeVerifier, record.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := tenantOrDefault(record.TenantID)
+	var user *domain.User
+	if record.LinkUserID != uuid.Nil {
+		user, err = u.link(tenantID, record.LinkUserID, claims.Subject)
+	} else {
+		user, err = u.resolveUser(tenantID, claims)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		return u.authUsecase.mfaChallenge(user)
+	}
+
+	return u.authUsecase.startSession(user, client)
+}
+
+// link ties the provider subject to the user, unless either is already
+// linked to another account.
+func (u *OIDCUsecase) link(tenantID string, userID uuid.UUID, subject string) (*domain.User, error) {
+	user, err := u.userRepo.GetUserByID(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OIDCSubject == subject {
+		return user, nil
+	}
+	if user.OIDCSubject != "" {
+		return nil, domain.ErrOIDCAlreadyLinked
+	}
+
+	linked, err := u.userRepo.GetUserByOIDCSubject(tenantID, subject)
+	if err == nil && linked.ID != user.ID {
+		return nil, domain.ErrOIDCAlreadyLinked
+	}
+	if err != nil && !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, err
+	}
+
+	if err := u.userRepo.LinkOIDCSubject(tenantID, user.ID, subject); err != nil {
+		return nil, err
+	}
+	user.OIDCSubject = subject
+
+	return user, nil
+}
+
+// resolveUser finds the user linked to the provider subject. When there is
+// none a new user is provisioned with cfg.OIDCDefaultRole, from the document
+// claim (cfg.OIDCDocumentClaim); if that document already belongs to a local
+// account, its owner has to sign in and link it with BeginLink first.
+func (u *OIDCUsecase) resolveUser(tenantID string, claims *auth.IDTokenClaims) (*domain.User, error) {
+	user, err := u.userRepo.GetUserByOIDCSubject(tenantID, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, domain.ErrUserNotFound) {
+		return nil, err
+	}
+
+	document := claims.String(u.cfg.OIDCDocumentClaim)
+	if document == "" {
+		return nil, fmt.Errorf("%w: %s", domain.ErrOIDCMissingClaim, u.cfg.OIDCDocumentClaim)
+	}
+	identity, err := domain.NewIdentityDocument("", document, "")
+	if err != nil {
+		return nil, &domain.FieldError{Field: u.cfg.OIDCDocumentClaim, Err: errors.Unwrap(err)}
+	}
+
+	_, err = u.userRepo.GetUserByIdentityDocument(tenantID, identity.Type, identity.Country, identity.Number)
+	if err == nil {
+		return nil, domain.ErrOIDCLinkRequired
+	}
+	if !errors.Is(err, domain.ErrGetUserByData) {
+		return nil, err
+	}
+
+	return u.provisionUser(tenantID, claims, identity)
+}
+
+func (u *OIDCUsecase) provisionUser(tenantID string, claims *auth.IDTokenClaims, identity domain.IdentityDocument) (*domain.User, error) {
+	if claims.PhoneNumber == "" {
+		return nil, fmt.Errorf("%w: phone_number", domain.ErrOIDCMissingClaim)
+	}
+
+	phone, err := domain.NormalizePhone(claims.PhoneNumber, u.cfg.PhoneDefaultCountry)
+	if err != nil {
+		return nil, &domain.FieldError{Field: "phone_number", Err: err}
+	}
+
+	name := claims.Name
+	if name == "" {
+		name = identity.Number
+	}
+
+	role := domain.Role(u.cfg.OIDCDefaultRole)
+	if !role.Valid() {
+		role = domain.RoleGuest
+	}
+
+	now := time.Now()
+	user := &domain.User{
+		TenantID:        tenantID,
+		Name:            name,
+		Phone:           phone,
+		Document:        identity.Number,
+		DocumentType:    identity.Type,
+		DocumentCountry: identity.Country,
+		Role:            role,
+		OIDCSubject:     claims.Subject,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	if err := u.userRepo.CreateUser(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,187 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/notifier"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	passwordResetKey         = "password_reset:%s"
+	passwordResetUserKey     = "password_reset_user:%s"
+	passwordResetUsedKey     = "password_reset_used:%s"
+	passwordResetThrottleKey = "password_reset_throttle:%s"
+	defaultPasswordResetTTL  = 30 * time.Minute
+	// passwordResetInterval is the minimum time between two reset messages
+	// for the same user.
+	passwordResetInterval = time.Minute
+)
+
+// passwordResetRecord is stored under the hash of a reset token.
+type passwordResetRecord struct {
+	UserID   uuid.UUID `json:"user_id"`
+	TenantID string    `json:"tenant_id"`
+}
+
+// PasswordResetUsecase lets users who forgot their password set a new one
+// with a single-use token sent through the notifier. Only the token hash is
+// stored, and each user has at most one valid token at a time.
+type PasswordResetUsecase struct {
+	userRepo    repositories.UserRepository
+	redisRepo   repositories.RedisRepository
+	notifier    notifier.Notifier
+	authUsecase *AuthUsecase
+	cfg         config.Config
+	validate    *validator.Validate
+}
+
+func NewPasswordResetUsecase(userRepo repositories.UserRepository, redisRepo repositories.RedisRepository, notify notifier.Notifier, authUsecase *AuthUsecase, cfg config.Config) *PasswordResetUsecase {
+	return &PasswordResetUsecase{
+		userRepo:    userRepo,
+		redisRepo:   redisRepo,
+		notifier:    notify,
+		authUsecase: authUsecase,
+		cfg:         cfg,
+		validate:    validator.New(),
+	}
+}
+
+// ForgotPassword sends a reset token to the user with the given document. It
+// succeeds silently for unknown or ambiguous documents and for repeated
+// requests, so the response does not reveal whether an account exists.
+func (u *PasswordResetUsecase) ForgotPassword(tenantID string, input *dto.ForgotPasswordDTO) error {
+	if err := u.validate.Struct(input); err != nil {
+		return err
+	}
+
+	user, err := findUserByDocument(u.userRepo, tenantID, domain.DocumentType(input.DocumentType), input.DocumentCountry, input.Document)
+	if errors.Is(err, domain.ErrGetUserByData) || errors.Is(err, domain.ErrAmbiguousDocument) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(passwordResetThrottleKey, user.ID), time.Now().Unix(), passwordResetInterval)
+	if err != nil || !first {
+		return err
+	}
+
+	token, err := auth.GenerateRefreshToken()
+	if err != nil {
+		return err
+	}
+	hash := auth.HashToken(token)
+
+	// Requesting a new token invalidates the previous one.
+	userKey := fmt.Sprintf(passwordResetUserKey, user.ID)
+	previous, err := u.redisRepo.Get(userKey)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if previous != "" {
+		if err := u.redisRepo.Delete(fmt.Sprintf(passwordResetKey, previous)); err != nil {
+			return err
+		}
+	}
+
+	record, err := json.Marshal(passwordResetRecord{UserID: user.ID, TenantID: user.TenantID})
+	if err != nil {
+		return err
+	}
+
+	ttl := u.ttl()
+	if err := u.redisRepo.Set(fmt.Sprintf(passwordResetKey, hash), record, ttl); err != nil {
+		return err
+	}
+	if err := u.redisRepo.Set(userKey, hash, ttl); err != nil {
+		return err
+	}
+
+	return u.notifier.Notify(notifier.Message{
+		UserID:  user.ID,
+		Name:    user.Name,
+		Phone:   user.Phone,
+		Subject: "Password reset",
+		Body:    u.resetBody(token, ttl),
+	})
+}
+
+// ResetPassword sets a new password using a token from ForgotPassword. The
+// token is consumed even if it is presented concurrently, every existing
+// session of the user is revoked and any login lockout is lifted.
+func (u *PasswordResetUsecase) ResetPassword(input *dto.ResetPasswordDTO) error {
+	if err := u.validate.Struct(input); err != nil {
+		return err
+	}
+
+	hash := auth.HashToken(input.Token)
+	tokenKey := fmt.Sprintf(passwordResetKey, hash)
+	stored, err := u.redisRepo.Get(tokenKey)
+	if errors.Is(err, redis.Nil) {
+		return domain.ErrInvalidResetToken
+	}
+	if err != nil {
+		return err
+	}
+
+	var record passwordResetRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		return domain.ErrInvalidResetToken
+	}
+	userID, tenantID := record.UserID, tenantOrDefault(record.TenantID)
+
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(passwordResetUsedKey, hash), time.Now().Unix(), u.ttl())
+	if err != nil {
+		return err
+	}
+	if !first {
+		return domain.ErrInvalidResetToken
+	}
+	if err := u.redisRepo.Delete(tokenKey, fmt.Sprintf(passwordResetUserKey, userID)); err != nil {
+		return err
+	}
+
+	passwordHash, err := auth.HashPassword(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := u.userRepo.UpdatePassword(tenantID, userID, passwordHash); err != nil {
+		return err
+	}
+
+	if err := u.authUsecase.RevokeUserSessions(tenantID, userID); err != nil {
+		return err
+	}
+
+	return u.authUsecase.UnlockUser(tenantID, userID)
+}
+
+func (u *PasswordResetUsecase) resetBody(token string, ttl time.Duration) string {
+	if u.cfg.PasswordResetURL != "" {
+		return fmt.Sprintf("Use this link to choose a new password within %s: %s?token=%s",
+			ttl, u.cfg.PasswordResetURL, url.QueryEscape(token))
+	}
+
+	return fmt.Sprintf("Use this code to choose a new password within %s: %s", ttl, token)
+}
+
+func (u *PasswordResetUsecase) ttl() time.Duration {
+	if u.cfg.PasswordResetTTL <= 0 {
+		return defaultPasswordResetTTL
+	}
+
+	return u.cfg.PasswordResetTTL
+}
@@ -0,0 +1,231 @@
+package usecases
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/ThailanTec/challenger/pousada/infra/sms"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	otpCodeKey            = "otp_code:%s"
+	otpAttemptsKey        = "otp_attempts:%s"
+	otpUsedKey            = "otp_used:%s"
+	otpThrottleKey        = "otp_throttle:%s"
+	defaultOTPTTL         = 5 * time.Minute
+	defaultOTPMaxAttempts = 5
+	// otpRequestInterval is the minimum time between two codes sent to the
+	// same phone.
+	otpRequestInterval = time.Minute
+)
+
+// otpRecord is stored under the phone while a code is outstanding.
+type otpRecord struct {
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	CodeHash string    `json:"code_hash"`
+}
+
+// PhoneLoginUsecase is the passwordless login: a 6-digit code is texted to the
+// user's phone and exchanged for the usual tokens. Codes are stored hashed,
+// expire after cfg.OTPTTL and are discarded after cfg.OTPMaxAttempts wrong
+// guesses.
+type PhoneLoginUsecase struct {
+	userRepo    repositories.UserRepository
+	redisRepo   repositories.RedisRepository
+	sender      sms.Sender
+	authUsecase *AuthUsecase
+	cfg         config.Config
+	validate    *validator.Validate
+}
+
+func NewPhoneLoginUsecase(userRepo repositories.UserRepository, redisRepo repositories.RedisRepository, sender sms.Sender, authUsecase *AuthUsecase, cfg config.Config) *PhoneLoginUsecase {
+	return &PhoneLoginUsecase{
+		userRepo:    userRepo,
+		redisRepo:   redisRepo,
+		sender:      sender,
+		authUsecase: authUsecase,
+		cfg:         cfg,
+		validate:    validator.New(),
+	}
+}
+
+// RequestCode texts a new code to the phone, replacing any outstanding one.
+// Like ForgotPassword it succeeds silently for unknown phones and for requests
+// made too soon after the previous one.
+func (u *PhoneLoginUsecase) RequestCode(tenantID string, input *dto.RequestOTPDTO) error {
+	if err := u.validate.Struct(input); err != nil {
+		return err
+	}
+	phone := domain.NormalizePhoneLookup(input.Phone, u.cfg.PhoneDefaultCountry)
+	subject := otpSubject(tenantID, phone)
+
+	user, err := u.userRepo.GetUserByPhone(tenantID, phone)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(otpThrottleKey, subject), time.Now().Unix(), otpRequestInterval)
+	if err != nil || !first {
+		return err
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return err
+	}
+
+	record, err := json.Marshal(otpRecord{ID: uuid.New(), UserID: user.ID, CodeHash: hashOTP(phone, code)})
+	if err != nil {
+		return err
+	}
+
+	ttl := u.ttl()
+	if err := u.redisRepo.Set(fmt.Sprintf(otpCodeKey, subject), record, ttl); err != nil {
+		return err
+	}
+	if err := u.redisRepo.Delete(fmt.Sprintf(otpAttemptsKey, subject)); err != nil {
+		return err
+	}
+
+	channel := sms.ChannelSMS
+	if input.Channel != "" {
+		channel = sms.Channel(input.Channel)
+	}
+
+	return u.sender.Send(channel, phone, fmt.Sprintf("Your login code is %s. It expires in %s.", code, ttl))
+}
+
+// VerifyCode exchanges a correct code for a token pair, or for an MFA token
+// when the user has TOTP enabled. Wrong codes count as failed logins of the
+// phone and the client IP, like wrong passwords do, so guessing cannot go on
+// across new codes; while throttled a *domain.RetryError is returned.
+func (u *PhoneLoginUsecase) VerifyCode(tenantID string, input *dto.VerifyOTPDTO, client domain.ClientInfo) (*dto.TokenResponseDTO, error) {
+	if err := u.validate.Struct(input); err != nil {
+		return nil, err
+	}
+	phone := domain.NormalizePhoneLookup(input.Phone, u.cfg.PhoneDefaultCountry)
+	subject := otpSubject(tenantID, phone)
+	identifier := loginIdentifier(tenantID, phone)
+	if err := u.authUsecase.limiter.Allow(identifier, client.IP); err != nil {
+		return nil, err
+	}
+
+	codeKey := fmt.Sprintf(otpCodeKey, subject)
+	stored, err := u.redisRepo.Get(codeKey)
+	if errors.Is(err, redis.Nil) {
+		return nil, u.authUsecase.loginFailed(identifier, client.IP, domain.ErrInvalidOTP)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record otpRecord
+	if err := json.Unmarshal([]byte(stored), &record); err != nil {
+		return nil, domain.ErrInvalidOTP
+	}
+
+	attemptsKey := fmt.Sprintf(otpAttemptsKey, subject)
+	attempts, err := u.redisRepo.Incr(attemptsKey, u.ttl())
+	if err != nil {
+		return nil, err
+	}
+	if attempts > int64(u.maxAttempts()) {
+		return nil, u.discard(codeKey, attemptsKey, domain.ErrInvalidOTP)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.CodeHash), []byte(hashOTP(phone, input.Code))) != 1 {
+		if attempts == int64(u.maxAttempts()) {
+			if err := u.discard(codeKey, attemptsKey, nil); err != nil {
+				return nil, err
+			}
+		}
+		return nil, u.authUsecase.loginFailed(identifier, client.IP, domain.ErrInvalidOTP)
+	}
+
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(otpUsedKey, record.ID), time.Now().Unix(), u.ttl())
+	if err != nil {
+		return nil, err
+	}
+	if !first {
+		return nil, domain.ErrInvalidOTP
+	}
+	if err := u.redisRepo.Delete(codeKey, attemptsKey); err != nil {
+		return nil, err
+	}
+
+	user, err := u.userRepo.GetUserByID(tenantID, record.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidOTP
+	}
+	if err := u.authUsecase.limiter.Succeed(identifier); err != nil {
+		return nil, err
+	}
+
+	if user.MFAEnabled {
+		return u.authUsecase.mfaChallenge(user)
+	}
+
+	return u.authUsecase.startSession(user, client)
+}
+
+func (u *PhoneLoginUsecase) discard(codeKey, attemptsKey string, cause error) error {
+	if err := u.redisRepo.Delete(codeKey, attemptsKey); err != nil {
+		return err
+	}
+
+	return cause
+}
+
+func (u *PhoneLoginUsecase) ttl() time.Duration {
+	if u.cfg.OTPTTL <= 0 {
+		return defaultOTPTTL
+	}
+
+	return u.cfg.OTPTTL
+}
+
+func (u *PhoneLoginUsecase) maxAttempts() int {
+	if u.cfg.OTPMaxAttempts <= 0 {
+		return defaultOTPMaxAttempts
+	}
+
+	return u.cfg.OTPMaxAttempts
+}
+
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// otpSubject names the phone of a tenant in Redis keys, since the same phone
+// can be registered in several tenants.
+func otpSubject(tenantID, phone string) string {
+	return tenantID + ":" + phone
+}
+
+// hashOTP binds the code to the phone so equal codes for different phones do
+// not share a hash.
+func hashOTP(phone, code string) string {
+	return auth.HashToken(phone + ":" + code)
+}
@@ -0,0 +1,163 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/repositories"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// PrivacyUsecase answers LGPD data subject requests: exporting everything
+// stored about a user and anonymizing them. Both are audited.
+type PrivacyUsecase struct {
+	userRepo     repositories.UserRepository
+	auditRepo    repositories.AuditRepository
+	apiKeyRepo   repositories.APIKeyRepository
+	redisRepo    repositories.RedisRepository
+	authUsecase  *AuthUsecase
+	auditUsecase *AuditUsecase
+}
+
+func NewPrivacyUsecase(userRepo repositories.UserRepository, auditRepo repositories.AuditRepository, apiKeyRepo repositories.APIKeyRepository, redisRepo repositories.RedisRepository, authUsecase *AuthUsecase, auditUsecase *AuditUsecase) *PrivacyUsecase {
+	return &PrivacyUsecase{
+		userRepo:     userRepo,
+		auditRepo:    auditRepo,
+		apiKeyRepo:   apiKeyRepo,
+		redisRepo:    redisRepo,
+		authUsecase:  authUsecase,
+		auditUsecase: auditUsecase,
+	}
+}
+
+// Export gathers the data stored about the user, deleted or not, on behalf
+// of actorID.
+func (u *PrivacyUsecase) Export(tenantID string, actorID, userID uuid.UUID) (*domain.UserDataExport, error) {
+	user, err := u.userRepo.GetUserByIDWithDeleted(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := u.auditRepo.GetAuditEntriesInvolving(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := u.authUsecase.ListSessions(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := u.apiKeyRepo.GetAPIKeys(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	ownKeys := make([]*domain.APIKey, 0)
+	for _, key := range keys {
+		if key.CreatedBy == userID {
+			ownKeys = append(ownKeys, key)
+		}
+	}
+
+	err = u.auditUsecase.Record(&domain.AuditEntry{
+		TenantID: tenantID,
+		ActorID:  actorID,
+		UserID:   userID,
+		Action:   domain.AuditUserExported,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.UserDataExport{
+		User:         user,
+		AuditEntries: entries,
+		Sessions:     sessions,
+		APIKeys:      ownKeys,
+		ExportedAt:   time.Now(),
+	}, nil
+}
+
+// Anonymize irreversibly scrubs the user's personal data, see
+// domain.User.Anonymize, along with the IPs and details of their audit
+// entries, and drops everything Redis holds keyed by their document, phone or
+// id: cached profile, sessions, login lockouts, codes and reset tokens. The
+// user is only marked anonymized once the rest is gone, so a failed attempt
+// can be retried. Anonymizing an already anonymized user scrubs their audit
+// entries and sessions again and records nothing.
+func (u *PrivacyUsecase) Anonymize(tenantID string, actorID, userID uuid.UUID) (*domain.User, error) {
+	user, err := u.userRepo.GetUserByIDWithDeleted(tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.AnonymizedAt != nil {
+		return user, u.scrub(user)
+	}
+
+	if !user.DeletedAt.Valid {
+		if err := u.authUsecase.RevokeUserSessions(tenantID, userID); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.scrub(user); err != nil {
+		return nil, err
+	}
+
+	user.Anonymize(time.Now())
+	if err := u.userRepo.AnonymizeUser(user); err != nil {
+		return nil, err
+	}
+
+	err = u.auditUsecase.Record(&domain.AuditEntry{
+		TenantID: tenantID,
+		ActorID:  actorID,
+		UserID:   userID,
+		Action:   domain.AuditUserAnonymized,
+	})
+
+	return user, err
+}
+
+// scrub clears the user's audit entries and the Redis keys derived from their
+// data.
+func (u *PrivacyUsecase) scrub(user *domain.User) error {
+	if err := u.auditRepo.ScrubAuditEntries(user.TenantID, user.ID); err != nil {
+		return err
+	}
+
+	return u.evict(user)
+}
+
+// evict deletes the Redis keys derived from the user's original data.
+func (u *PrivacyUsecase) evict(user *domain.User) error {
+	if err := u.authUsecase.forgetUserSessions(user.ID); err != nil {
+		return err
+	}
+
+	resetKey := fmt.Sprintf(passwordResetUserKey, user.ID)
+	resetToken, err := u.redisRepo.Get(resetKey)
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	identifier := loginIdentifier(user.TenantID, user.Document)
+	subject := otpSubject(user.TenantID, user.Phone)
+	keys := []string{
+		userCacheKeyOf(user.TenantID, user),
+		fmt.Sprintf(loginLockKey, identifier),
+		fmt.Sprintf(loginNextKey, identifier),
+		fmt.Sprintf(loginFailuresKey, loginScopeDocument, identifier),
+		fmt.Sprintf(otpCodeKey, subject),
+		fmt.Sprintf(otpAttemptsKey, subject),
+		fmt.Sprintf(otpThrottleKey, subject),
+		resetKey,
+	}
+	if resetToken != "" {
+		keys = append(keys, fmt.Sprintf(passwordResetKey, resetToken))
+	}
+
+	return u.redisRepo.Delete(keys...)
+}
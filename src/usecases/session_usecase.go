@@ -0,0 +1,222 @@
+package usecases
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	sessionKey      = "session:%s"
+	userSessionsKey = "user_sessions:%s"
+	sessionSeenKey  = "session_seen:%s"
+	// sessionSeenInterval limits how often last-seen is written for a busy
+	// session.
+	sessionSeenInterval = time.Minute
+)
+
+// startSession opens a new refresh token family, records it in the user's
+// session inventory and issues its first token pair.
+func (u *AuthUsecase) startSession(user *domain.User, client domain.ClientInfo) (*dto.TokenResponseDTO, error) {
+	now := time.Now()
+	session := &domain.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		TenantID:   user.TenantID,
+		UserAgent:  client.UserAgent,
+		IP:         client.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	ttl := u.refreshTTL()
+	if err := u.redisRepo.Set(fmt.Sprintf(refreshFamilyKey, session.ID), now.UnixNano(), ttl); err != nil {
+		return nil, err
+	}
+	if err := u.saveSession(session, ttl); err != nil {
+		return nil, err
+	}
+	if err := u.redisRepo.SAdd(fmt.Sprintf(userSessionsKey, user.ID), ttl, session.ID.String()); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(user, session.ID)
+}
+
+// ListSessions returns the user's live sessions in the tenant, most recently
+// seen first. Sessions that ended (logout, expiry, revocation) are pruned on
+// the way.
+func (u *AuthUsecase) ListSessions(tenantID string, userID uuid.UUID) ([]*domain.Session, error) {
+	ids, err := u.redisRepo.SMembers(fmt.Sprintf(userSessionsKey, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*domain.Session, 0, len(ids))
+	for _, raw := range ids {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			continue
+		}
+
+		session, err := u.liveSession(userID, id)
+		if errors.Is(err, domain.ErrSessionNotFound) {
+			if err := u.forgetSession(userID, id); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if tenantOrDefault(session.TenantID) == tenantID {
+			sessions = append(sessions, session)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+
+	return sessions, nil
+}
+
+// RevokeSession ends one of the user's sessions: its refresh token stops
+// working and its access tokens are rejected by AuthenticateToken.
+func (u *AuthUsecase) RevokeSession(tenantID string, userID, sessionID uuid.UUID) error {
+	session, err := u.liveSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if tenantOrDefault(session.TenantID) != tenantID {
+		return domain.ErrSessionNotFound
+	}
+
+	return u.forgetSession(userID, sessionID)
+}
+
+// liveSession loads a session of userID, failing with ErrSessionNotFound when
+// it belongs to someone else or is no longer valid.
+func (u *AuthUsecase) liveSession(userID, sessionID uuid.UUID) (*domain.Session, error) {
+	stored, err := u.redisRepo.Get(fmt.Sprintf(sessionKey, sessionID))
+	if errors.Is(err, redis.Nil) {
+		return nil, domain.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal([]byte(stored), &session); err != nil || session.UserID != userID {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	active, err := u.sessionActive(userID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, domain.ErrSessionNotFound
+	}
+
+	return &session, nil
+}
+
+// sessionActive reports whether the session's refresh family still exists and
+// predates no "revoke all" for the user.
+func (u *AuthUsecase) sessionActive(userID, sessionID uuid.UUID) (bool, error) {
+	familyIssuedAt, err := u.redisRepo.Get(fmt.Sprintf(refreshFamilyKey, sessionID))
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	issuedAt, _ := parseUnixNano(familyIssuedAt, false)
+	revoked, err := u.issuedBeforeRevocation(userID, issuedAt)
+	if err != nil {
+		return false, err
+	}
+
+	return !revoked, nil
+}
+
+func (u *AuthUsecase) forgetSession(userID, sessionID uuid.UUID) error {
+	err := u.redisRepo.Delete(
+		fmt.Sprintf(refreshFamilyKey, sessionID),
+		fmt.Sprintf(sessionKey, sessionID),
+		fmt.Sprintf(sessionSeenKey, sessionID),
+	)
+	if err != nil {
+		return err
+	}
+
+	return u.redisRepo.SRem(fmt.Sprintf(userSessionsKey, userID), sessionID.String())
+}
+
+// touchSession updates the session's last-seen time, at most once per
+// sessionSeenInterval.
+func (u *AuthUsecase) touchSession(sessionID uuid.UUID) error {
+	first, err := u.redisRepo.SetNX(fmt.Sprintf(sessionSeenKey, sessionID), time.Now().Unix(), sessionSeenInterval)
+	if err != nil || !first {
+		return err
+	}
+
+	key := fmt.Sprintf(sessionKey, sessionID)
+	stored, err := u.redisRepo.Get(key)
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var session domain.Session
+	if err := json.Unmarshal([]byte(stored), &session); err != nil {
+		return nil
+	}
+	session.LastSeenAt = time.Now()
+
+	ttl, err := u.redisRepo.TTL(key)
+	if err != nil || ttl <= 0 {
+		return err
+	}
+
+	return u.saveSession(&session, ttl)
+}
+
+func (u *AuthUsecase) saveSession(session *domain.Session, ttl time.Duration) error {
+	record, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return u.redisRepo.Set(fmt.Sprintf(sessionKey, session.ID), record, ttl)
+}
+
+// forgetUserSessions drops every session record of the user, with the IPs
+// and user agents they hold.
+func (u *AuthUsecase) forgetUserSessions(userID uuid.UUID) error {
+	ids, err := u.redisRepo.SMembers(fmt.Sprintf(userSessionsKey, userID))
+	if err != nil {
+		return err
+	}
+
+	for _, raw := range ids {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			continue
+		}
+		if err := u.forgetSession(userID, id); err != nil {
+			return err
+		}
+	}
+
+	return u.redisRepo.Delete(fmt.Sprintf(userSessionsKey, userID))
+}
@@ -1,21 +1,55 @@
 package usecases
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
 	"github.com/ThailanTec/challenger/pousada/infra/repositories"
 	"github.com/ThailanTec/challenger/pousada/src/dto"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
+	"strings"
 	"time"
 )
 
+// Cache keys are namespaced by tenant, since the same document can belong to
+// a different user in each tenant, and hold the document type and number.
+const userCacheKey = "tenant:%s:user:%s:%s"
+
+// documentCacheKey names the cached user holding a document. Passport numbers
+// are only unique within their country, so theirs is part of the key.
+func documentCacheKey(tenantID string, docType domain.DocumentType, country, number string) string {
+	if docType == domain.DocumentPassport {
+		number = country + ":" + number
+	}
+
+	return fmt.Sprintf(userCacheKey, tenantID, docType, number)
+}
+
+// userCacheKeyOf names the cached copy of user in the tenant.
+func userCacheKeyOf(tenantID string, user *domain.User) string {
+	return documentCacheKey(tenantID, user.DocumentType, user.DocumentCountry, user.Document)
+}
+
+const (
+	defaultUserPageSize = 20
+	maxUserPageSize     = 100
+)
+
 type UserUsecase interface {
-	CreateUser(userDTO *dto.UserDTO) (*domain.User, error)
-	GetUsers() ([]*domain.User, error)
-	GetUserByDocument(document string) (*domain.User, error)
-	DeleteUser(id uuid.UUID) error
-	UpdateUser(id uuid.UUID, user *dto.UserDTO) (*domain.User, error)
+	CreateUser(tenantID string, userDTO *dto.UserDTO) (*domain.User, error)
+	GetUsers(filter domain.UserFilter) (*domain.UserPage, error)
+	ExportUsers(filter domain.UserFilter, fn func(*domain.User) error) error
+	GetUserByDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error)
+	DeleteUser(tenantID string, id uuid.UUID, version int64) error
+	UpdateUser(tenantID string, id uuid.UUID, user *dto.UserDTO, version int64) (*domain.User, error)
+	PatchUser(tenantID string, id uuid.UUID, patchType string, patch []byte, version int64) (*domain.User, error)
+	RestoreUser(tenantID string, id uuid.UUID) (*domain.User, error)
+	PurgeUser(tenantID string, id uuid.UUID) error
+	PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error)
 }
 
 type userUsecase struct {
@@ -23,18 +57,32 @@ type userUsecase struct {
 	redisRepo repositories.RedisRepository
 	cacheTTL  time.Duration
 	validate  *validator.Validate
+	// phoneCountry is the country of phone numbers given without one.
+	phoneCountry string
 }
 
-func NewUserUsecase(ur repositories.UserRepository, redisRepo repositories.RedisRepository, cacheTTL time.Duration) UserUsecase {
+func NewUserUsecase(ur repositories.UserRepository, redisRepo repositories.RedisRepository, cacheTTL time.Duration, phoneCountry string) UserUsecase {
 	return &userUsecase{userRepo: ur,
-		validate:  validator.New(),
-		redisRepo: redisRepo,
-		cacheTTL:  cacheTTL,
+		validate:     validator.New(),
+		redisRepo:    redisRepo,
+		cacheTTL:     cacheTTL,
+		phoneCountry: phoneCountry,
 	}
 }
 
-func (uc *userUsecase) CreateUser(userDTO *dto.UserDTO) (*domain.User, error) {
-	usr, err := domain.NewUser(userDTO)
+func (uc *userUsecase) CreateUser(tenantID string, userDTO *dto.UserDTO) (*domain.User, error) {
+	if err := uc.validate.Struct(userDTO); err != nil {
+		return nil, err
+	}
+
+	usr, err := domain.NewUser(userDTO, uc.phoneCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	usr.TenantID = tenantID
+	usr.Role = domain.RoleGuest
+	usr.PasswordHash, err = auth.HashPassword(userDTO.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -45,7 +93,7 @@ func (uc *userUsecase) CreateUser(userDTO *dto.UserDTO) (*domain.User, error) {
 	}
 
 	userJSON, _ := json.Marshal(usr)
-	err = uc.redisRepo.Set(usr.Document, userJSON, uc.cacheTTL)
+	err = uc.redisRepo.Set(userCacheKeyOf(tenantID, usr), userJSON, uc.cacheTTL)
 	if err != nil {
 		return nil, err
 	}
@@ -53,19 +101,73 @@ func (uc *userUsecase) CreateUser(userDTO *dto.UserDTO) (*domain.User, error) {
 	return usr, nil
 }
 
-func (uc *userUsecase) GetUsers() ([]*domain.User, error) {
-	cacheKey := "all_users"
+// GetUsers returns one page of users. Pages are not cached: with filters and
+// cursors the same page is rarely asked for twice.
+func (uc *userUsecase) GetUsers(filter domain.UserFilter) (*domain.UserPage, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultUserPageSize
+	}
+	if filter.Limit > maxUserPageSize {
+		filter.Limit = maxUserPageSize
+	}
+	if filter.Sort == "" {
+		filter.Sort = domain.UserSortCreatedAt
+	}
+	if filter.After != nil && (filter.After.Sort != filter.Sort || filter.After.Desc != filter.Desc) {
+		return nil, domain.ErrInvalidCursor
+	}
+	if filter.Phone != "" {
+		filter.Phone = domain.NormalizePhoneLookup(filter.Phone, uc.phoneCountry)
+	}
+
+	return uc.userRepo.GetUsers(filter)
+}
+
+// ExportUsers calls fn with every user matching filter, in the order of a
+// listing with the same sort, without holding them all in memory. The
+// cursor and limit of filter are ignored.
+func (uc *userUsecase) ExportUsers(filter domain.UserFilter, fn func(*domain.User) error) error {
+	filter.After, filter.Limit, filter.WithTotal = nil, 0, false
+	if filter.Sort == "" {
+		filter.Sort = domain.UserSortCreatedAt
+	}
+	if filter.Phone != "" {
+		filter.Phone = domain.NormalizePhoneLookup(filter.Phone, uc.phoneCountry)
+	}
+
+	return uc.userRepo.StreamUsers(filter, fn)
+}
+
+// GetUserByDocument finds a user by document type, country and number.
+// Without a type, CPFs and CNPJs are recognized by their check digits; any
+// other number is looked up among all types and not cached. A passport
+// looked up without its country is not cached either, and fails with
+// ErrAmbiguousDocument when the number exists in several countries.
+func (uc *userUsecase) GetUserByDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error) {
+	if docType == "" {
+		docType = domain.InferDocumentType(number)
+	}
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if docType == "" || docType == domain.DocumentPassport && country == "" {
+		return findUserByDocument(uc.userRepo, tenantID, docType, country, number)
+	}
+
+	number = domain.NormalizeDocumentNumber(docType, number)
+	if docType != domain.DocumentPassport {
+		country = ""
+	}
+	cacheKey := documentCacheKey(tenantID, docType, country, number)
 
 	cachedUsers, err := uc.redisRepo.Get(cacheKey)
 	if err == nil && cachedUsers != "" {
-		var users []*domain.User
-		err := json.Unmarshal([]byte(cachedUsers), &users)
+		user := &domain.User{}
+		err := json.Unmarshal([]byte(cachedUsers), &user)
 		if err == nil {
-			return users, nil
+			return user, nil
 		}
 	}
 
-	users, err := uc.userRepo.GetUsers()
+	users, err := uc.userRepo.GetUserByIdentityDocument(tenantID, docType, country, number)
 	if err != nil {
 		return nil, err
 	}
@@ -74,45 +176,175 @@ func (uc *userUsecase) GetUsers() ([]*domain.User, error) {
 	if err == nil {
 		_ = uc.redisRepo.Set(cacheKey, usersJSON, uc.cacheTTL*time.Minute)
 	}
+	return users, err
+}
 
-	return users, nil
+// findUserByDocument finds a user by a document as typed, without the cache.
+// Without a type, CPFs and CNPJs are recognized by their check digits and any
+// other number is looked up among all types; the country only narrows
+// passports. A number matching several users fails with ErrAmbiguousDocument.
+func findUserByDocument(userRepo repositories.UserRepository, tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error) {
+	if docType == "" {
+		docType = domain.InferDocumentType(number)
+	}
+	number = domain.NormalizeDocumentNumber(docType, number)
+	if docType == "" {
+		return userRepo.GetUserByData(tenantID, number)
+	}
+
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if docType != domain.DocumentPassport {
+		country = ""
+	}
+
+	return userRepo.GetUserByIdentityDocument(tenantID, docType, country, number)
 }
 
-func (uc *userUsecase) GetUserByDocument(document string) (*domain.User, error) {
-	cachedUsers, err := uc.redisRepo.Get(document)
-	if err == nil && cachedUsers != "" {
-		user := &domain.User{}
-		err := json.Unmarshal([]byte(cachedUsers), &user)
-		if err == nil {
-			return user, nil
-		}
+// DeleteUser deletes the user if it is still at version, or whatever its
+// version when version is 0, see UserRepository.DeleteUser.
+func (uc *userUsecase) DeleteUser(tenantID string, id uuid.UUID, version int64) error {
+	current, err := uc.userRepo.GetUserByID(tenantID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.userRepo.DeleteUser(tenantID, id, version); err != nil {
+		return err
 	}
 
-	users, err := uc.userRepo.GetUserByData(document)
+	uc.evict(tenantID, current)
+	return nil
+}
+
+// UpdateUser replaces the editable fields of the user if it is still at
+// version, or whatever its version when version is 0.
+func (uc *userUsecase) UpdateUser(tenantID string, id uuid.UUID, user *dto.UserDTO, version int64) (*domain.User, error) {
+	current, err := uc.userRepo.GetUserByID(tenantID, id)
 	if err != nil {
 		return nil, err
 	}
 
-	usersJSON, err := json.Marshal(users)
-	if err == nil {
-		_ = uc.redisRepo.Set(document, usersJSON, uc.cacheTTL*time.Minute)
+	updated, err := uc.saveChanges(tenantID, id, &dto.UserPatchDTO{
+		Name:            user.Name,
+		Phone:           user.Phone,
+		Document:        user.Document,
+		DocumentType:    user.DocumentType,
+		DocumentCountry: user.DocumentCountry,
+	}, version)
+	if err != nil {
+		return nil, err
 	}
-	return users, err
+
+	uc.evict(tenantID, current, updated)
+	return updated, nil
 }
 
-func (uc *userUsecase) DeleteUser(id uuid.UUID) error {
-	err := uc.userRepo.DeleteUser(id)
+// PatchUser applies a JSON Merge Patch or JSON Patch, per patchType, to the
+// editable fields of the user and saves the result once it passes the same
+// checks as a full update. It returns the user as stored. A non-zero version
+// must be the user's current one; either way the patch is only saved if the
+// user did not change while it was applied.
+func (uc *userUsecase) PatchUser(tenantID string, id uuid.UUID, patchType string, patch []byte, version int64) (*domain.User, error) {
+	if patchType != domain.MergePatchType && patchType != domain.JSONPatchType {
+		return nil, domain.ErrUnsupportedPatchType
+	}
 
-	return err
+	current, err := uc.userRepo.GetUserByID(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if version != 0 && version != current.Version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	doc, err := json.Marshal(domain.PatchableUser(current))
+	if err != nil {
+		return nil, err
+	}
+	patched, err := domain.ApplyPatch(patchType, doc, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes dto.UserPatchDTO
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&changes); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidPatch, err)
+	}
+
+	updated, err := uc.saveChanges(tenantID, id, &changes, current.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.evict(tenantID, current, updated)
+	return updated, nil
+}
+
+func (uc *userUsecase) saveChanges(tenantID string, id uuid.UUID, changes *dto.UserPatchDTO, version int64) (*domain.User, error) {
+	if err := uc.validate.Struct(changes); err != nil {
+		return nil, err
+	}
+
+	usr, err := domain.NewUserChanges(changes, uc.phoneCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.userRepo.UpdateUser(tenantID, id, usr, version)
 }
 
-func (uc *userUsecase) UpdateUser(id uuid.UUID, user *dto.UserDTO) (*domain.User, error) {
-	usr, err := domain.NewUser(user)
+// evict drops the cached copies of the tenant's users, so lookups by document
+// do not serve stale data or versions.
+func (uc *userUsecase) evict(tenantID string, users ...*domain.User) {
+	keys := make([]string, len(users))
+	for i, user := range users {
+		keys[i] = userCacheKeyOf(tenantID, user)
+	}
+
+	_ = uc.redisRepo.Delete(keys...)
+}
+
+// RestoreUser undeletes a soft-deleted user and returns it. Anonymized users
+// stay deleted and are reported as ErrUserAnonymized.
+func (uc *userUsecase) RestoreUser(tenantID string, id uuid.UUID) (*domain.User, error) {
+	deleted, err := uc.userRepo.GetUserByIDWithDeleted(tenantID, id)
 	if err != nil {
 		return nil, err
 	}
+	if deleted.AnonymizedAt != nil {
+		return nil, domain.ErrUserAnonymized
+	}
 
-	users, err := uc.userRepo.UpdateUser(id, usr)
+	if err := uc.userRepo.RestoreUser(tenantID, id); err != nil {
+		return nil, err
+	}
 
-	return users, err
+	restored, err := uc.userRepo.GetUserByID(tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	uc.evict(tenantID, restored)
+	return restored, nil
+}
+
+// PurgeUser permanently removes a user, which must have been deleted first:
+// an active user is reported as ErrUserNotDeleted.
+func (uc *userUsecase) PurgeUser(tenantID string, id uuid.UUID) error {
+	err := uc.userRepo.PurgeUser(tenantID, id)
+	if errors.Is(err, domain.ErrIDNotFound) {
+		if _, activeErr := uc.userRepo.GetUserByID(tenantID, id); activeErr == nil {
+			return domain.ErrUserNotDeleted
+		}
+	}
+
+	return err
+}
+
+// PurgeDeletedUsers permanently removes the users deleted before
+// deletedBefore and returns how many there were.
+func (uc *userUsecase) PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error) {
+	return uc.userRepo.PurgeDeletedUsers(tenantID, deletedBefore)
 }
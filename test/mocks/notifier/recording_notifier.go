@@ -0,0 +1,32 @@
+package mocks
+
+import (
+	"sync"
+
+	"github.com/ThailanTec/challenger/pousada/infra/notifier"
+)
+
+// RecordingNotifier keeps every message in memory instead of sending it.
+type RecordingNotifier struct {
+	mu       sync.Mutex
+	Messages []notifier.Message
+	Err      error
+}
+
+func (n *RecordingNotifier) Notify(msg notifier.Message) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.Err != nil {
+		return n.Err
+	}
+	n.Messages = append(n.Messages, msg)
+	return nil
+}
+
+func (n *RecordingNotifier) Last() notifier.Message {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.Messages[len(n.Messages)-1]
+}
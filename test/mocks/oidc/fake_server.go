@@ -0,0 +1,182 @@
+package mocks
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// FakeOIDCServer is a local OpenID Connect provider for tests. Every call to
+// its authorization endpoint signs in as a user with Claims, immediately
+// redirecting back with a code; the token endpoint checks the client secret
+// and the PKCE verifier before returning a signed ID token.
+type FakeOIDCServer struct {
+	*httptest.Server
+	ClientID     string
+	ClientSecret string
+	// Claims are added to every ID token. "sub" defaults to "subject-1".
+	Claims map[string]interface{}
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]fakeAuthRequest
+}
+
+type fakeAuthRequest struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+func NewFakeOIDCServer(t *testing.T) *FakeOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := &FakeOIDCServer{
+		ClientID:     "pousada",
+		ClientSecret: "fake-secret",
+		Claims:       map[string]interface{}{},
+		key:          key,
+		codes:        map[string]fakeAuthRequest{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", f.discovery)
+	mux.HandleFunc("/jwks", f.jwks)
+	mux.HandleFunc("/authorize", f.authorize)
+	mux.HandleFunc("/token", f.token)
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+
+	return f
+}
+
+// Authorize follows an authorization URL like a browser would and returns the
+// code and state the provider redirected back with.
+func (f *FakeOIDCServer) Authorize(t *testing.T, authURL string) (code, state string) {
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil || resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize: status %d, location %q", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func (f *FakeOIDCServer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{
+		"issuer":                 f.URL,
+		"authorization_endpoint": f.URL + "/authorize",
+		"token_endpoint":         f.URL + "/token",
+		"jwks_uri":               f.URL + "/jwks",
+	})
+}
+
+func (f *FakeOIDCServer) jwks(w http.ResponseWriter, _ *http.Request) {
+	pub := f.key.PublicKey
+	writeJSON(w, map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "fake",
+		"use": "sig",
+		"alg": "RS256",
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (f *FakeOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != f.ClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := randomString()
+	f.mu.Lock()
+	f.codes[code] = fakeAuthRequest{
+		redirectURI:   query.Get("redirect_uri"),
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+	}
+	f.mu.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", query.Get("state"))
+	redirect.RawQuery = values.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (f *FakeOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != f.ClientID || secret != f.ClientSecret {
+		http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	request, found := f.codes[r.FormValue("code")]
+	delete(f.codes, r.FormValue("code"))
+	f.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !found || request.redirectURI != r.FormValue("redirect_uri") ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != request.codeChallenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   f.URL,
+		"sub":   "subject-1",
+		"aud":   []string{f.ClientID},
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"nonce": request.nonce,
+	}
+	for name, value := range f.Claims {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "fake"
+	idToken, err := token.SignedString(f.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]string{"access_token": randomString(), "token_type": "Bearer", "id_token": idToken})
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
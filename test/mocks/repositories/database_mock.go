@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/golang/mock/gomock"
@@ -27,55 +28,68 @@ func (m *UserRepositoryMockDB) EXPECT() *UserRepositoryMockDBRecorder {
 	return m.recorder
 }
 
-func (m *UserRepositoryMockDB) GetUsers() ([]*domain.User, error) {
+func (m *UserRepositoryMockDB) GetUsers(filter domain.UserFilter) (*domain.UserPage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUsers")
-	ret0, _ := ret[0].([]*domain.User)
+	ret := m.ctrl.Call(m, "GetUsers", filter)
+	ret0, _ := ret[0].(*domain.UserPage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (mr *UserRepositoryMockDBRecorder) GetUsers() *gomock.Call {
+func (mr *UserRepositoryMockDBRecorder) GetUsers(filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUsers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsers", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUsers), filter)
 }
 
-func (m *UserRepositoryMockDB) GetUserByData(document string) (*domain.User, error) {
+func (m *UserRepositoryMockDB) GetUserByData(tenantID, document string) (*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByData", document)
+	ret := m.ctrl.Call(m, "GetUserByData", tenantID, document)
 	ret0, _ := ret[0].(*domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (mr *UserRepositoryMockDBRecorder) GetUserByData(document interface{}) *gomock.Call {
+func (mr *UserRepositoryMockDBRecorder) GetUserByData(tenantID, document interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByData", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByData), document)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByData", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByData), tenantID, document)
 }
 
-func (m *UserRepositoryMockDB) DeleteUser(id uuid.UUID) error {
+func (m *UserRepositoryMockDB) GetUserByIdentityDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret := m.ctrl.Call(m, "GetUserByIdentityDocument", tenantID, docType, country, number)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) GetUserByIdentityDocument(tenantID, docType, country, number interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIdentityDocument", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByIdentityDocument), tenantID, docType, country, number)
+}
+
+func (m *UserRepositoryMockDB) DeleteUser(tenantID string, id uuid.UUID, version int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", tenantID, id, version)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-func (mr *UserRepositoryMockDBRecorder) DeleteUser(id interface{}) *gomock.Call {
+func (mr *UserRepositoryMockDBRecorder) DeleteUser(tenantID, id, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).DeleteUser), tenantID, id, version)
 }
 
-func (m *UserRepositoryMockDB) UpdateUser(id uuid.UUID, user *domain.User) (*domain.User, error) {
+func (m *UserRepositoryMockDB) UpdateUser(tenantID string, id uuid.UUID, user *domain.User, version int64) (*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUser", id, user)
+	ret := m.ctrl.Call(m, "UpdateUser", tenantID, id, user, version)
 	ret0, _ := ret[0].(*domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-func (mr *UserRepositoryMockDBRecorder) UpdateUser(id, user interface{}) *gomock.Call {
+func (mr *UserRepositoryMockDBRecorder) UpdateUser(tenantID, id, user, version interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).UpdateUser), id, user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).UpdateUser), tenantID, id, user, version)
 }
 
 func NewUserRepositoryMock(ctrl *gomock.Controller) *UserRepositoryMockDB {
@@ -96,10 +110,189 @@ func (mr *UserRepositoryMockDBRecorder) CreateUser(user interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCall(mr.mock, "CreateUser", user)
 }
 
-func (m *UserRepositoryMockDB) GetUserByID(id uuid.UUID) (*domain.User, error) {
+func (m *UserRepositoryMockDB) GetUserByID(tenantID string, id uuid.UUID) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", tenantID, id)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) GetUserByID(tenantID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByID), tenantID, id)
+}
+
+func (m *UserRepositoryMockDB) UpdatePassword(tenantID string, id uuid.UUID, passwordHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePassword", tenantID, id, passwordHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) UpdatePassword(tenantID, id, passwordHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePassword", reflect.TypeOf((*UserRepositoryMockDB)(nil).UpdatePassword), tenantID, id, passwordHash)
+}
+
+func (m *UserRepositoryMockDB) UpdateRole(tenantID string, id uuid.UUID, role domain.Role) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", tenantID, id, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) UpdateRole(tenantID, id, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*UserRepositoryMockDB)(nil).UpdateRole), tenantID, id, role)
+}
+
+func (m *UserRepositoryMockDB) UpdateMFA(tenantID string, id uuid.UUID, totpSecret string, enabled bool, recoveryCodeHashes []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMFA", tenantID, id, totpSecret, enabled, recoveryCodeHashes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) UpdateMFA(tenantID, id, totpSecret, enabled, recoveryCodeHashes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMFA", reflect.TypeOf((*UserRepositoryMockDB)(nil).UpdateMFA), tenantID, id, totpSecret, enabled, recoveryCodeHashes)
+}
+
+func (m *UserRepositoryMockDB) ConsumeRecoveryCode(tenantID string, id uuid.UUID, current, remaining []string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConsumeRecoveryCode", tenantID, id, current, remaining)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) ConsumeRecoveryCode(tenantID, id, current, remaining interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConsumeRecoveryCode", reflect.TypeOf((*UserRepositoryMockDB)(nil).ConsumeRecoveryCode), tenantID, id, current, remaining)
+}
+
+func (m *UserRepositoryMockDB) GetUserByOIDCSubject(tenantID, subject string) (*domain.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserByID", id)
+	ret := m.ctrl.Call(m, "GetUserByOIDCSubject", tenantID, subject)
 	ret0, _ := ret[0].(*domain.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
+
+func (mr *UserRepositoryMockDBRecorder) GetUserByOIDCSubject(tenantID, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByOIDCSubject", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByOIDCSubject), tenantID, subject)
+}
+
+func (m *UserRepositoryMockDB) LinkOIDCSubject(tenantID string, id uuid.UUID, subject string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LinkOIDCSubject", tenantID, id, subject)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) LinkOIDCSubject(tenantID, id, subject interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LinkOIDCSubject", reflect.TypeOf((*UserRepositoryMockDB)(nil).LinkOIDCSubject), tenantID, id, subject)
+}
+
+func (m *UserRepositoryMockDB) GetUserByPhone(tenantID, phone string) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByPhone", tenantID, phone)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) GetUserByPhone(tenantID, phone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByPhone", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByPhone), tenantID, phone)
+}
+
+func (m *UserRepositoryMockDB) RestoreUser(tenantID string, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", tenantID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) RestoreUser(tenantID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).RestoreUser), tenantID, id)
+}
+
+func (m *UserRepositoryMockDB) PurgeUser(tenantID string, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeUser", tenantID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) PurgeUser(tenantID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).PurgeUser), tenantID, id)
+}
+
+func (m *UserRepositoryMockDB) PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", tenantID, deletedBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) PurgeDeletedUsers(tenantID, deletedBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*UserRepositoryMockDB)(nil).PurgeDeletedUsers), tenantID, deletedBefore)
+}
+
+func (m *UserRepositoryMockDB) GetUserByIDWithDeleted(tenantID string, id uuid.UUID) (*domain.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByIDWithDeleted", tenantID, id)
+	ret0, _ := ret[0].(*domain.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) GetUserByIDWithDeleted(tenantID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByIDWithDeleted", reflect.TypeOf((*UserRepositoryMockDB)(nil).GetUserByIDWithDeleted), tenantID, id)
+}
+
+func (m *UserRepositoryMockDB) AnonymizeUser(user *domain.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymizeUser", user)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) AnonymizeUser(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymizeUser", reflect.TypeOf((*UserRepositoryMockDB)(nil).AnonymizeUser), user)
+}
+
+func (m *UserRepositoryMockDB) UpsertUsers(tenantID string, users []*domain.User, dryRun bool) ([]domain.UpsertResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertUsers", tenantID, users, dryRun)
+	ret0, _ := ret[0].([]domain.UpsertResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+func (mr *UserRepositoryMockDBRecorder) UpsertUsers(tenantID, users, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertUsers", reflect.TypeOf((*UserRepositoryMockDB)(nil).UpsertUsers), tenantID, users, dryRun)
+}
+
+func (m *UserRepositoryMockDB) StreamUsers(filter domain.UserFilter, fn func(*domain.User) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamUsers", filter, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+func (mr *UserRepositoryMockDBRecorder) StreamUsers(filter, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamUsers", reflect.TypeOf((*UserRepositoryMockDB)(nil).StreamUsers), filter, fn)
+}
@@ -1,8 +1,11 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -15,13 +18,14 @@ func (m *UserRepositoryMock) CreateUser(user *domain.User) error {
 	return args.Error(0)
 }
 
-func (m *UserRepositoryMock) GetUsers() ([]*domain.User, error) {
-	args := m.Called()
-	return args.Get(0).([]*domain.User), args.Error(1)
+func (m *UserRepositoryMock) GetUsers(filter domain.UserFilter) (*domain.UserPage, error) {
+	args := m.Called(filter)
+	page, _ := args.Get(0).(*domain.UserPage)
+	return page, args.Error(1)
 }
 
-func (m *UserRepositoryMock) GetUserByData(document string) (*domain.User, error) {
-	args := m.Called(document)
+func (m *UserRepositoryMock) GetUserByData(tenantID, document string) (*domain.User, error) {
+	args := m.Called(tenantID, document)
 	var user *domain.User
 	if args.Get(0) != nil {
 		user = args.Get(0).(*domain.User)
@@ -29,19 +33,225 @@ func (m *UserRepositoryMock) GetUserByData(document string) (*domain.User, error
 	return user, args.Error(1)
 }
 
-func (m *UserRepositoryMock) DeleteUser(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *UserRepositoryMock) GetUserByIdentityDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error) {
+	args := m.Called(tenantID, docType, country, number)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserRepositoryMock) DeleteUser(tenantID string, id uuid.UUID, version int64) error {
+	args := m.Called(tenantID, id, version)
 	return args.Error(0)
 }
 
-func (m *UserRepositoryMock) UpdateUser(id uuid.UUID, user *domain.User) (*domain.User, error) {
-	args := m.Called(id, user)
+func (m *UserRepositoryMock) UpdateUser(tenantID string, id uuid.UUID, user *domain.User, version int64) (*domain.User, error) {
+	args := m.Called(tenantID, id, user, version)
 	if result := args.Get(0); result != nil {
 		return result.(*domain.User), args.Error(1)
 	}
 	return nil, args.Error(1)
 }
-func (m *UserRepositoryMock) GetUserByID(id uuid.UUID) (*domain.User, error) {
-	args := m.Called(id)
-	return args.Get(0).(*domain.User), args.Error(1)
+func (m *UserRepositoryMock) GetUserByID(tenantID string, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(tenantID, id)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserRepositoryMock) UpdatePassword(tenantID string, id uuid.UUID, passwordHash string) error {
+	args := m.Called(tenantID, id, passwordHash)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) UpdateRole(tenantID string, id uuid.UUID, role domain.Role) error {
+	args := m.Called(tenantID, id, role)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) UpdateMFA(tenantID string, id uuid.UUID, totpSecret string, enabled bool, recoveryCodeHashes []string) error {
+	args := m.Called(tenantID, id, totpSecret, enabled, recoveryCodeHashes)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) ConsumeRecoveryCode(tenantID string, id uuid.UUID, current, remaining []string) (bool, error) {
+	args := m.Called(tenantID, id, current, remaining)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *UserRepositoryMock) GetUserByOIDCSubject(tenantID, subject string) (*domain.User, error) {
+	args := m.Called(tenantID, subject)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserRepositoryMock) LinkOIDCSubject(tenantID string, id uuid.UUID, subject string) error {
+	args := m.Called(tenantID, id, subject)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) GetUserByPhone(tenantID, phone string) (*domain.User, error) {
+	args := m.Called(tenantID, phone)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserRepositoryMock) RestoreUser(tenantID string, id uuid.UUID) error {
+	args := m.Called(tenantID, id)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) PurgeUser(tenantID string, id uuid.UUID) error {
+	args := m.Called(tenantID, id)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error) {
+	args := m.Called(tenantID, deletedBefore)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *UserRepositoryMock) GetUserByIDWithDeleted(tenantID string, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(tenantID, id)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserRepositoryMock) AnonymizeUser(user *domain.User) error {
+	args := m.Called(user)
+	return args.Error(0)
+}
+
+func (m *UserRepositoryMock) UpsertUsers(tenantID string, users []*domain.User, dryRun bool) ([]domain.UpsertResult, error) {
+	args := m.Called(tenantID, users, dryRun)
+	results, _ := args.Get(0).([]domain.UpsertResult)
+	return results, args.Error(1)
+}
+
+// StreamUsers calls fn with the users the expectation returns, stopping at
+// the first error like the repository does.
+func (m *UserRepositoryMock) StreamUsers(filter domain.UserFilter, fn func(*domain.User) error) error {
+	args := m.Called(filter)
+	users, _ := args.Get(0).([]*domain.User)
+	for _, user := range users {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+type RedisRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *RedisRepositoryMock) Set(key string, value interface{}, expiration time.Duration) error {
+	args := m.Called(key, value, expiration)
+	return args.Error(0)
+}
+
+func (m *RedisRepositoryMock) Get(key string) (string, error) {
+	args := m.Called(key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *RedisRepositoryMock) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	args := m.Called(key, value, expiration)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *RedisRepositoryMock) Delete(keys ...string) error {
+	args := m.Called(keys)
+	return args.Error(0)
+}
+
+func (m *RedisRepositoryMock) Incr(key string, expiration time.Duration) (int64, error) {
+	args := m.Called(key, expiration)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *RedisRepositoryMock) TTL(key string) (time.Duration, error) {
+	args := m.Called(key)
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *RedisRepositoryMock) SAdd(key string, expiration time.Duration, members ...string) error {
+	args := m.Called(key, expiration, members)
+	return args.Error(0)
+}
+
+func (m *RedisRepositoryMock) SMembers(key string) ([]string, error) {
+	args := m.Called(key)
+	members, _ := args.Get(0).([]string)
+	return members, args.Error(1)
+}
+
+func (m *RedisRepositoryMock) SRem(key string, members ...string) error {
+	args := m.Called(key, members)
+	return args.Error(0)
+}
+
+// NewCacheMissRedisRepositoryMock returns a RedisRepositoryMock that never has
+// the requested key cached and accepts any write.
+func NewCacheMissRedisRepositoryMock() *RedisRepositoryMock {
+	m := new(RedisRepositoryMock)
+	m.On("Get", mock.Anything).Return("", redis.Nil).Maybe()
+	m.On("Set", mock.Anything, mock.Anything, mock.Anything).Return(nil).Maybe()
+	m.On("Delete", mock.Anything).Return(nil).Maybe()
+	return m
+}
+
+type APIKeyRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *APIKeyRepositoryMock) CreateAPIKey(key *domain.APIKey) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *APIKeyRepositoryMock) GetAPIKeys(tenantID string) ([]*domain.APIKey, error) {
+	args := m.Called(tenantID)
+	keys, _ := args.Get(0).([]*domain.APIKey)
+	return keys, args.Error(1)
+}
+
+func (m *APIKeyRepositoryMock) GetAPIKeyByPrefix(prefix string) (*domain.APIKey, error) {
+	args := m.Called(prefix)
+	key, _ := args.Get(0).(*domain.APIKey)
+	return key, args.Error(1)
+}
+
+func (m *APIKeyRepositoryMock) RevokeAPIKey(tenantID string, id uuid.UUID, at time.Time) error {
+	args := m.Called(tenantID, id, at)
+	return args.Error(0)
+}
+
+func (m *APIKeyRepositoryMock) TouchAPIKey(id uuid.UUID, at time.Time) error {
+	args := m.Called(id, at)
+	return args.Error(0)
+}
+
+type AuditRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *AuditRepositoryMock) CreateAuditEntry(entry *domain.AuditEntry) error {
+	args := m.Called(entry)
+	return args.Error(0)
+}
+
+func (m *AuditRepositoryMock) GetAuditEntries(filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	args := m.Called(filter)
+	entries, _ := args.Get(0).([]*domain.AuditEntry)
+	return entries, args.Error(1)
+}
+
+func (m *AuditRepositoryMock) GetAuditEntriesInvolving(tenantID string, userID uuid.UUID) ([]*domain.AuditEntry, error) {
+	args := m.Called(tenantID, userID)
+	entries, _ := args.Get(0).([]*domain.AuditEntry)
+	return entries, args.Error(1)
+}
+
+func (m *AuditRepositoryMock) ScrubAuditEntries(tenantID string, userID uuid.UUID) error {
+	args := m.Called(tenantID, userID)
+	return args.Error(0)
 }
@@ -0,0 +1,159 @@
+package mocks
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// FakeRedisRepository is an in-memory RedisRepository for tests that need
+// state to survive between calls (token rotation, counters, denylists...).
+type FakeRedisRepository struct {
+	mu      sync.Mutex
+	values  map[string]string
+	sets    map[string]map[string]struct{}
+	expires map[string]time.Time
+}
+
+func NewFakeRedisRepository() *FakeRedisRepository {
+	return &FakeRedisRepository{
+		values:  map[string]string{},
+		sets:    map[string]map[string]struct{}{},
+		expires: map[string]time.Time{},
+	}
+}
+
+func (f *FakeRedisRepository) Set(key string, value interface{}, expiration time.Duration) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.set(key, value, expiration)
+	return nil
+}
+
+func (f *FakeRedisRepository) Get(key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.get(key)
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (f *FakeRedisRepository) SetNX(key string, value interface{}, expiration time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.get(key); ok {
+		return false, nil
+	}
+	f.set(key, value, expiration)
+	return true, nil
+}
+
+func (f *FakeRedisRepository) Delete(keys ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, key := range keys {
+		delete(f.values, key)
+		delete(f.sets, key)
+		delete(f.expires, key)
+	}
+	return nil
+}
+
+func (f *FakeRedisRepository) Incr(key string, expiration time.Duration) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.get(key)
+	count, _ := strconv.ParseInt(value, 10, 64)
+	count++
+	f.values[key] = strconv.FormatInt(count, 10)
+	if !ok && expiration > 0 {
+		f.expires[key] = time.Now().Add(expiration)
+	}
+	return count, nil
+}
+
+// TTL mirrors Redis: -2 when the key does not exist, -1 when it has no expiry.
+func (f *FakeRedisRepository) TTL(key string) (time.Duration, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.get(key); !ok && f.members(key) == nil {
+		return -2, nil
+	}
+	exp, ok := f.expires[key]
+	if !ok {
+		return -1, nil
+	}
+	return time.Until(exp), nil
+}
+
+func (f *FakeRedisRepository) SAdd(key string, expiration time.Duration, members ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	set := f.members(key)
+	if set == nil {
+		set = map[string]struct{}{}
+		f.sets[key] = set
+	}
+	for _, member := range members {
+		set[member] = struct{}{}
+	}
+	if expiration > 0 {
+		f.expires[key] = time.Now().Add(expiration)
+	}
+	return nil
+}
+
+func (f *FakeRedisRepository) SMembers(key string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	members := []string{}
+	for member := range f.members(key) {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+func (f *FakeRedisRepository) SRem(key string, members ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	set := f.members(key)
+	for _, member := range members {
+		delete(set, member)
+	}
+	return nil
+}
+
+func (f *FakeRedisRepository) members(key string) map[string]struct{} {
+	if exp, ok := f.expires[key]; ok && time.Now().After(exp) {
+		delete(f.sets, key)
+		delete(f.expires, key)
+	}
+	return f.sets[key]
+}
+
+func (f *FakeRedisRepository) set(key string, value interface{}, expiration time.Duration) {
+	switch v := value.(type) {
+	case []byte:
+		f.values[key] = string(v)
+	default:
+		f.values[key] = fmt.Sprint(v)
+	}
+	delete(f.expires, key)
+	if expiration > 0 {
+		f.expires[key] = time.Now().Add(expiration)
+	}
+}
+
+func (f *FakeRedisRepository) get(key string) (string, bool) {
+	if exp, ok := f.expires[key]; ok && time.Now().After(exp) {
+		delete(f.values, key)
+		delete(f.expires, key)
+	}
+	value, ok := f.values[key]
+	return value, ok
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/ThailanTec/challenger/pousada/infra/sms"
+)
+
+var codePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+type SentMessage struct {
+	Channel sms.Channel
+	Phone   string
+	Message string
+}
+
+// RecordingSender keeps every text message in memory instead of sending it.
+type RecordingSender struct {
+	mu       sync.Mutex
+	Messages []SentMessage
+}
+
+func (s *RecordingSender) Send(channel sms.Channel, phone, message string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Messages = append(s.Messages, SentMessage{Channel: channel, Phone: phone, Message: message})
+	return nil
+}
+
+// LastCode returns the 6-digit code in the most recent message.
+func (s *RecordingSender) LastCode() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.Messages) == 0 {
+		return ""
+	}
+	return codePattern.FindString(s.Messages[len(s.Messages)-1].Message)
+}
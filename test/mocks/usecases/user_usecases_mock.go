@@ -1,6 +1,8 @@
 package mocks
 
 import (
+	"time"
+
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/ThailanTec/challenger/pousada/src/dto"
 	"github.com/google/uuid"
@@ -11,27 +13,66 @@ type UserUsecaseMock struct {
 	mock.Mock
 }
 
-func (m *UserUsecaseMock) CreateUser(userDTO *dto.UserDTO) (*domain.User, error) {
-	args := m.Called(userDTO)
-	return args.Get(0).(*domain.User), args.Error(1)
+func (m *UserUsecaseMock) CreateUser(tenantID string, userDTO *dto.UserDTO) (*domain.User, error) {
+	args := m.Called(tenantID, userDTO)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserUsecaseMock) GetUsers(filter domain.UserFilter) (*domain.UserPage, error) {
+	args := m.Called(filter)
+	page, _ := args.Get(0).(*domain.UserPage)
+	return page, args.Error(1)
 }
 
-func (m *UserUsecaseMock) GetUsers() ([]*domain.User, error) {
-	args := m.Called()
-	return args.Get(0).([]*domain.User), args.Error(1)
+// ExportUsers calls fn with the users the expectation returns, stopping at
+// the first error.
+func (m *UserUsecaseMock) ExportUsers(filter domain.UserFilter, fn func(*domain.User) error) error {
+	args := m.Called(filter)
+	users, _ := args.Get(0).([]*domain.User)
+	for _, user := range users {
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
 }
 
-func (m *UserUsecaseMock) GetUserByDocument(document string) (*domain.User, error) {
-	args := m.Called(document)
+func (m *UserUsecaseMock) GetUserByDocument(tenantID string, docType domain.DocumentType, country, number string) (*domain.User, error) {
+	args := m.Called(tenantID, docType, country, number)
 	return args.Get(0).(*domain.User), args.Error(1)
 }
 
-func (m *UserUsecaseMock) DeleteUser(id uuid.UUID) error {
-	args := m.Called(id)
+func (m *UserUsecaseMock) DeleteUser(tenantID string, id uuid.UUID, version int64) error {
+	args := m.Called(tenantID, id, version)
 	return args.Error(0)
 }
 
-func (m *UserUsecaseMock) UpdateUser(id uuid.UUID, userDTO *dto.UserDTO) (*domain.User, error) {
-	args := m.Called(id, userDTO)
-	return args.Get(0).(*domain.User), args.Error(1)
+func (m *UserUsecaseMock) UpdateUser(tenantID string, id uuid.UUID, userDTO *dto.UserDTO, version int64) (*domain.User, error) {
+	args := m.Called(tenantID, id, userDTO, version)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserUsecaseMock) PatchUser(tenantID string, id uuid.UUID, patchType string, patch []byte, version int64) (*domain.User, error) {
+	args := m.Called(tenantID, id, patchType, patch, version)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserUsecaseMock) RestoreUser(tenantID string, id uuid.UUID) (*domain.User, error) {
+	args := m.Called(tenantID, id)
+	user, _ := args.Get(0).(*domain.User)
+	return user, args.Error(1)
+}
+
+func (m *UserUsecaseMock) PurgeUser(tenantID string, id uuid.UUID) error {
+	args := m.Called(tenantID, id)
+	return args.Error(0)
+}
+
+func (m *UserUsecaseMock) PurgeDeletedUsers(tenantID string, deletedBefore time.Time) (int64, error) {
+	args := m.Called(tenantID, deletedBefore)
+	purged, _ := args.Get(0).(int64)
+	return purged, args.Error(1)
 }
@@ -0,0 +1,89 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDocument(t *testing.T) {
+	tests := map[string]struct {
+		input     string
+		canonical string
+		err       error
+	}{
+		"formatted cpf":          {input: "123.456.789-09", canonical: "12345678909"},
+		"plain cpf":              {input: "52998224725", canonical: "52998224725"},
+		"formatted cnpj":         {input: "11.222.333/0001-81", canonical: "11222333000181"},
+		"alphanumeric cnpj":      {input: "12.abc.345/01de-35", canonical: "12ABC34501DE35"},
+		"cpf wrong check digit":  {input: "123.456.789-00", err: domain.ErrInvalidCPF},
+		"cpf repeated digits":    {input: "111.111.111-11", err: domain.ErrInvalidCPF},
+		"cpf with letters":       {input: "1234567890A", err: domain.ErrInvalidCPF},
+		"cnpj wrong check digit": {input: "11.222.333/0001-80", err: domain.ErrInvalidCNPJ},
+		"cnpj letter in digits":  {input: "11.222.333/0001-8A", err: domain.ErrInvalidCNPJ},
+		"wrong length":           {input: "1234", err: domain.ErrInvalidDocument},
+		"empty":                  {input: "", err: domain.ErrInvalidDocument},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			canonical, err := domain.ParseDocument(tt.input)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.canonical, canonical)
+		})
+	}
+}
+
+func TestNormalizeDocument_LeavesUnknownValuesAlone(t *testing.T) {
+	assert.Equal(t, "12345678909", domain.NormalizeDocument("123.456.789-09"))
+	assert.Equal(t, "DOC1", domain.NormalizeDocument("DOC1"))
+}
+
+func TestNewUser_RejectsInvalidDocument(t *testing.T) {
+	_, err := domain.NewUser(&dto.UserDTO{Name: "Ana", Phone: "31994416221", Document: "123.456.789-00"}, "BR")
+
+	var fieldErr *domain.FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "document", fieldErr.Field)
+	assert.ErrorIs(t, err, domain.ErrInvalidCPF)
+}
+
+func TestNewIdentityDocument(t *testing.T) {
+	tests := map[string]struct {
+		docType  domain.DocumentType
+		number   string
+		country  string
+		expected domain.IdentityDocument
+		field    string
+	}{
+		"untyped cpf":         {number: "123.456.789-09", expected: domain.IdentityDocument{Type: domain.DocumentCPF, Number: "12345678909", Country: "BR"}},
+		"untyped cnpj":        {number: "11.222.333/0001-81", expected: domain.IdentityDocument{Type: domain.DocumentCNPJ, Number: "11222333000181", Country: "BR"}},
+		"passport":            {docType: domain.DocumentPassport, number: "ab 123456", country: "ar", expected: domain.IdentityDocument{Type: domain.DocumentPassport, Number: "AB123456", Country: "AR"}},
+		"crnm":                {docType: domain.DocumentCRNM, number: "v123456-7", expected: domain.IdentityDocument{Type: domain.DocumentCRNM, Number: "V1234567", Country: "BR"}},
+		"passport no country": {docType: domain.DocumentPassport, number: "AB123456", field: "document_country"},
+		"passport too long":   {docType: domain.DocumentPassport, number: "AB1234567890", country: "AR", field: "document"},
+		"rne malformed":       {docType: domain.DocumentRNE, number: "123456", field: "document"},
+		"cpf from abroad":     {docType: domain.DocumentCPF, number: "123.456.789-09", country: "US", field: "document_country"},
+		"cpf typed as cnpj":   {docType: domain.DocumentCNPJ, number: "123.456.789-09", field: "document"},
+		"unknown type":        {docType: "driver_license", number: "123", field: "document_type"},
+		"invalid untyped":     {number: "AB123456", field: "document"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			document, err := domain.NewIdentityDocument(tt.docType, tt.number, tt.country)
+
+			if tt.field == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, document)
+				return
+			}
+			var fieldErr *domain.FieldError
+			assert.ErrorAs(t, err, &fieldErr)
+			assert.Equal(t, tt.field, fieldErr.Field)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserETag(t *testing.T) {
+	user := &domain.User{Version: 7}
+
+	assert.Equal(t, `"7"`, user.ETag())
+	version, err := domain.ParseIfMatch(user.ETag())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), version)
+}
+
+func TestParseIfMatch(t *testing.T) {
+	tests := map[string]struct {
+		header  string
+		version int64
+		err     error
+	}{
+		"absent":       {header: ""},
+		"any":          {header: "*"},
+		"strong tag":   {header: `"3"`, version: 3},
+		"padded":       {header: ` "3" `, version: 3},
+		"weak tag":     {header: `W/"3"`, err: domain.ErrVersionMismatch},
+		"foreign tag":  {header: `"abc"`, err: domain.ErrVersionMismatch},
+		"zero version": {header: `"0"`, err: domain.ErrVersionMismatch},
+		"unquoted":     {header: "3", err: domain.ErrInvalidETag},
+		"list of tags": {header: `"3", "4"`, err: domain.ErrInvalidETag},
+		"lone quote":   {header: `"`, err: domain.ErrInvalidETag},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			version, err := domain.ParseIfMatch(tt.header)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.version, version)
+		})
+	}
+}
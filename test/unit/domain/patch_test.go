@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyMergePatch(t *testing.T) {
+	tests := map[string]struct {
+		doc, patch, expected string
+	}{
+		"replace member":  {doc: `{"a":"b","c":"d"}`, patch: `{"a":"z"}`, expected: `{"a":"z","c":"d"}`},
+		"remove member":   {doc: `{"a":"b","c":"d"}`, patch: `{"a":null}`, expected: `{"c":"d"}`},
+		"nested object":   {doc: `{"a":{"b":"c"}}`, patch: `{"a":{"d":"e"}}`, expected: `{"a":{"b":"c","d":"e"}}`},
+		"array replaced":  {doc: `{"a":[1,2]}`, patch: `{"a":[3]}`, expected: `{"a":[3]}`},
+		"object to value": {doc: `{"a":{"b":"c"}}`, patch: `{"a":"x"}`, expected: `{"a":"x"}`},
+		"non object":      {doc: `{"a":"b"}`, patch: `["c"]`, expected: `["c"]`},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			patched, err := domain.ApplyMergePatch([]byte(tt.doc), []byte(tt.patch))
+
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(patched))
+		})
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	const doc = `{"name":"Ana","tags":["a","b"],"a/b":{"~c":1}}`
+
+	tests := map[string]struct {
+		patch    string
+		expected string
+		err      error
+	}{
+		"replace":         {patch: `[{"op":"replace","path":"/name","value":"Bia"}]`, expected: `{"name":"Bia","tags":["a","b"],"a/b":{"~c":1}}`},
+		"add to array":    {patch: `[{"op":"add","path":"/tags/1","value":"x"}]`, expected: `{"name":"Ana","tags":["a","x","b"],"a/b":{"~c":1}}`},
+		"append to array": {patch: `[{"op":"add","path":"/tags/-","value":"c"}]`, expected: `{"name":"Ana","tags":["a","b","c"],"a/b":{"~c":1}}`},
+		"remove escaped":  {patch: `[{"op":"remove","path":"/a~1b/~0c"}]`, expected: `{"name":"Ana","tags":["a","b"],"a/b":{}}`},
+		"move":            {patch: `[{"op":"move","from":"/name","path":"/nick"}]`, expected: `{"nick":"Ana","tags":["a","b"],"a/b":{"~c":1}}`},
+		"copy":            {patch: `[{"op":"copy","from":"/tags/0","path":"/first"}]`, expected: `{"name":"Ana","first":"a","tags":["a","b"],"a/b":{"~c":1}}`},
+		"test then set":   {patch: `[{"op":"test","path":"/name","value":"Ana"},{"op":"replace","path":"/name","value":"Bia"}]`, expected: `{"name":"Bia","tags":["a","b"],"a/b":{"~c":1}}`},
+		"test fails":      {patch: `[{"op":"test","path":"/name","value":"Bia"}]`, err: domain.ErrPatchTestFailed},
+		"replace missing": {patch: `[{"op":"replace","path":"/nick","value":"x"}]`, err: domain.ErrInvalidPatch},
+		"remove missing":  {patch: `[{"op":"remove","path":"/tags/5"}]`, err: domain.ErrInvalidPatch},
+		"add no value":    {patch: `[{"op":"add","path":"/nick"}]`, err: domain.ErrInvalidPatch},
+		"unknown op":      {patch: `[{"op":"merge","path":"/name","value":"x"}]`, err: domain.ErrInvalidPatch},
+		"bad pointer":     {patch: `[{"op":"remove","path":"name"}]`, err: domain.ErrInvalidPatch},
+		"move into child": {patch: `[{"op":"move","from":"/a~1b","path":"/a~1b/x"}]`, err: domain.ErrInvalidPatch},
+		"not an array":    {patch: `{"op":"remove","path":"/name"}`, err: domain.ErrInvalidPatch},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			patched, err := domain.ApplyJSONPatch([]byte(doc), []byte(tt.patch))
+
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(patched))
+		})
+	}
+}
+
+func TestApplyPatch_UnsupportedType(t *testing.T) {
+	_, err := domain.ApplyPatch("application/json", []byte(`{}`), []byte(`{}`))
+
+	assert.ErrorIs(t, err, domain.ErrUnsupportedPatchType)
+}
@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhone(t *testing.T) {
+	tests := map[string]struct {
+		input   string
+		country string
+		e164    string
+		err     error
+	}{
+		"formatted mobile":         {input: "(48) 99999-0000", country: "BR", e164: "+5548999990000"},
+		"already e164":             {input: "+5548999990000", country: "BR", e164: "+5548999990000"},
+		"spaced e164":              {input: "+55 48 99999-0000", country: "BR", e164: "+5548999990000"},
+		"international prefix":     {input: "0055 48 99999 0000", country: "BR", e164: "+5548999990000"},
+		"trunk prefix":             {input: "048 99999-0000", country: "BR", e164: "+5548999990000"},
+		"carrier selection":        {input: "0 21 48 99999-0000", country: "BR", e164: "+5548999990000"},
+		"landline":                 {input: "(11) 3333-4444", country: "BR", e164: "+551133334444"},
+		"calling code typed":       {input: "5548999990000", country: "BR", e164: "+5548999990000"},
+		"other default country":    {input: "011 5555-0000", country: "AR", e164: "+541155550000"},
+		"foreign e164":             {input: "+1 (415) 555-2671", country: "BR", e164: "+14155552671"},
+		"unknown area code":        {input: "(20) 99999-0000", country: "BR", err: domain.ErrInvalidPhone},
+		"mobile without nine":      {input: "(48) 89999-0000", country: "BR", err: domain.ErrInvalidPhone},
+		"too short":                {input: "123456789", country: "BR", err: domain.ErrInvalidPhone},
+		"wrong length for country": {input: "+598 1234", country: "BR", err: domain.ErrInvalidPhone},
+		"letters":                  {input: "48 9999 ABCD", country: "BR", err: domain.ErrInvalidPhone},
+		"unknown default country":  {input: "48999990000", country: "ZZ", err: domain.ErrInvalidPhone},
+		"empty":                    {input: "", country: "BR", err: domain.ErrInvalidPhone},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			e164, err := domain.NormalizePhone(tt.input, tt.country)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.e164, e164)
+		})
+	}
+}
+
+func TestNormalizePhoneLookup_LeavesUnknownValuesAlone(t *testing.T) {
+	assert.Equal(t, "+5548999990000", domain.NormalizePhoneLookup("(48) 99999-0000", "BR"))
+	assert.Equal(t, "phone1", domain.NormalizePhoneLookup(" phone1 ", "BR"))
+}
+
+func TestNewUser_RejectsInvalidPhone(t *testing.T) {
+	_, err := domain.NewUser(&dto.UserDTO{Name: "Ana", Phone: "123", Document: "123.456.789-09"}, "BR")
+
+	var fieldErr *domain.FieldError
+	assert.ErrorAs(t, err, &fieldErr)
+	assert.Equal(t, "phone", fieldErr.Field)
+	assert.ErrorIs(t, err, domain.ErrInvalidPhone)
+}
@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestParseUserExportColumns(t *testing.T) {
+	tests := map[string]struct {
+		value   string
+		columns []string
+		err     error
+	}{
+		"default":  {value: "", columns: domain.DefaultUserExportColumns},
+		"selected": {value: "name, phone,deleted_at", columns: []string{"name", "phone", "deleted_at"}},
+		"unknown":  {value: "name,password_hash", err: domain.ErrInvalidExportColumn},
+		"repeated": {value: "name,name", err: domain.ErrInvalidExportColumn},
+		"empty":    {value: "name,", err: domain.ErrInvalidExportColumn},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			columns, err := domain.ParseUserExportColumns(tt.value)
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, tt.columns, columns)
+		})
+	}
+}
+
+func TestUserExportRow(t *testing.T) {
+	deletedAt := time.Date(2024, 5, 2, 12, 0, 0, 0, time.FixedZone("BRT", -3*60*60))
+	user := &domain.User{
+		ID:        uuid.MustParse("af430404-e5ea-4752-9d89-0c371ec0d9fc"),
+		Name:      "Ana",
+		Version:   2,
+		DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true},
+	}
+
+	row := domain.UserExportRow(user, []string{"id", "name", "version", "deleted_at", "anonymized_at"})
+
+	assert.Equal(t, []interface{}{"af430404-e5ea-4752-9d89-0c371ec0d9fc", "Ana", int64(2), "2024-05-02T15:00:00Z", nil}, row)
+}
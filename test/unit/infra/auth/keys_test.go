@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pemFor(t *testing.T, key crypto.PrivateKey) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func writePEMKey(t *testing.T, dir, name string, key crypto.PrivateKey) string {
+	path := filepath.Join(dir, name+".pem")
+	require.NoError(t, os.WriteFile(path, pemFor(t, key), 0o600))
+
+	return path
+}
+
+func generateKeys(t *testing.T) (rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey, edKey ed25519.PrivateKey) {
+	var err error
+	rsaKey, err = rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err = ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	return rsaKey, ecKey, edKey
+}
+
+func TestLoadKeySet_SignsAndVerifiesEveryAlgorithm(t *testing.T) {
+	dir := t.TempDir()
+	rsaKey, ecKey, edKey := generateKeys(t)
+	keysEntry := "rsa=" + writePEMKey(t, dir, "rsa", rsaKey) +
+		",ec=" + writePEMKey(t, dir, "ec", ecKey) +
+		",ed=" + writePEMKey(t, dir, "ed", edKey)
+
+	for kid, alg := range map[string]string{"rsa": "RS256", "ec": "ES256", "ed": "EdDSA"} {
+		t.Run(alg, func(t *testing.T) {
+			cfg := config.Config{JWTExpirationMinutes: 5, JWTKeys: keysEntry, JWTActiveKeyID: kid}
+			keys, err := auth.LoadKeySet(cfg)
+			require.NoError(t, err)
+
+			userID := uuid.New()
+			token, err := auth.GenerateJWT(auth.Claims{UserID: userID}, keys, cfg)
+			require.NoError(t, err)
+
+			claims, err := auth.ValidateJWT(token, keys)
+			assert.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+		})
+	}
+}
+
+func TestValidateJWT_RetiredKeyGracePeriod(t *testing.T) {
+	rsaKey, ecKey, _ := generateKeys(t)
+	oldKey, err := auth.ParsePEMKey("old", pemFor(t, rsaKey))
+	require.NoError(t, err)
+	newKey, err := auth.ParsePEMKey("new", pemFor(t, ecKey))
+	require.NoError(t, err)
+	cfg := config.Config{JWTExpirationMinutes: 5}
+
+	before, err := auth.NewKeySet("old", time.Hour, oldKey, newKey)
+	require.NoError(t, err)
+	token, err := auth.GenerateJWT(auth.Claims{UserID: uuid.New()}, before, cfg)
+	require.NoError(t, err)
+
+	oldKey.RetiredAt = time.Now().Add(-30 * time.Minute)
+	within, err := auth.NewKeySet("new", time.Hour, oldKey, newKey)
+	require.NoError(t, err)
+	_, err = auth.ValidateJWT(token, within)
+	assert.NoError(t, err)
+	assert.Len(t, within.JWKS().Keys, 2)
+
+	oldKey.RetiredAt = time.Now().Add(-2 * time.Hour)
+	_, err = auth.ValidateJWT(token, within)
+	assert.ErrorIs(t, err, auth.ErrKeyExpired)
+	assert.Len(t, within.JWKS().Keys, 1)
+	assert.Equal(t, "new", within.JWKS().Keys[0].Kid)
+}
+
+func TestValidateJWT_RejectsHS256WhenUsingAsymmetricKeys(t *testing.T) {
+	_, _, edKey := generateKeys(t)
+	cfg := config.Config{JWTSecret: "secret", JWTExpirationMinutes: 5}
+
+	symmetric, err := auth.LoadKeySet(cfg)
+	require.NoError(t, err)
+	token, err := auth.GenerateJWT(auth.Claims{UserID: uuid.New()}, symmetric, cfg)
+	require.NoError(t, err)
+
+	key, err := auth.ParsePEMKey("ed", pemFor(t, edKey))
+	require.NoError(t, err)
+	asymmetric, err := auth.NewKeySet("ed", 0, key)
+	require.NoError(t, err)
+
+	_, err = auth.ValidateJWT(token, asymmetric)
+	assert.Error(t, err)
+	assert.Empty(t, symmetric.JWKS().Keys)
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Base32 of the RFC 6238 SHA-1 test seed "12345678901234567890".
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode_RFC6238Vectors(t *testing.T) {
+	for unix, want := range map[int64]string{59: "287082", 1111111109: "081804", 1234567890: "005924"} {
+		code, err := auth.TOTPCode(rfcSecret, time.Unix(unix, 0))
+		require.NoError(t, err)
+		assert.Equal(t, want, code)
+	}
+}
+
+func TestValidateTOTP_AcceptsAdjacentStepsOnly(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := auth.TOTPCode(rfcSecret, now)
+	require.NoError(t, err)
+
+	_, ok := auth.ValidateTOTP(rfcSecret, code, now.Add(30*time.Second))
+	assert.True(t, ok)
+	_, ok = auth.ValidateTOTP(rfcSecret, code, now.Add(90*time.Second))
+	assert.False(t, ok)
+	_, ok = auth.ValidateTOTP(rfcSecret, "12345", now)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri := auth.TOTPURI("Pousada", "doc 1", rfcSecret)
+
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Pousada:doc%201?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=Pousada")
+}
+
+func TestGenerateRecoveryCodes(t *testing.T) {
+	codes, err := auth.GenerateRecoveryCodes(10)
+	require.NoError(t, err)
+
+	assert.Len(t, codes, 10)
+	assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, codes[0])
+	assert.Equal(t, codes[0], auth.NormalizeRecoveryCode(strings.ToUpper(strings.ReplaceAll(codes[0], "-", ""))))
+}
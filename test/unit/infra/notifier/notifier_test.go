@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/infra/notifier"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFileNotifier_AppendsJSONLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notifications.log")
+	notify, err := notifier.New(config.Config{NotifierType: "file", NotifierFile: path}, zap.NewNop())
+	require.NoError(t, err)
+
+	userID := uuid.New()
+	require.NoError(t, notify.Notify(notifier.Message{UserID: userID, Subject: "first", Body: "one"}))
+	require.NoError(t, notify.Notify(notifier.Message{UserID: userID, Subject: "second", Body: "two"}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+
+	var msg notifier.Message
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &msg))
+	assert.Equal(t, userID, msg.UserID)
+	assert.Equal(t, "second", msg.Subject)
+}
+
+func TestNew_UnknownType(t *testing.T) {
+	_, err := notifier.New(config.Config{NotifierType: "pigeon"}, zap.NewNop())
+
+	assert.ErrorIs(t, err, notifier.ErrUnknownNotifier)
+}
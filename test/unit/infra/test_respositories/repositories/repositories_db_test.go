@@ -19,23 +19,24 @@ func TestCreateUser_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	user := &domain.User{
 		ID:        uuid.New(),
 		Name:      "John Doe",
-		Phone:     "123456789",
-		Document:  "doc1",
+		Phone:     "+5548999990000",
+		Document:  "DOC1",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
 	userRepoMock.EXPECT().CreateUser(gomock.Any()).Return(nil) // Accepts any user object
 
-	result, err := usecase.CreateUser(&dto.UserDTO{
+	result, err := usecase.CreateUser(domain.DefaultTenant, &dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "529.982.247-25",
+		Password: "s3cret-pass",
 	})
 
 	assert.NoError(t, err)
@@ -49,18 +50,19 @@ func TestCreateUser_Failure(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userDTO := &dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "529.982.247-25",
+		Password: "s3cret-pass",
 	}
 	// action
 	expectedErr := errors.New("erro ao criar o usuário")
 	userRepoMock.EXPECT().CreateUser(gomock.Any()).Return(expectedErr)
 
-	result, err := usecase.CreateUser(userDTO)
+	result, err := usecase.CreateUser(domain.DefaultTenant, userDTO)
 	// assert
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -72,12 +74,12 @@ func TestGetUsers_Failure(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	expectedErr := errors.New("erro ao obter usuários")
-	userRepoMock.EXPECT().GetUsers().Return(nil, expectedErr)
+	userRepoMock.EXPECT().GetUsers(gomock.Any()).Return(nil, expectedErr)
 
-	result, err := usecase.GetUsers()
+	result, err := usecase.GetUsers(domain.UserFilter{TenantID: domain.DefaultTenant})
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -89,14 +91,14 @@ func TestGetUsers_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	mockUsers := []*domain.User{
 		{
 			ID:        uuid.New(),
 			Name:      "John Doe",
-			Phone:     "123456789",
-			Document:  "doc1",
+			Phone:     "+5548999990000",
+			Document:  "DOC1",
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
@@ -104,20 +106,20 @@ func TestGetUsers_Success(t *testing.T) {
 			ID:        uuid.New(),
 			Name:      "Jane Doe",
 			Phone:     "987654321",
-			Document:  "doc2",
+			Document:  "DOC2",
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
 	}
 
-	userRepoMock.EXPECT().GetUsers().Return(mockUsers, nil)
+	userRepoMock.EXPECT().GetUsers(gomock.Any()).Return(&domain.UserPage{Users: mockUsers}, nil)
 
-	result, err := usecase.GetUsers()
+	result, err := usecase.GetUsers(domain.UserFilter{TenantID: domain.DefaultTenant})
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
-	assert.Equal(t, len(mockUsers), len(result))
-	assert.Equal(t, mockUsers, result)
+	assert.Equal(t, len(mockUsers), len(result.Users))
+	assert.Equal(t, mockUsers, result.Users)
 }
 
 func TestGetUserByDocument_Success(t *testing.T) {
@@ -125,20 +127,20 @@ func TestGetUserByDocument_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	mockUser := &domain.User{
 		ID:        uuid.New(),
 		Name:      "John Doe",
-		Phone:     "123456789",
-		Document:  "doc1",
+		Phone:     "+5548999990000",
+		Document:  "DOC1",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	userRepoMock.EXPECT().GetUserByData("doc1").Return(mockUser, nil)
+	userRepoMock.EXPECT().GetUserByData(domain.DefaultTenant, "DOC1").Return(mockUser, nil)
 
-	result, err := usecase.GetUserByDocument("doc1")
+	result, err := usecase.GetUserByDocument(domain.DefaultTenant, "", "", "DOC1")
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -150,11 +152,11 @@ func TestGetUserByDocument_Failure(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
-	userRepoMock.EXPECT().GetUserByData("doc1").Return(nil, errors.New("user not found"))
+	userRepoMock.EXPECT().GetUserByData(domain.DefaultTenant, "DOC1").Return(nil, errors.New("user not found"))
 
-	result, err := usecase.GetUserByDocument("doc1")
+	result, err := usecase.GetUserByDocument(domain.DefaultTenant, "", "", "DOC1")
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -165,13 +167,14 @@ func TestDeleteUser_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
 
-	userRepoMock.EXPECT().DeleteUser(userID).Return(nil)
+	userRepoMock.EXPECT().GetUserByID(domain.DefaultTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.EXPECT().DeleteUser(domain.DefaultTenant, userID, int64(0)).Return(nil)
 
-	err := usecase.DeleteUser(userID)
+	err := usecase.DeleteUser(domain.DefaultTenant, userID, 0)
 
 	assert.NoError(t, err)
 }
@@ -181,13 +184,14 @@ func TestDeleteUser_Failure(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
 
-	userRepoMock.EXPECT().DeleteUser(userID).Return(errors.New("error deleting user"))
+	userRepoMock.EXPECT().GetUserByID(domain.DefaultTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.EXPECT().DeleteUser(domain.DefaultTenant, userID, int64(0)).Return(errors.New("error deleting user"))
 
-	err := usecase.DeleteUser(userID)
+	err := usecase.DeleteUser(domain.DefaultTenant, userID, 0)
 
 	assert.Error(t, err)
 }
@@ -197,27 +201,28 @@ func TestUpdateUser_Success(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
 	userDTO := &dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "529.982.247-25",
 	}
 
 	updatedUser := &domain.User{
 		ID:        userID,
 		Name:      "John Doe",
-		Phone:     "123456789",
-		Document:  "doc1",
+		Phone:     "+5548999990000",
+		Document:  "DOC1",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	userRepoMock.EXPECT().UpdateUser(userID, gomock.Any()).Return(updatedUser, nil)
+	userRepoMock.EXPECT().GetUserByID(domain.DefaultTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.EXPECT().UpdateUser(domain.DefaultTenant, userID, gomock.Any(), int64(0)).Return(updatedUser, nil)
 
-	result, err := usecase.UpdateUser(userID, userDTO)
+	result, err := usecase.UpdateUser(domain.DefaultTenant, userID, userDTO, 0)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, result)
@@ -229,18 +234,19 @@ func TestUpdateUser_Failure(t *testing.T) {
 	defer ctrl.Finish()
 
 	userRepoMock := mocks.NewUserRepositoryMock(ctrl)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
 	userDTO := &dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "529.982.247-25",
 	}
 
-	userRepoMock.EXPECT().UpdateUser(userID, gomock.Any()).Return(nil, errors.New("error updating user"))
+	userRepoMock.EXPECT().GetUserByID(domain.DefaultTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.EXPECT().UpdateUser(domain.DefaultTenant, userID, gomock.Any(), int64(0)).Return(nil, errors.New("error updating user"))
 
-	result, err := usecase.UpdateUser(userID, userDTO)
+	result, err := usecase.UpdateUser(domain.DefaultTenant, userID, userDTO, 0)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	handler "github.com/ThailanTec/challenger/pousada/src/handlers"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestImportUsers(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		query       string
+		body        string
+		status      int
+	}{
+		"dry run":          {contentType: "text/csv; charset=utf-8", query: "?dry_run=true", body: "name,phone,document,password\nAna,+5548999990000,52998224725,s3cret-pass\n", status: http.StatusOK},
+		"invalid dry_run":  {contentType: domain.CSVType, query: "?dry_run=maybe", status: http.StatusBadRequest},
+		"unsupported type": {contentType: "application/json", body: "[]", status: http.StatusUnsupportedMediaType},
+		"bad header":       {contentType: domain.CSVType, body: "nome,telefone\n", status: http.StatusBadRequest},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			// Arrange
+			userRepoMock := new(mocks.UserRepositoryMock)
+			userRepoMock.On("UpsertUsers", domain.DefaultTenant, mock.Anything, true).
+				Return([]domain.UpsertResult{{Created: true}}, nil).Maybe()
+			importUsecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), config.Config{PhoneDefaultCountry: "BR"})
+			router := gin.New()
+			router.POST("/users/import", handler.NewImportHandler(importUsecase, 1<<20, zap.NewNop()).ImportUsers)
+
+			// Act
+			req := httptest.NewRequest(http.MethodPost, "/users/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			switch tt.status {
+			case http.StatusOK:
+				var report dto.ImportReportDTO
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+				assert.True(t, report.DryRun)
+				assert.Equal(t, 1, report.Created)
+				require.Len(t, report.Rows, 1)
+				assert.Equal(t, "created", report.Rows[0].Action)
+			case http.StatusUnsupportedMediaType:
+				assert.Contains(t, w.Header().Get("Accept-Post"), domain.NDJSONType)
+			}
+		})
+	}
+}
+
+func TestImportUsers_CutShort(t *testing.T) {
+	valid := "name,phone,document,password\nAna,+5548999990000,52998224725,s3cret-pass\n"
+	tests := map[string]struct {
+		body     string
+		maxBytes int64
+		status   int
+	}{
+		"unreadable row": {body: valid + "\"Bia,+5548999990001\n", maxBytes: 1 << 20, status: http.StatusBadRequest},
+		"too large":      {body: valid + strings.Repeat("x", 4096), maxBytes: int64(len(valid) + 64), status: http.StatusRequestEntityTooLarge},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			// Arrange
+			userRepoMock := new(mocks.UserRepositoryMock)
+			userRepoMock.On("UpsertUsers", domain.DefaultTenant, mock.Anything, false).
+				Return([]domain.UpsertResult{{Created: true}}, nil)
+			importUsecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), config.Config{PhoneDefaultCountry: "BR"})
+			router := gin.New()
+			router.POST("/users/import", handler.NewImportHandler(importUsecase, tt.maxBytes, zap.NewNop()).ImportUsers)
+
+			// Act
+			req := httptest.NewRequest(http.MethodPost, "/users/import", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", domain.CSVType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			var response struct {
+				Error  string              `json:"error"`
+				Report dto.ImportReportDTO `json:"report"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.NotEmpty(t, response.Error)
+			assert.Equal(t, 1, response.Report.Created, "the row saved before the error is reported")
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	handler "github.com/ThailanTec/challenger/pousada/src/handlers"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	repoMocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/usecases"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestGetMe_Success(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	cfg := config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5}
+	keys, err := auth.LoadKeySet(cfg)
+	require.NoError(t, err)
+
+	user := &domain.User{ID: uuid.New(), TenantID: domain.DefaultTenant, Name: "John Doe", Document: "DOC1", Role: domain.RoleGuest}
+	userRepoMock := new(repoMocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", domain.DefaultTenant, user.ID).Return(user, nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, repoMocks.NewFakeRedisRepository(), keys, cfg)
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	meHandler := handler.NewMeHandler(userUsecaseMock, authUsecase, zap.NewNop())
+
+	router := gin.New()
+	me := router.Group("/me", middleware.JWTAuthMiddleware(authUsecase))
+	me.GET("", meHandler.GetMe)
+	me.PUT("", meHandler.UpdateMe)
+	me.DELETE("", meHandler.DeleteMe)
+
+	token, err := auth.GenerateJWT(auth.Claims{UserID: user.ID, Role: user.Role, TenantID: user.TenantID}, keys, cfg)
+	require.NoError(t, err)
+	send := func(method string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/me", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Act
+	w := send(http.MethodGet, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response dto.UserResponseDTO
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, user.ID, response.ID)
+}
+
+func TestUpdateMe_UsesAuthenticatedUser(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	cfg := config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5}
+	keys, err := auth.LoadKeySet(cfg)
+	require.NoError(t, err)
+
+	user := &domain.User{ID: uuid.New(), TenantID: domain.DefaultTenant, Name: "John Doe", Document: "DOC1", Role: domain.RoleGuest}
+	userRepoMock := new(repoMocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", domain.DefaultTenant, user.ID).Return(user, nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, repoMocks.NewFakeRedisRepository(), keys, cfg)
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	meHandler := handler.NewMeHandler(userUsecaseMock, authUsecase, zap.NewNop())
+
+	router := gin.New()
+	me := router.Group("/me", middleware.JWTAuthMiddleware(authUsecase))
+	me.GET("", meHandler.GetMe)
+	me.PUT("", meHandler.UpdateMe)
+	me.DELETE("", meHandler.DeleteMe)
+
+	token, err := auth.GenerateJWT(auth.Claims{UserID: user.ID, Role: user.Role, TenantID: user.TenantID}, keys, cfg)
+	require.NoError(t, err)
+	send := func(method string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/me", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	userUsecaseMock.On("UpdateUser", domain.DefaultTenant, user.ID, mock.Anything, int64(0)).Return(user, nil)
+	body, _ := json.Marshal(dto.UserDTO{Name: "John Doe", Phone: "123", Document: "DOC1"})
+
+	// Act
+	w := send(http.MethodPut, body)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func TestDeleteMe_RevokesToken(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	cfg := config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5}
+	keys, err := auth.LoadKeySet(cfg)
+	require.NoError(t, err)
+
+	user := &domain.User{ID: uuid.New(), TenantID: domain.DefaultTenant, Name: "John Doe", Document: "DOC1", Role: domain.RoleGuest}
+	userRepoMock := new(repoMocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", domain.DefaultTenant, user.ID).Return(user, nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, repoMocks.NewFakeRedisRepository(), keys, cfg)
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	meHandler := handler.NewMeHandler(userUsecaseMock, authUsecase, zap.NewNop())
+
+	router := gin.New()
+	me := router.Group("/me", middleware.JWTAuthMiddleware(authUsecase))
+	me.GET("", meHandler.GetMe)
+	me.PUT("", meHandler.UpdateMe)
+	me.DELETE("", meHandler.DeleteMe)
+
+	token, err := auth.GenerateJWT(auth.Claims{UserID: user.ID, Role: user.Role, TenantID: user.TenantID}, keys, cfg)
+	require.NoError(t, err)
+	send := func(method string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/me", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	userUsecaseMock.On("DeleteUser", domain.DefaultTenant, user.ID, int64(0)).Return(nil)
+
+	// Act
+	w := send(http.MethodDelete, nil)
+	after := send(http.MethodGet, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, after.Code)
+}
+
+func TestDeleteMe_FailedDeleteKeepsSession(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	cfg := config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5}
+	keys, err := auth.LoadKeySet(cfg)
+	require.NoError(t, err)
+
+	user := &domain.User{ID: uuid.New(), TenantID: domain.DefaultTenant, Name: "John Doe", Document: "DOC1", Role: domain.RoleGuest}
+	userRepoMock := new(repoMocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", domain.DefaultTenant, user.ID).Return(user, nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, repoMocks.NewFakeRedisRepository(), keys, cfg)
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	meHandler := handler.NewMeHandler(userUsecaseMock, authUsecase, zap.NewNop())
+
+	router := gin.New()
+	me := router.Group("/me", middleware.JWTAuthMiddleware(authUsecase))
+	me.GET("", meHandler.GetMe)
+	me.PUT("", meHandler.UpdateMe)
+	me.DELETE("", meHandler.DeleteMe)
+
+	token, err := auth.GenerateJWT(auth.Claims{UserID: user.ID, Role: user.Role, TenantID: user.TenantID}, keys, cfg)
+	require.NoError(t, err)
+	send := func(method string, body []byte) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/me", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	userUsecaseMock.On("DeleteUser", domain.DefaultTenant, user.ID, int64(0)).Return(domain.ErrVersionMismatch)
+
+	// Act
+	w := send(http.MethodDelete, nil)
+	after := send(http.MethodGet, nil)
+
+	// Assert
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, http.StatusOK, after.Code, "the user is not logged out of an account that still exists")
+}
@@ -11,13 +11,20 @@ import (
 	"time"
 
 	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
 	"github.com/ThailanTec/challenger/pousada/src/dto"
 	handler "github.com/ThailanTec/challenger/pousada/src/handlers"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	repoMocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
 	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/usecases"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
 )
 
 // TestCreateUser_Success tests the successful creation of a user
@@ -26,7 +33,7 @@ func TestCreateUser_Success(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	// Creating a test server with the handler
 	router := gin.Default()
@@ -34,20 +41,20 @@ func TestCreateUser_Success(t *testing.T) {
 
 	userDTO := dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "DOC1",
 	}
 
 	user := &domain.User{
 		ID:        uuid.New(),
 		Name:      "John Doe",
-		Phone:     "123456789",
-		Document:  "doc1",
+		Phone:     "+5548999990000",
+		Document:  "DOC1",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	userUsecaseMock.On("CreateUser", mock.Anything).Return(user, nil)
+	userUsecaseMock.On("CreateUser", domain.DefaultTenant, mock.Anything).Return(user, nil)
 
 	// Act
 	body, _ := json.Marshal(userDTO)
@@ -68,18 +75,18 @@ func TestCreateUser_InternalServerError(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	router := gin.Default()
 	router.POST("/users", userHandler.CreateUser)
 
 	userDTO := dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "DOC1",
 	}
 
-	userUsecaseMock.On("CreateUser", mock.Anything).Return(nil, errors.New("something went wrong"))
+	userUsecaseMock.On("CreateUser", domain.DefaultTenant, mock.Anything).Return(nil, errors.New("something went wrong"))
 
 	// Act
 	body, _ := json.Marshal(userDTO)
@@ -94,6 +101,50 @@ func TestCreateUser_InternalServerError(t *testing.T) {
 	userUsecaseMock.AssertExpectations(t)
 }
 
+func TestCreateUser_InvalidDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+
+	router := gin.New()
+	router.POST("/users", userHandler.CreateUser)
+
+	fieldErr := &domain.FieldError{Field: "document", Err: domain.ErrInvalidCPF}
+	userUsecaseMock.On("CreateUser", domain.DefaultTenant, mock.Anything).Return(nil, fieldErr)
+
+	// Act
+	body, _ := json.Marshal(dto.UserDTO{Name: "John Doe", Phone: "+5548999990000", Document: "123.456.789-00", Password: "s3cret-pass"})
+	req, _ := http.NewRequest(http.MethodPost, "/users", bytes.NewBuffer(body))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var response struct {
+		Fields map[string]string `json:"fields"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, domain.ErrInvalidCPF.Error(), response.Fields["document"])
+}
+
+func TestGetUserByDocument_InvalidType(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	router := gin.New()
+	router.GET("/users/:document", userHandler.GetUserByDocument)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/AB123456?type=driver_license", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	userUsecaseMock.AssertNotCalled(t, "GetUserByDocument", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestGetUser_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -103,15 +154,16 @@ func TestGetUser_Success(t *testing.T) {
 		{
 			ID:        uuid.New(),
 			Name:      "John Doe",
-			Phone:     "123456789",
-			Document:  "doc1",
+			Phone:     "+5548999990000",
+			Document:  "DOC1",
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		},
 	}
-	userUsecaseMock.On("GetUsers").Return(users, nil)
+	defaultFilter := domain.UserFilter{TenantID: domain.DefaultTenant, Deleted: domain.DeletedExclude, Sort: domain.UserSortCreatedAt}
+	userUsecaseMock.On("GetUsers", defaultFilter).Return(&domain.UserPage{Users: users}, nil)
 
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	router := gin.Default()
 	router.GET("/users", userHandler.GetUser)
@@ -127,12 +179,110 @@ func TestGetUser_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)
 	userUsecaseMock.AssertExpectations(t)
 
-	var responseBody []dto.UserDTO
+	var responseBody dto.UserPageResponseDTO
 	err := json.Unmarshal(w.Body.Bytes(), &responseBody)
 	if err != nil {
 		t.Errorf("Error unmarshalling response body: %v", err)
 	}
-	assert.Equal(t, len(users), len(responseBody))
+	assert.Equal(t, len(users), len(responseBody.Data))
+	assert.Empty(t, responseBody.NextCursor)
+	assert.Nil(t, responseBody.Total)
+}
+
+func TestGetUser_ParsesFiltersAndCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	last := &domain.User{ID: uuid.New(), Name: "Ana"}
+	cursor := domain.NewUserCursor(last, domain.UserSortName, true)
+	total := int64(42)
+	expected := domain.UserFilter{
+		TenantID:    domain.DefaultTenant,
+		NamePrefix:  "An",
+		Phone:       "31994416221",
+		CreatedFrom: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		CreatedTo:   time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+		Deleted:     domain.DeletedInclude,
+		Sort:        domain.UserSortName,
+		Desc:        true,
+		Limit:       10,
+		After:       cursor,
+		WithTotal:   true,
+	}
+	userUsecaseMock.On("GetUsers", expected).Return(&domain.UserPage{Users: []*domain.User{last}, NextCursor: cursor.Encode(), Total: &total}, nil)
+
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	router := gin.New()
+	router.GET("/users", userHandler.GetUser)
+
+	// Act
+	query := "?limit=10&name=An&phone=31994416221&created_from=2024-01-01&created_to=2024-01-31" +
+		"&deleted=include&sort=name&order=desc&include_total=true&cursor=" + cursor.Encode()
+	req, _ := http.NewRequest(http.MethodGet, "/users"+query, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	var responseBody dto.UserPageResponseDTO
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &responseBody))
+	assert.Equal(t, cursor.Encode(), responseBody.NextCursor)
+	assert.Equal(t, &total, responseBody.Total)
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func TestGetUser_InvalidQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userHandler := handler.NewUserHandler(new(mocks.UserUsecaseMock), zap.NewNop())
+	router := gin.New()
+	router.GET("/users", userHandler.GetUser)
+
+	for _, query := range []string{"limit=0", "cursor=nope", "sort=phone", "order=up", "deleted=maybe", "created_from=yesterday"} {
+		req, _ := http.NewRequest(http.MethodGet, "/users?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetUser_DeletedNeedsDeletePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5}
+	keys, err := auth.LoadKeySet(cfg)
+	require.NoError(t, err)
+
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userUsecaseMock.On("GetUsers", mock.Anything).Return(&domain.UserPage{}, nil)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	authUsecase := usecases.NewAuthUsecase(new(repoMocks.UserRepositoryMock), repoMocks.NewFakeRedisRepository(), keys, cfg)
+
+	router := gin.New()
+	users := router.Group("/users", middleware.JWTAuthMiddleware(authUsecase))
+	users.GET("", userHandler.GetUser)
+	users.GET("export", userHandler.ExportUsers)
+
+	token, err := auth.GenerateJWT(auth.Claims{UserID: uuid.New(), Role: domain.RoleStaff, TenantID: domain.DefaultTenant}, keys, cfg)
+	require.NoError(t, err)
+
+	for path, want := range map[string]int{
+		"/users":                     http.StatusOK,
+		"/users?deleted=exclude":     http.StatusOK,
+		"/users?deleted=include":     http.StatusForbidden,
+		"/users?deleted=only":        http.StatusForbidden,
+		"/users/export?deleted=only": http.StatusForbidden,
+	} {
+		req, _ := http.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, want, w.Code, path)
+	}
+	userUsecaseMock.AssertNumberOfCalls(t, "GetUsers", 2)
+	userUsecaseMock.AssertNotCalled(t, "ExportUsers", mock.Anything, mock.Anything)
 }
 
 func TestGetUser_Failure(t *testing.T) {
@@ -140,9 +290,9 @@ func TestGetUser_Failure(t *testing.T) {
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
 	expectedError := errors.New("failed to fetch users")
-	userUsecaseMock.On("GetUsers").Return([]*domain.User{}, expectedError)
+	userUsecaseMock.On("GetUsers", mock.Anything).Return(nil, expectedError)
 
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	router := gin.Default()
 	router.GET("/users", userHandler.GetUser)
@@ -171,10 +321,10 @@ func TestGetUserByDocument_Success(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	expectedUser := &domain.User{}
-	userUsecaseMock.On("GetUserByDocument", mock.Anything).Return(expectedUser, nil)
+	userUsecaseMock.On("GetUserByDocument", domain.DefaultTenant, domain.DocumentType(""), "", mock.Anything).Return(expectedUser, nil)
 
 	router := gin.Default()
 	router.GET("/users/:document", userHandler.GetUserByDocument)
@@ -200,10 +350,10 @@ func TestGetUserByDocument_Failure(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	expectedError := errors.New("failed to get user")
-	userUsecaseMock.On("GetUserByDocument", mock.Anything).Return(&domain.User{}, expectedError)
+	userUsecaseMock.On("GetUserByDocument", domain.DefaultTenant, domain.DocumentType(""), "", mock.Anything).Return(&domain.User{}, expectedError)
 
 	router := gin.Default()
 	router.GET("/users/:document", userHandler.GetUserByDocument)
@@ -224,10 +374,10 @@ func TestDeleteUser_Success(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	userID := uuid.New()
-	userUsecaseMock.On("DeleteUser", userID).Return(nil)
+	userUsecaseMock.On("DeleteUser", domain.DefaultTenant, userID, int64(0)).Return(nil)
 
 	router := gin.Default()
 	router.DELETE("/users/:id", userHandler.DeleteUser)
@@ -247,11 +397,11 @@ func TestDeleteUser_Failure(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	userID := uuid.New()
 	expectedError := errors.New("failed to delete user")
-	userUsecaseMock.On("DeleteUser", userID).Return(expectedError)
+	userUsecaseMock.On("DeleteUser", domain.DefaultTenant, userID, int64(0)).Return(expectedError)
 
 	router := gin.Default()
 	router.DELETE("/users/:id", userHandler.DeleteUser)
@@ -271,7 +421,7 @@ func TestUpdateUser_Success(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	userID := uuid.New()
 	userDTO := dto.UserDTO{
@@ -280,7 +430,7 @@ func TestUpdateUser_Success(t *testing.T) {
 	expectedUser := &domain.User{
 		// ... populate with expected user data
 	}
-	userUsecaseMock.On("UpdateUser", userID, mock.Anything).Return(expectedUser, nil)
+	userUsecaseMock.On("UpdateUser", domain.DefaultTenant, userID, mock.Anything, int64(0)).Return(expectedUser, nil)
 
 	router := gin.Default()
 	router.PUT("/users/:id", userHandler.UpdateUser)
@@ -302,7 +452,7 @@ func TestUpdateUser_InvalidJSON(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	userID := uuid.New()
 
@@ -326,14 +476,14 @@ func TestUpdateUser_UsecaseError(t *testing.T) {
 
 	// Arrange
 	userUsecaseMock := new(mocks.UserUsecaseMock)
-	userHandler := handler.NewUserHandler(userUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
 
 	userID := uuid.New()
 	userDTO := dto.UserDTO{
 		// ... populate with user data
 	}
 	expectedError := errors.New("failed to update user")
-	userUsecaseMock.On("UpdateUser", userID, mock.Anything).Return(&domain.User{}, expectedError)
+	userUsecaseMock.On("UpdateUser", domain.DefaultTenant, userID, mock.Anything, int64(0)).Return(&domain.User{}, expectedError)
 
 	router := gin.Default()
 	router.PUT("/users/:id", userHandler.UpdateUser)
@@ -349,3 +499,434 @@ func TestUpdateUser_UsecaseError(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	userUsecaseMock.AssertExpectations(t)
 }
+
+func TestGetUserByDocument_SetsETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	user := &domain.User{ID: uuid.New(), Document: "52998224725", DocumentType: domain.DocumentCPF, Version: 4}
+	userUsecaseMock.On("GetUserByDocument", domain.DefaultTenant, domain.DocumentType(""), "", "52998224725").Return(user, nil)
+
+	router := gin.Default()
+	router.GET("/users/:document", userHandler.GetUserByDocument)
+
+	// Act
+	req, _ := http.NewRequest(http.MethodGet, "/users/52998224725", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+}
+
+func TestUpdateUser_IfMatch(t *testing.T) {
+	tests := map[string]struct {
+		ifMatch string
+		version int64
+		err     error
+		status  int
+	}{
+		"current version": {ifMatch: `"4"`, version: 4, status: http.StatusOK},
+		"stale version":   {ifMatch: `"3"`, version: 3, err: domain.ErrVersionMismatch, status: http.StatusPreconditionFailed},
+		"weak tag":        {ifMatch: `W/"4"`, status: http.StatusPreconditionFailed},
+		"malformed":       {ifMatch: "4", status: http.StatusBadRequest},
+		"unknown user":    {ifMatch: `"4"`, version: 4, err: domain.ErrIDNotFound, status: http.StatusNotFound},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			// Arrange
+			userUsecaseMock := new(mocks.UserUsecaseMock)
+			userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+			userID := uuid.New()
+			if tt.version != 0 {
+				var updated *domain.User
+				if tt.err == nil {
+					updated = &domain.User{ID: userID, Version: tt.version + 1}
+				}
+				userUsecaseMock.On("UpdateUser", domain.DefaultTenant, userID, mock.Anything, tt.version).Return(updated, tt.err)
+			}
+
+			router := gin.Default()
+			router.PUT("/users/:id", userHandler.UpdateUser)
+
+			// Act
+			body, _ := json.Marshal(dto.UserDTO{Name: "Ana", Phone: "+5548999990000", Document: "52998224725"})
+			req, _ := http.NewRequest(http.MethodPut, fmt.Sprintf("/users/%s", userID), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+			}
+			userUsecaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeleteUser_StaleVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	userID := uuid.New()
+	userUsecaseMock.On("DeleteUser", domain.DefaultTenant, userID, int64(2)).Return(domain.ErrVersionMismatch)
+
+	router := gin.Default()
+	router.DELETE("/users/:id", userHandler.DeleteUser)
+
+	// Act
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%s", userID), nil)
+	req.Header.Set("If-Match", `"2"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func TestPatchUser(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		patchType   string
+		user        *domain.User
+		err         error
+		status      int
+	}{
+		"merge patch":       {contentType: "application/merge-patch+json", patchType: domain.MergePatchType, user: &domain.User{Name: "Ana"}, status: http.StatusOK},
+		"plain json":        {contentType: "application/json; charset=utf-8", patchType: domain.MergePatchType, user: &domain.User{Name: "Ana"}, status: http.StatusOK},
+		"json patch":        {contentType: "application/json-patch+json", patchType: domain.JSONPatchType, user: &domain.User{Name: "Ana"}, status: http.StatusOK},
+		"unsupported":       {contentType: "text/plain", patchType: "text/plain", err: domain.ErrUnsupportedPatchType, status: http.StatusUnsupportedMediaType},
+		"failed test":       {contentType: "application/json-patch+json", patchType: domain.JSONPatchType, err: domain.ErrPatchTestFailed, status: http.StatusConflict},
+		"invalid patch":     {contentType: "application/json-patch+json", patchType: domain.JSONPatchType, err: domain.ErrInvalidPatch, status: http.StatusBadRequest},
+		"invalid field":     {contentType: "application/merge-patch+json", patchType: domain.MergePatchType, err: &domain.FieldError{Field: "phone", Err: domain.ErrInvalidPhone}, status: http.StatusBadRequest},
+		"unknown user":      {contentType: "application/merge-patch+json", patchType: domain.MergePatchType, err: domain.ErrIDNotFound, status: http.StatusNotFound},
+		"repository failed": {contentType: "application/merge-patch+json", patchType: domain.MergePatchType, err: errors.New("db down"), status: http.StatusInternalServerError},
+		"stale version":     {contentType: "application/merge-patch+json", patchType: domain.MergePatchType, err: domain.ErrVersionMismatch, status: http.StatusPreconditionFailed},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			// Arrange
+			userUsecaseMock := new(mocks.UserUsecaseMock)
+			userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+			userID := uuid.New()
+			patch := []byte(`{"name":"Ana"}`)
+			userUsecaseMock.On("PatchUser", domain.DefaultTenant, userID, tt.patchType, patch, int64(0)).Return(tt.user, tt.err)
+
+			router := gin.Default()
+			router.PATCH("/users/:id", userHandler.PatchUser)
+
+			// Act
+			req, _ := http.NewRequest(http.MethodPatch, fmt.Sprintf("/users/%s", userID), bytes.NewBuffer(patch))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusUnsupportedMediaType {
+				assert.Contains(t, w.Header().Get("Accept-Patch"), domain.JSONPatchType)
+			}
+			userUsecaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestGetDeletedUsers_OnlyListsDeleted(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	userUsecaseMock.On("GetUsers", mock.MatchedBy(func(filter domain.UserFilter) bool {
+		return filter.Deleted == domain.DeletedOnly && filter.NamePrefix == "an"
+	})).Return(&domain.UserPage{}, nil)
+
+	router := gin.Default()
+	router.GET("/users/deleted", userHandler.GetDeletedUsers)
+
+	// Act
+	req, _ := http.NewRequest(http.MethodGet, "/users/deleted?name=an&deleted=exclude", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func TestUserByID_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	router := gin.New()
+	router.DELETE("/users/:id", userHandler.DeleteUser)
+	router.PUT("/users/:id", userHandler.UpdateUser)
+	router.PATCH("/users/:id", userHandler.PatchUser)
+
+	for _, method := range []string{http.MethodDelete, http.MethodPut, http.MethodPatch} {
+		// Act
+		req, _ := http.NewRequest(method, "/users/not-a-uuid", bytes.NewBufferString(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		// Assert
+		assert.Equal(t, http.StatusBadRequest, w.Code, method)
+		assert.JSONEq(t, `{"error": "invalid user id"}`, w.Body.String(), method)
+	}
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func TestRestoreUser(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		status int
+	}{
+		"restored":     {status: http.StatusOK},
+		"not deleted":  {err: domain.ErrIDNotFound, status: http.StatusNotFound},
+		"taken again":  {err: domain.ErrUserConflict, status: http.StatusConflict},
+		"anonymized":   {err: domain.ErrUserAnonymized, status: http.StatusConflict},
+		"server error": {err: errors.New("db down"), status: http.StatusInternalServerError},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			// Arrange
+			userUsecaseMock := new(mocks.UserUsecaseMock)
+			userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+			userID := uuid.New()
+			var user *domain.User
+			if tt.err == nil {
+				user = &domain.User{ID: userID}
+			}
+			userUsecaseMock.On("RestoreUser", domain.DefaultTenant, userID).Return(user, tt.err)
+
+			router := gin.Default()
+			router.POST("/users/:id/restore", userHandler.RestoreUser)
+
+			// Act
+			req, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("/users/%s/restore", userID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			userUsecaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeUser(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		status int
+	}{
+		"purged":       {status: http.StatusNoContent},
+		"unknown":      {err: domain.ErrIDNotFound, status: http.StatusNotFound},
+		"still active": {err: domain.ErrUserNotDeleted, status: http.StatusConflict},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+
+			// Arrange
+			userUsecaseMock := new(mocks.UserUsecaseMock)
+			userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+			userID := uuid.New()
+			userUsecaseMock.On("PurgeUser", domain.DefaultTenant, userID).Return(tt.err)
+
+			router := gin.Default()
+			router.DELETE("/users/:id/purge", userHandler.PurgeUser)
+
+			// Act
+			req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("/users/%s/purge", userID), nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			// Assert
+			assert.Equal(t, tt.status, w.Code)
+			userUsecaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	before := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	userUsecaseMock.On("PurgeDeletedUsers", domain.DefaultTenant, before).Return(int64(3), nil)
+
+	router := gin.Default()
+	router.DELETE("/users/deleted", userHandler.PurgeDeletedUsers)
+
+	// Act
+	req, _ := http.NewRequest(http.MethodDelete, "/users/deleted?deleted_before=2024-01-01", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	missing, _ := http.NewRequest(http.MethodDelete, "/users/deleted", nil)
+	wMissing := httptest.NewRecorder()
+	router.ServeHTTP(wMissing, missing)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"purged": 3}`, w.Body.String())
+	assert.Equal(t, http.StatusBadRequest, wMissing.Code, "deleted_before is required")
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func newExportRouter(userUsecaseMock *mocks.UserUsecaseMock) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/export", handler.NewUserHandler(userUsecaseMock, zap.NewNop()).ExportUsers)
+	return router
+}
+
+func TestExportUsers_CSV(t *testing.T) {
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	users := []*domain.User{
+		{Name: "Ana, Maria", Phone: "+5548999990000"},
+		{Name: "=HYPERLINK(\"x\")", Phone: "+5548999990001"},
+		{Name: "+1+1", Phone: "+5548999990002"},
+		{Name: "-2", Phone: "+5548999990003"},
+		{Name: "\tcmd", Phone: "+5548999990004"},
+	}
+	filter := domain.UserFilter{TenantID: domain.DefaultTenant, NamePrefix: "a", Deleted: domain.DeletedExclude, Sort: domain.UserSortName}
+	userUsecaseMock.On("ExportUsers", filter).Return(users, nil)
+
+	// Act
+	req, _ := http.NewRequest(http.MethodGet, "/users/export?name=a&sort=name&columns=name,phone,deleted_at", nil)
+	w := httptest.NewRecorder()
+	newExportRouter(userUsecaseMock).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".csv")
+	assert.Equal(t, "name,phone,deleted_at\n"+
+		"\"Ana, Maria\",+5548999990000,\n"+
+		"\"'=HYPERLINK(\"\"x\"\")\",+5548999990001,\n"+
+		"'+1+1,+5548999990002,\n"+
+		"'-2,+5548999990003,\n"+
+		"'\tcmd,+5548999990004,\n", w.Body.String())
+	userUsecaseMock.AssertExpectations(t)
+}
+
+func TestExportUsers_NDJSON(t *testing.T) {
+	tests := map[string]struct {
+		query  string
+		accept string
+	}{
+		"accept header":    {query: "", accept: "application/x-ndjson"},
+		"ndjson alias":     {query: "", accept: "application/ndjson"},
+		"format parameter": {query: "&format=ndjson", accept: "text/csv"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			userUsecaseMock := new(mocks.UserUsecaseMock)
+			users := []*domain.User{{Name: "Ana", Version: 2}, {Name: "Bia", Version: 1}}
+			userUsecaseMock.On("ExportUsers", mock.Anything).Return(users, nil)
+
+			req, _ := http.NewRequest(http.MethodGet, "/users/export?columns=version,name,anonymized_at"+tt.query, nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			newExportRouter(userUsecaseMock).ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "application/x-ndjson; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, `{"version":2,"name":"Ana","anonymized_at":null}`+"\n"+
+				`{"version":1,"name":"Bia","anonymized_at":null}`+"\n", w.Body.String())
+		})
+	}
+}
+
+func TestExportUsers_RejectsRequest(t *testing.T) {
+	tests := map[string]struct {
+		query  string
+		accept string
+		status int
+	}{
+		"unknown column":   {query: "columns=name,password_hash", status: http.StatusBadRequest},
+		"unknown format":   {query: "format=xlsx", status: http.StatusBadRequest},
+		"invalid filter":   {query: "sort=phone", status: http.StatusBadRequest},
+		"not acceptable":   {accept: "application/json", status: http.StatusNotAcceptable},
+		"wildcard is fine": {accept: "*/*", status: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			userUsecaseMock := new(mocks.UserUsecaseMock)
+			userUsecaseMock.On("ExportUsers", mock.Anything).Return(nil, nil).Maybe()
+
+			req, _ := http.NewRequest(http.MethodGet, "/users/export?"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			newExportRouter(userUsecaseMock).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status != http.StatusOK {
+				userUsecaseMock.AssertNotCalled(t, "ExportUsers", mock.Anything)
+			}
+		})
+	}
+}
+
+func TestExportUsers_FailsBeforeFirstRow(t *testing.T) {
+	// Arrange
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userUsecaseMock.On("ExportUsers", mock.Anything).Return(nil, errors.New("connection refused"))
+
+	// Act
+	req, _ := http.NewRequest(http.MethodGet, "/users/export", nil)
+	w := httptest.NewRecorder()
+	newExportRouter(userUsecaseMock).ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/json")
+	assert.Empty(t, w.Header().Get("Content-Disposition"))
+	assert.Contains(t, w.Body.String(), "connection refused")
+}
+
+func TestGetUserByDocument_AmbiguousPassport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	userUsecaseMock := new(mocks.UserUsecaseMock)
+	userUsecaseMock.On("GetUserByDocument", domain.DefaultTenant, domain.DocumentPassport, "", "AB123456").
+		Return((*domain.User)(nil), domain.ErrAmbiguousDocument)
+	userHandler := handler.NewUserHandler(userUsecaseMock, zap.NewNop())
+	router := gin.New()
+	router.GET("/users/:document", userHandler.GetUserByDocument)
+
+	req, _ := http.NewRequest(http.MethodGet, "/users/AB123456?type=passport", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
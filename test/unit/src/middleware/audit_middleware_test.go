@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestAuditImpersonation_FlagsAndRecordsImpersonatedRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.LoadKeySet(testConfig)
+	require.NoError(t, err)
+
+	guestID, adminID := uuid.New(), uuid.New()
+	impersonation, err := auth.GenerateJWT(auth.Claims{UserID: guestID, Role: domain.RoleGuest, ActorID: adminID}, keys, testConfig)
+	require.NoError(t, err)
+	regular, err := auth.GenerateJWT(auth.Claims{UserID: guestID, Role: domain.RoleGuest}, keys, testConfig)
+	require.NoError(t, err)
+
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+
+	authUsecase := usecases.NewAuthUsecase(new(mocks.UserRepositoryMock), mocks.NewFakeRedisRepository(), keys, testConfig)
+	router := gin.New()
+	router.Use(middleware.AuditImpersonation(usecases.NewAuditUsecase(auditRepoMock), zap.NewNop()))
+	group := router.Group("/me", middleware.JWTAuthMiddleware(authUsecase))
+	group.GET("", func(c *gin.Context) { c.Status(http.StatusOK) })
+	group.DELETE("", middleware.DenyImpersonation(), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	call := func(method, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	regularResp := call(http.MethodGet, regular)
+	assert.Equal(t, http.StatusOK, regularResp.Code)
+	assert.Empty(t, regularResp.Header().Get(middleware.ImpersonatedByHeader))
+	auditRepoMock.AssertNotCalled(t, "CreateAuditEntry", mock.Anything)
+
+	readResp := call(http.MethodGet, impersonation)
+	assert.Equal(t, http.StatusOK, readResp.Code)
+	assert.Equal(t, adminID.String(), readResp.Header().Get(middleware.ImpersonatedByHeader))
+
+	deleteResp := call(http.MethodDelete, impersonation)
+	assert.Equal(t, http.StatusForbidden, deleteResp.Code)
+
+	auditRepoMock.AssertNumberOfCalls(t, "CreateAuditEntry", 2)
+	auditRepoMock.AssertCalled(t, "CreateAuditEntry", mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == domain.AuditImpersonatedRequest && entry.ActorID == adminID && entry.UserID == guestID &&
+			entry.Method == http.MethodDelete && entry.Path == "/me" && entry.Status == http.StatusForbidden
+	}))
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_AcceptsScopedAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.LoadKeySet(testConfig)
+	require.NoError(t, err)
+
+	plain, prefix, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+	apiKey := &domain.APIKey{ID: uuid.New(), Prefix: prefix, SecretHash: auth.HashToken(plain), Scopes: []domain.Permission{domain.PermUsersRead}}
+	apiKeyRepoMock := new(mocks.APIKeyRepositoryMock)
+	apiKeyRepoMock.On("GetAPIKeyByPrefix", prefix).Return(apiKey, nil)
+	apiKeyRepoMock.On("GetAPIKeyByPrefix", mock.Anything).Return(nil, domain.ErrAPIKeyNotFound)
+	apiKeyRepoMock.On("TouchAPIKey", apiKey.ID, mock.Anything).Return(nil)
+
+	authUsecase := usecases.NewAuthUsecase(new(mocks.UserRepositoryMock), mocks.NewFakeRedisRepository(), keys, testConfig)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router := gin.New()
+	group := router.Group("/users", middleware.AuthMiddleware(authUsecase, usecases.NewAPIKeyUsecase(apiKeyRepoMock)))
+	group.GET("", middleware.RequirePermission(domain.PermUsersRead), ok)
+	group.DELETE("/:id", middleware.RequirePermission(domain.PermUsersDelete), ok)
+
+	call := func(method, path, key string) int {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("X-API-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, call(http.MethodGet, "/users", plain))
+	assert.Equal(t, http.StatusForbidden, call(http.MethodDelete, "/users/"+uuid.NewString(), plain))
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/users", "pk_000000000000_nope"))
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireIfMatch(t *testing.T) {
+	tests := map[string]struct {
+		required bool
+		ifMatch  string
+		status   int
+	}{
+		"required and sent":     {required: true, ifMatch: `"1"`, status: http.StatusOK},
+		"required and missing":  {required: true, status: http.StatusPreconditionRequired},
+		"optional and missing":  {status: http.StatusOK},
+		"optional and wildcard": {ifMatch: "*", status: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.PUT("/users/:id", middleware.RequireIfMatch(tt.required), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			req := httptest.NewRequest(http.MethodPut, "/users/1", nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+		})
+	}
+}
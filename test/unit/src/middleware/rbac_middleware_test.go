@@ -0,0 +1,126 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testConfig = config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5}
+
+func newRouter(t *testing.T) (*gin.Engine, *auth.KeySet) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.LoadKeySet(testConfig)
+	require.NoError(t, err)
+
+	authUsecase := usecases.NewAuthUsecase(new(mocks.UserRepositoryMock), mocks.NewFakeRedisRepository(), keys, testConfig)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	router := gin.New()
+	group := router.Group("/users", middleware.JWTAuthMiddleware(authUsecase))
+	group.GET("", middleware.RequirePermission(domain.PermUsersRead), ok)
+	group.PUT("/:id", middleware.RequirePermissionOrSelf("id", domain.PermUsersWrite), ok)
+	group.DELETE("/:id", middleware.RequireRole(domain.RoleAdmin), ok)
+
+	return router, keys
+}
+
+func request(t *testing.T, router *gin.Engine, keys *auth.KeySet, claims auth.Claims, method, path string) int {
+	token, err := auth.GenerateJWT(claims, keys, testConfig)
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestRBAC_GuestOnlyActsOnSelf(t *testing.T) {
+	router, keys := newRouter(t)
+	guest := auth.Claims{UserID: uuid.New(), Role: domain.RoleGuest}
+
+	assert.Equal(t, http.StatusForbidden, request(t, router, keys, guest, http.MethodGet, "/users"))
+	assert.Equal(t, http.StatusOK, request(t, router, keys, guest, http.MethodPut, "/users/"+guest.UserID.String()))
+	assert.Equal(t, http.StatusForbidden, request(t, router, keys, guest, http.MethodPut, "/users/"+uuid.NewString()))
+	assert.Equal(t, http.StatusForbidden, request(t, router, keys, guest, http.MethodDelete, "/users/"+guest.UserID.String()))
+}
+
+func TestRBAC_StaffAndAdmin(t *testing.T) {
+	router, keys := newRouter(t)
+	staff := auth.Claims{UserID: uuid.New(), Role: domain.RoleStaff}
+	admin := auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin}
+
+	assert.Equal(t, http.StatusOK, request(t, router, keys, staff, http.MethodGet, "/users"))
+	assert.Equal(t, http.StatusOK, request(t, router, keys, staff, http.MethodPut, "/users/"+uuid.NewString()))
+	assert.Equal(t, http.StatusForbidden, request(t, router, keys, staff, http.MethodDelete, "/users/"+uuid.NewString()))
+	assert.Equal(t, http.StatusOK, request(t, router, keys, admin, http.MethodDelete, "/users/"+uuid.NewString()))
+}
+
+func TestRequireTargetRank(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.LoadKeySet(testConfig)
+	require.NoError(t, err)
+	authUsecase := usecases.NewAuthUsecase(new(mocks.UserRepositoryMock), mocks.NewFakeRedisRepository(), keys, testConfig)
+
+	users := map[uuid.UUID]*domain.User{}
+	for _, role := range []domain.Role{domain.RoleGuest, domain.RoleStaff, domain.RoleAdmin} {
+		user := &domain.User{ID: uuid.New(), Role: role}
+		users[user.ID] = user
+	}
+	idOf := func(role domain.Role) string {
+		for id, user := range users {
+			if user.Role == role {
+				return id.String()
+			}
+		}
+		return ""
+	}
+	loadUser := func(_ string, id uuid.UUID) (*domain.User, error) {
+		if user, ok := users[id]; ok {
+			return user, nil
+		}
+		return nil, domain.ErrIDNotFound
+	}
+
+	router := gin.New()
+	router.PUT("/users/:id", middleware.JWTAuthMiddleware(authUsecase), middleware.RequireTargetRank("id", loadUser),
+		func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	tests := map[string]struct {
+		caller domain.Role
+		target string
+		status int
+	}{
+		"staff on guest":   {caller: domain.RoleStaff, target: idOf(domain.RoleGuest), status: http.StatusOK},
+		"staff on staff":   {caller: domain.RoleStaff, target: idOf(domain.RoleStaff), status: http.StatusOK},
+		"staff on admin":   {caller: domain.RoleStaff, target: idOf(domain.RoleAdmin), status: http.StatusForbidden},
+		"guest on staff":   {caller: domain.RoleGuest, target: idOf(domain.RoleStaff), status: http.StatusForbidden},
+		"admin on admin":   {caller: domain.RoleAdmin, target: idOf(domain.RoleAdmin), status: http.StatusOK},
+		"unknown target":   {caller: domain.RoleStaff, target: uuid.NewString(), status: http.StatusOK},
+		"malformed target": {caller: domain.RoleStaff, target: "not-a-uuid", status: http.StatusOK},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			caller := auth.Claims{UserID: uuid.New(), Role: tt.caller}
+
+			assert.Equal(t, tt.status, request(t, router, keys, caller, http.MethodPut, "/users/"+tt.target))
+		})
+	}
+
+	self := auth.Claims{UserID: uuid.MustParse(idOf(domain.RoleAdmin)), Role: domain.RoleGuest}
+	assert.Equal(t, http.StatusOK, request(t, router, keys, self, http.MethodPut, "/users/"+self.UserID.String()), "callers always reach themselves")
+}
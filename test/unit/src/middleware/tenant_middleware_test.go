@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/middleware"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantMiddleware_ResolvesTenantFromHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.TenantMiddleware(config.Config{Tenants: "default, pousada-sul"}))
+	router.GET("/", func(c *gin.Context) { c.String(http.StatusOK, middleware.GetTenant(c)) })
+
+	call := func(tenantID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if tenantID != "" {
+			req.Header.Set(middleware.TenantHeader, tenantID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, domain.DefaultTenant, w.Body.String())
+
+	w = call("pousada-sul")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pousada-sul", w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, call("pousada-norte").Code)
+}
+
+func TestAuthMiddleware_RejectsTokenFromAnotherTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keys, err := auth.LoadKeySet(testConfig)
+	require.NoError(t, err)
+
+	token, err := auth.GenerateJWT(auth.Claims{UserID: uuid.New(), Role: domain.RoleGuest, TenantID: "pousada-sul"}, keys, testConfig)
+	require.NoError(t, err)
+
+	authUsecase := usecases.NewAuthUsecase(new(mocks.UserRepositoryMock), mocks.NewFakeRedisRepository(), keys, testConfig)
+	router := gin.New()
+	router.Use(middleware.TenantMiddleware(config.Config{Tenants: "default,pousada-sul,pousada-norte"}))
+	router.GET("/", middleware.AuthMiddleware(authUsecase, nil), func(c *gin.Context) { c.String(http.StatusOK, middleware.GetTenant(c)) })
+
+	call := func(tenantID string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if tenantID != "" {
+			req.Header.Set(middleware.TenantHeader, tenantID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "pousada-sul", w.Body.String())
+
+	assert.Equal(t, http.StatusForbidden, call("pousada-norte").Code)
+}
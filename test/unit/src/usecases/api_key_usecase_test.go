@@ -0,0 +1,105 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func createAPIKey(t *testing.T, repoMock *mocks.APIKeyRepositoryMock, scopes ...string) (*dto.CreatedAPIKeyDTO, *domain.APIKey) {
+	var stored *domain.APIKey
+	repoMock.On("CreateAPIKey", mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(0).(*domain.APIKey)
+		stored.ID = uuid.New()
+	}).Return(nil).Once()
+
+	admin := &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: testTenant}
+	created, err := usecases.NewAPIKeyUsecase(repoMock).CreateAPIKey(admin, &dto.CreateAPIKeyDTO{Name: "channel sync", Scopes: scopes})
+	require.NoError(t, err)
+
+	return created, stored
+}
+
+func TestCreateAPIKey_StoresOnlyHash(t *testing.T) {
+	// Arrange
+	repoMock := new(mocks.APIKeyRepositoryMock)
+
+	// Act
+	created, stored := createAPIKey(t, repoMock, string(domain.PermUsersRead))
+
+	// Assert
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, stored.Prefix, created.Prefix)
+	assert.Equal(t, auth.HashToken(created.Key), stored.SecretHash)
+	assert.NotContains(t, stored.SecretHash, created.Key)
+	assert.Equal(t, []domain.Permission{domain.PermUsersRead}, stored.Scopes)
+}
+
+func TestCreateAPIKey_RejectsScopesTheCreatorLacks(t *testing.T) {
+	// Arrange
+	usecase := usecases.NewAPIKeyUsecase(new(mocks.APIKeyRepositoryMock))
+	staff := &auth.Claims{UserID: uuid.New(), Role: domain.RoleStaff, TenantID: testTenant}
+
+	// Act
+	_, deleteErr := usecase.CreateAPIKey(staff, &dto.CreateAPIKeyDTO{Name: "x", Scopes: []string{string(domain.PermUsersDelete)}})
+	_, unknownErr := usecase.CreateAPIKey(staff, &dto.CreateAPIKeyDTO{Name: "x", Scopes: []string{"everything"}})
+
+	// Assert
+	assert.ErrorIs(t, deleteErr, domain.ErrInvalidScope)
+	assert.ErrorIs(t, unknownErr, domain.ErrInvalidScope)
+}
+
+func TestAuthenticateAPIKey_ScopesAndLastUsed(t *testing.T) {
+	// Arrange
+	repoMock := new(mocks.APIKeyRepositoryMock)
+	created, stored := createAPIKey(t, repoMock, string(domain.PermUsersRead))
+	repoMock.On("GetAPIKeyByPrefix", stored.Prefix).Return(stored, nil)
+	repoMock.On("TouchAPIKey", stored.ID, mock.Anything).Return(nil).Once()
+	usecase := usecases.NewAPIKeyUsecase(repoMock)
+
+	// Act
+	claims, err := usecase.AuthenticateAPIKey(created.Key)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, claims.IsAPIKey())
+	assert.True(t, claims.Can(domain.PermUsersRead))
+	assert.False(t, claims.Can(domain.PermUsersWrite))
+	repoMock.AssertExpectations(t)
+
+	// A key used moments ago is not written again.
+	now := time.Now()
+	stored.LastUsedAt = &now
+	_, err = usecase.AuthenticateAPIKey(created.Key)
+	assert.NoError(t, err)
+	repoMock.AssertNumberOfCalls(t, "TouchAPIKey", 1)
+}
+
+func TestAuthenticateAPIKey_Rejected(t *testing.T) {
+	// Arrange
+	repoMock := new(mocks.APIKeyRepositoryMock)
+	created, stored := createAPIKey(t, repoMock, string(domain.PermUsersRead))
+	revokedAt := time.Now()
+	stored.RevokedAt = &revokedAt
+	repoMock.On("GetAPIKeyByPrefix", stored.Prefix).Return(stored, nil)
+	usecase := usecases.NewAPIKeyUsecase(repoMock)
+
+	// Act
+	_, revokedErr := usecase.AuthenticateAPIKey(created.Key)
+	_, forgedErr := usecase.AuthenticateAPIKey("pk_" + stored.Prefix + "_forged")
+	_, malformedErr := usecase.AuthenticateAPIKey("not-a-key")
+
+	// Assert
+	assert.ErrorIs(t, revokedErr, domain.ErrInvalidAPIKey)
+	assert.ErrorIs(t, forgedErr, domain.ErrInvalidAPIKey)
+	assert.ErrorIs(t, malformedErr, domain.ErrInvalidAPIKey)
+}
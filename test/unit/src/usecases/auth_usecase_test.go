@@ -0,0 +1,509 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testConfig  = config.Config{JWTSecret: "test-secret", JWTExpirationMinutes: 5, RefreshTokenExpirationHours: 1, PhoneDefaultCountry: "BR"}
+	testKeys, _ = auth.LoadKeySet(testConfig)
+	testClient  = domain.ClientInfo{IP: "127.0.0.1", UserAgent: "go-test"}
+	testTenant  = domain.DefaultTenant
+	adminClaims = &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: domain.DefaultTenant}
+)
+
+func newUserWithPassword(t *testing.T, password string) *domain.User {
+	hash, err := auth.HashPassword(password)
+	assert.NoError(t, err)
+
+	return &domain.User{
+		ID:           uuid.New(),
+		TenantID:     testTenant,
+		Name:         "John Doe",
+		Document:     "DOC1",
+		Role:         domain.RoleGuest,
+		PasswordHash: hash,
+	}
+}
+
+func TestLogin_Success(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+
+	// Act
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.RefreshToken)
+	claims, err := auth.ValidateJWT(tokens.AccessToken, testKeys)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestLogin_TokensCarryTenant(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+	user.TenantID = "pousada-sul"
+
+	userRepoMock.On("GetUserByData", "pousada-sul", "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", "pousada-sul", user.ID).Return(user, nil)
+
+	// Act
+	tokens, err := usecase.Login("pousada-sul", &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	assert.NoError(t, err)
+	refreshed, err := usecase.RefreshToken(tokens.RefreshToken)
+	assert.NoError(t, err)
+
+	// Assert
+	claims, err := usecase.AuthenticateToken(refreshed.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "pousada-sul", claims.TenantID)
+}
+
+func TestLogin_NormalizesDocument(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+	user.Document = "52998224725"
+
+	userRepoMock.On("GetUserByIdentityDocument", testTenant, domain.DocumentCPF, "", "52998224725").Return(user, nil)
+
+	// Act
+	_, err := usecase.Login(testTenant, &dto.LoginDTO{Document: " 529.982.247-25 ", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	assert.NoError(t, err)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestLogin_ForeignDocument(t *testing.T) {
+	tests := map[string]struct {
+		credentials *dto.LoginDTO
+		lookup      []interface{}
+	}{
+		"typed passport": {
+			credentials: &dto.LoginDTO{Document: "ab-123456", DocumentType: "passport", DocumentCountry: "ar", Password: "s3cret-pass"},
+			lookup:      []interface{}{"GetUserByIdentityDocument", testTenant, domain.DocumentPassport, "AR", "AB123456"},
+		},
+		"untyped rne": {
+			credentials: &dto.LoginDTO{Document: "v123456-7", Password: "s3cret-pass"},
+			lookup:      []interface{}{"GetUserByData", testTenant, "V1234567"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			userRepoMock := new(mocks.UserRepositoryMock)
+			usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+			user := newUserWithPassword(t, "s3cret-pass")
+			userRepoMock.On(tt.lookup[0].(string), tt.lookup[1:]...).Return(user, nil)
+
+			// Act
+			_, err := usecase.Login(testTenant, tt.credentials, testClient)
+
+			// Assert
+			assert.NoError(t, err)
+			userRepoMock.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLogin_InvalidDocumentType(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+
+	// Act
+	_, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "AB123456", DocumentType: "visa", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	var validationErrs validator.ValidationErrors
+	assert.ErrorAs(t, err, &validationErrs)
+}
+
+func TestLogin_InvalidPassword(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+
+	// Act
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "wrong-pass"}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+	assert.Nil(t, tokens)
+}
+
+func TestLogin_UnknownDocument(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC2").Return(nil, domain.ErrGetUserByData)
+
+	// Act
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC2", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Nil(t, tokens)
+}
+
+func TestLogin_AmbiguousDocument(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+
+	userRepoMock.On("GetUserByData", testTenant, "X1234567").Return(nil, domain.ErrAmbiguousDocument)
+
+	// Act
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "X1234567", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidCredentials)
+	assert.Nil(t, tokens)
+}
+
+func TestRefreshToken_Rotates(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	assert.NoError(t, err)
+
+	// Act
+	rotated, err := usecase.RefreshToken(login.RefreshToken)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NotEqual(t, login.RefreshToken, rotated.RefreshToken)
+	claims, err := auth.ValidateJWT(rotated.AccessToken, testKeys)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+}
+
+func TestRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	assert.NoError(t, err)
+	rotated, err := usecase.RefreshToken(login.RefreshToken)
+	assert.NoError(t, err)
+
+	// Act
+	_, reuseErr := usecase.RefreshToken(login.RefreshToken)
+	_, descendantErr := usecase.RefreshToken(rotated.RefreshToken)
+
+	// Assert
+	assert.ErrorIs(t, reuseErr, domain.ErrRefreshTokenReused)
+	assert.ErrorIs(t, descendantErr, domain.ErrInvalidRefreshToken)
+}
+
+func TestRefreshToken_Unknown(t *testing.T) {
+	// Arrange
+	usecase := usecases.NewAuthUsecase(new(mocks.UserRepositoryMock), mocks.NewFakeRedisRepository(), testKeys, testConfig)
+
+	// Act
+	tokens, err := usecase.RefreshToken("not-a-token")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	assert.Nil(t, tokens)
+}
+
+func TestChangePassword_Success(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("UpdatePassword", testTenant, user.ID, mock.MatchedBy(func(hash string) bool {
+		return auth.CheckPassword(hash, "n3w-s3cret-pass") == nil
+	})).Return(nil)
+
+	// Act
+	err := usecase.ChangePassword(testTenant, user.ID, &dto.ChangePasswordDTO{
+		CurrentPassword: "s3cret-pass",
+		NewPassword:     "n3w-s3cret-pass",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestChangePassword_WrongCurrentPassword(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+
+	// Act
+	err := usecase.ChangePassword(testTenant, user.ID, &dto.ChangePasswordDTO{
+		CurrentPassword: "wrong-pass",
+		NewPassword:     "n3w-s3cret-pass",
+	})
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+	userRepoMock.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLogout_RevokesAccessAndRefreshTokens(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	assert.NoError(t, err)
+	_, err = usecase.AuthenticateToken(login.AccessToken)
+	assert.NoError(t, err)
+
+	// Act
+	err = usecase.Logout(login.AccessToken, login.RefreshToken)
+
+	// Assert
+	assert.NoError(t, err)
+	_, err = usecase.AuthenticateToken(login.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+	_, err = usecase.RefreshToken(login.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+}
+
+func TestRevokeUserSessions(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	assert.NoError(t, err)
+
+	// Act
+	err = usecase.RevokeUserSessions(testTenant, user.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	_, err = usecase.AuthenticateToken(login.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+	_, err = usecase.RefreshToken(login.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+}
+
+func TestRevokeUserSessions_LoginRightAfter(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	require.NoError(t, usecase.RevokeUserSessions(testTenant, user.ID))
+
+	// Act
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = usecase.AuthenticateToken(login.AccessToken)
+	assert.NoError(t, err, "a token issued in the same second as the revocation is valid")
+	_, err = usecase.RefreshToken(login.RefreshToken)
+	assert.NoError(t, err)
+}
+
+func TestRevokeUserSessions_LegacyCutoffInSeconds(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	usecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, redisRepo.Set("revoked_before:"+user.ID.String(), time.Now().Unix(), time.Hour))
+
+	// Assert
+	_, err = usecase.AuthenticateToken(login.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+	_, err = usecase.RefreshToken(login.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+}
+
+func TestUpdateRole_RevokesSessions(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("UpdateRole", testTenant, user.ID, domain.RoleStaff).Return(nil)
+	login, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	assert.NoError(t, err)
+	claims, err := usecase.AuthenticateToken(login.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleGuest, claims.Role)
+
+	// Act
+	err = usecase.UpdateRole(adminClaims, testTenant, user.ID, &dto.UpdateRoleDTO{Role: "staff"})
+
+	// Assert
+	assert.NoError(t, err)
+	_, err = usecase.AuthenticateToken(login.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestUpdateRole_Invalid(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+
+	// Act
+	err := usecase.UpdateRole(adminClaims, testTenant, uuid.New(), &dto.UpdateRoleDTO{Role: "owner"})
+
+	// Assert
+	assert.Error(t, err)
+	userRepoMock.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateRole_RefusesGrantingAboveCaller(t *testing.T) {
+	tests := map[string]*auth.Claims{
+		"api key": {APIKeyID: uuid.New(), TenantID: testTenant, Scopes: []domain.Permission{domain.PermRolesManage}},
+		"staff":   {UserID: uuid.New(), Role: domain.RoleStaff, TenantID: testTenant},
+	}
+
+	for name, caller := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			userRepoMock := new(mocks.UserRepositoryMock)
+			usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+			user := newUserWithPassword(t, "s3cret-pass")
+			userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil).Maybe()
+
+			// Act
+			err := usecase.UpdateRole(caller, testTenant, user.ID, &dto.UpdateRoleDTO{Role: "admin"})
+
+			// Assert
+			assert.ErrorIs(t, err, domain.ErrTargetOutranks)
+			userRepoMock.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestLogin_LocksAccountAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	cfg := testConfig
+	cfg.LoginMaxAttempts = 3
+	cfg.LoginAttemptWindow = time.Minute
+	cfg.LoginLockoutDuration = time.Minute
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, cfg)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	for i := 0; i < 3; i++ {
+		_, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "wrong-pass"}, testClient)
+		assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+	}
+
+	// Act
+	_, lockedErr := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+	unlockErr := usecase.UnlockUser(testTenant, user.ID)
+	_, afterUnlockErr := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, lockedErr, domain.ErrAccountLocked)
+	var retryErr *domain.RetryError
+	assert.ErrorAs(t, lockedErr, &retryErr)
+	assert.Greater(t, retryErr.RetryAfter, time.Duration(0))
+	assert.NoError(t, unlockErr)
+	assert.NoError(t, afterUnlockErr)
+}
+
+func TestLogin_ProgressiveDelay(t *testing.T) {
+	// Arrange
+	cfg := testConfig
+	cfg.LoginDelayBase = time.Minute
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, cfg)
+	user := newUserWithPassword(t, "s3cret-pass")
+
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	_, err := usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "wrong-pass"}, testClient)
+	assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+
+	// Act
+	_, err = usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrTooManyAttempts)
+}
+
+func TestLogin_ThrottlesByIP(t *testing.T) {
+	// Arrange
+	cfg := testConfig
+	cfg.LoginMaxAttemptsPerIP = 2
+	cfg.LoginAttemptWindow = time.Minute
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, cfg)
+
+	userRepoMock.On("GetUserByData", testTenant, mock.Anything).Return(nil, domain.ErrGetUserByData)
+	_, _ = usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC1", Password: "guess"}, domain.ClientInfo{IP: "10.0.0.1"})
+	_, _ = usecase.Login(testTenant, &dto.LoginDTO{Document: "DOC2", Password: "guess"}, domain.ClientInfo{IP: "10.0.0.1"})
+
+	// Act
+	_, blockedErr := usecase.Login(testTenant, &dto.LoginDTO{Document: "doc3", Password: "guess"}, domain.ClientInfo{IP: "10.0.0.1"})
+	_, otherIPErr := usecase.Login(testTenant, &dto.LoginDTO{Document: "doc3", Password: "guess"}, domain.ClientInfo{IP: "10.0.0.2"})
+
+	// Assert
+	assert.ErrorIs(t, blockedErr, domain.ErrTooManyAttempts)
+	assert.ErrorIs(t, otherIPErr, domain.ErrInvalidCredentials)
+}
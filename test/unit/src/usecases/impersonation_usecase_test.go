@@ -0,0 +1,118 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImpersonate_IssuesTokenForTargetWithActor(t *testing.T) {
+	// Arrange
+	guest := newUserWithPassword(t, "s3cret-pass")
+	admin := &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: testTenant}
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	usecase := usecases.NewImpersonationUsecase(userRepoMock, authUsecase, usecases.NewAuditUsecase(auditRepoMock), testConfig)
+
+	// Act
+	token, err := usecase.Impersonate(admin, guest.ID, &dto.ImpersonateDTO{Reason: "ticket 42"}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	claims, err := authUsecase.AuthenticateToken(token.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, guest.ID, claims.UserID)
+	assert.Equal(t, domain.RoleGuest, claims.Role)
+	assert.Equal(t, admin.UserID, claims.ActorID)
+	assert.True(t, claims.IsImpersonation())
+
+	auditRepoMock.AssertCalled(t, "CreateAuditEntry", mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == domain.AuditImpersonationStart && entry.ActorID == admin.UserID &&
+			entry.UserID == guest.ID && entry.Detail == "ticket 42" && entry.IP == testClient.IP
+	}))
+}
+
+func TestImpersonate_RevokingActorSessionsEndsImpersonation(t *testing.T) {
+	// Arrange
+	guest := newUserWithPassword(t, "s3cret-pass")
+	admin := &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: testTenant}
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	usecase := usecases.NewImpersonationUsecase(userRepoMock, authUsecase, usecases.NewAuditUsecase(auditRepoMock), testConfig)
+	userRepoMock.On("GetUserByID", testTenant, admin.UserID).Return(&domain.User{ID: admin.UserID, Role: domain.RoleAdmin}, nil)
+	token, err := usecase.Impersonate(admin, guest.ID, &dto.ImpersonateDTO{Reason: "ticket 42"}, testClient)
+	require.NoError(t, err)
+
+	// Act
+	err = authUsecase.RevokeUserSessions(testTenant, admin.UserID)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = authUsecase.AuthenticateToken(token.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+}
+
+func TestImpersonate_Forbidden(t *testing.T) {
+	guest := newUserWithPassword(t, "s3cret-pass")
+	admin := &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: testTenant}
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	usecase := usecases.NewImpersonationUsecase(userRepoMock, authUsecase, usecases.NewAuditUsecase(auditRepoMock), testConfig)
+	otherAdmin := &domain.User{ID: uuid.New(), Role: domain.RoleAdmin}
+	userRepoMock.On("GetUserByID", testTenant, otherAdmin.ID).Return(otherAdmin, nil)
+	input := &dto.ImpersonateDTO{Reason: "ticket 42"}
+
+	cases := map[string]struct {
+		actor  *auth.Claims
+		target uuid.UUID
+	}{
+		"admin target":  {actor: admin, target: otherAdmin.ID},
+		"self":          {actor: admin, target: admin.UserID},
+		"nested":        {actor: &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: testTenant, ActorID: uuid.New()}, target: guest.ID},
+		"api key actor": {actor: &auth.Claims{APIKeyID: uuid.New(), Scopes: []domain.Permission{domain.PermImpersonate}}, target: guest.ID},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := usecase.Impersonate(tc.actor, tc.target, input, testClient)
+			assert.ErrorIs(t, err, domain.ErrImpersonationForbidden)
+		})
+	}
+	auditRepoMock.AssertNotCalled(t, "CreateAuditEntry", mock.Anything)
+}
+
+func TestImpersonate_RequiresReason(t *testing.T) {
+	// Arrange
+	guest := newUserWithPassword(t, "s3cret-pass")
+	admin := &auth.Claims{UserID: uuid.New(), Role: domain.RoleAdmin, TenantID: testTenant}
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+	usecase := usecases.NewImpersonationUsecase(userRepoMock, authUsecase, usecases.NewAuditUsecase(auditRepoMock), testConfig)
+
+	// Act
+	_, err := usecase.Impersonate(admin, guest.ID, &dto.ImpersonateDTO{}, testClient)
+
+	// Assert
+	assert.Error(t, err)
+	auditRepoMock.AssertNotCalled(t, "CreateAuditEntry", mock.Anything)
+}
@@ -0,0 +1,256 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func usersCount(n int) interface{} {
+	return mock.MatchedBy(func(users []*domain.User) bool { return len(users) == n })
+}
+
+func TestImport_CSVReportsEveryRow(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	usecase := usecases.NewImportUsecase(userRepoMock, redisRepo, testConfig)
+	userRepoMock.On("UpsertUsers", testTenant, mock.MatchedBy(func(users []*domain.User) bool {
+		return len(users) == 2 && users[0].Document == "52998224725" && users[0].Role == domain.RoleGuest &&
+			users[0].PasswordHash != "" && users[1].Phone == "+5548999990001" && users[1].PasswordHash != ""
+	}), false).Return([]domain.UpsertResult{{Created: true}, {}}, nil)
+	cacheKey := fmt.Sprintf("tenant:%s:user:cpf:11144477735", testTenant)
+	require.NoError(t, redisRepo.Set(cacheKey, "{}", time.Minute))
+	file := "\ufeffNome;Phone;Document;Password\n" +
+		"Ana;(48) 99999-0000;529.982.247-25;s3cret-pass\n" +
+		"Bia;(48) 99999-0001;111.444.777-35;s3cret-pass\n" +
+		"Caio;123;123.456.789-09;s3cret-pass\n" +
+		"Duda;(48) 99999-0003;123.456.789-09;\n"
+
+	// Act
+	_, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), false)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidImport, "nome is not a column")
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(strings.Replace(file, "Nome", "Name", 1)), false)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, 2, report.Rejected)
+	require.Len(t, report.Rows, 4)
+	assert.Equal(t, 2, report.Rows[0].Line)
+	assert.Equal(t, domain.ImportCreated, report.Rows[0].Action)
+	assert.Equal(t, domain.ImportUpdated, report.Rows[1].Action)
+	assert.Equal(t, domain.ImportRejected, report.Rows[2].Action)
+	assert.Contains(t, report.Rows[2].Errors, "phone")
+	assert.Equal(t, "failed on required", report.Rows[3].Errors["password"], "the password is required as in CreateUser")
+	cached, _ := redisRepo.Get(cacheKey)
+	assert.Empty(t, cached, "the cached updated user is evicted")
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestImport_NDJSONRejectsBadRows(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testConfig)
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(2), true).
+		Return([]domain.UpsertResult{{Created: true}, {Err: domain.ErrUserConflict}}, nil)
+	file := `{"name":"Ana","phone":"+5548999990000","document":"52998224725","password":"s3cret-pass"}
+
+{"name":"Bia","phone":"+5548999990001","document":"52998224725","password":"s3cret-pass"}
+{"phone":"+5548999990002","document":"11144477735","password":"s3cret-pass"}
+{"name":"Caio","phone":"+5548999990003","document":"11144477735","password":"s3cret-pass","role":"admin"}
+{"name":"Duda","phone":"+5548999990004","document":"AB123456","document_type":"passport","document_country":"AR","password":"s3cret-pass"}
+{"name":
+`
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.NDJSONType, strings.NewReader(file), true)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, report.DryRun)
+	require.Len(t, report.Rows, 6)
+	lines := make([]int, len(report.Rows))
+	for i, row := range report.Rows {
+		lines[i] = row.Line
+	}
+	assert.Equal(t, []int{1, 3, 4, 5, 6, 7}, lines)
+	assert.Equal(t, domain.ImportCreated, report.Rows[0].Action)
+	assert.Contains(t, report.Rows[1].Errors["row"], "line 1", "same document as line 1")
+	assert.Equal(t, "failed on required", report.Rows[2].Errors["name"])
+	assert.Contains(t, report.Rows[3].Errors["row"], "role")
+	assert.Equal(t, domain.ErrUserConflict.Error(), report.Rows[4].Errors["row"])
+	assert.Contains(t, report.Rows[5].Errors, "row")
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 5, report.Rejected)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestImport_RejectsRowsMatchingStaff(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testConfig)
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(1), false).
+		Return([]domain.UpsertResult{{Err: domain.ErrImportNonGuest}}, nil)
+	file := "name,phone,document,password\n" +
+		"Ana,+5548999990000,52998224725,s3cret-pass\n"
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), false)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 1)
+	assert.Equal(t, domain.ImportRejected, report.Rows[0].Action)
+	assert.Equal(t, domain.ErrImportNonGuest.Error(), report.Rows[0].Errors["row"])
+	assert.Equal(t, 0, report.Updated)
+}
+
+func TestImport_KeepsReportWhenEvictionFails(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepoMock := new(mocks.RedisRepositoryMock)
+	redisRepoMock.On("Delete", mock.Anything).Return(errors.New("redis down"))
+	usecase := usecases.NewImportUsecase(userRepoMock, redisRepoMock, testConfig)
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(1), false).
+		Return([]domain.UpsertResult{{}}, nil)
+	file := "name,phone,document,password\n" +
+		"Ana,+5548999990000,52998224725,s3cret-pass\n"
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), false)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Updated)
+	redisRepoMock.AssertExpectations(t)
+}
+
+func TestImport_SavesInBatches(t *testing.T) {
+	// Arrange
+	cfg := testConfig
+	cfg.ImportBatchSize = 2
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), cfg)
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(2), false).
+		Return([]domain.UpsertResult{{Created: true}, {Created: true}}, nil).Once()
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(1), false).
+		Return([]domain.UpsertResult{{Created: true}}, nil).Once()
+	file := "name,phone,document,password\n" +
+		"Ana,+5548999990000,52998224725,s3cret-pass\n" +
+		"Bia,+5548999990001,11144477735,s3cret-pass\n" +
+		"Caio,+5548999990002,12345678909,s3cret-pass\n"
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), false)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, report.Created)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestImport_ReportsRowsBeforeUnreadableOne(t *testing.T) {
+	// Arrange
+	cfg := testConfig
+	cfg.ImportBatchSize = 1
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), cfg)
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(1), false).
+		Return([]domain.UpsertResult{{Created: true}}, nil).Twice()
+	file := "name,phone,document,password\n" +
+		"Ana,+5548999990000,52998224725,s3cret-pass\n" +
+		"Bia,+5548999990001,11144477735,s3cret-pass\n" +
+		"\"Caio,+5548999990002,12345678909,s3cret-pass\n"
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), false)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidImport)
+	require.NotNil(t, report, "the saved rows are still reported")
+	assert.Equal(t, 2, report.Created)
+	require.Len(t, report.Rows, 2)
+	assert.Equal(t, 3, report.Rows[1].Line)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestImport_DryRunSkipsHashing(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testConfig)
+	userRepoMock.On("UpsertUsers", testTenant, mock.MatchedBy(func(users []*domain.User) bool {
+		return len(users) == 1 && users[0].PasswordHash == ""
+	}), true).Return([]domain.UpsertResult{{Created: true}}, nil)
+	file := "name,phone,document,password\n" +
+		"Ana,+5548999990000,52998224725,s3cret-pass\n"
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), true)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestImport_RejectsFile(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		file        string
+		err         error
+	}{
+		"unsupported type": {contentType: "application/json", file: `[]`, err: domain.ErrUnsupportedImportType},
+		"empty csv":        {contentType: domain.CSVType, file: ``, err: domain.ErrInvalidImport},
+		"missing column":   {contentType: domain.CSVType, file: "name,phone\nAna,+5548999990000\n", err: domain.ErrInvalidImport},
+		"repeated column":  {contentType: domain.CSVType, file: "name,phone,document,phone\n", err: domain.ErrInvalidImport},
+		"broken quoting":   {contentType: domain.CSVType, file: "name,phone,document,password\n\"Ana,+5548999990000,52998224725,s3cret-pass\n", err: domain.ErrInvalidImport},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			userRepoMock := new(mocks.UserRepositoryMock)
+			usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testConfig)
+
+			_, err := usecase.Import(testTenant, tt.contentType, strings.NewReader(tt.file), false)
+
+			assert.ErrorIs(t, err, tt.err)
+			userRepoMock.AssertNotCalled(t, "UpsertUsers", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestImport_CSVWrongFieldCount(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewImportUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testConfig)
+	userRepoMock.On("UpsertUsers", testTenant, usersCount(1), false).
+		Return([]domain.UpsertResult{{Created: true}}, nil)
+	file := "name,phone,document,password\n" +
+		"Ana,+5548999990000,s3cret-pass\n" +
+		"Bia,+5548999990001,11144477735,s3cret-pass\n"
+
+	// Act
+	report, err := usecase.Import(testTenant, domain.CSVType, strings.NewReader(file), false)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, report.Rows, 2)
+	assert.Equal(t, domain.ImportRejected, report.Rows[0].Action)
+	assert.Equal(t, 2, report.Rows[0].Line)
+	assert.Equal(t, domain.ImportCreated, report.Rows[1].Action)
+}
@@ -0,0 +1,180 @@
+package usecases
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// newMFAUsecase wires a user whose MFA columns follow every UpdateMFA call,
+// the way the database row would.
+func newMFAUsecase(t *testing.T) (*usecases.AuthUsecase, *domain.User) {
+	user := newUserWithPassword(t, "s3cret-pass")
+	user.Role = domain.RoleStaff
+
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("UpdateMFA", testTenant, user.ID, mock.Anything, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			user.TOTPSecret = args.String(2)
+			user.MFAEnabled = args.Bool(3)
+			user.RecoveryCodeHashes, _ = args.Get(4).([]string)
+		}).
+		Return(nil)
+	userRepoMock.On("ConsumeRecoveryCode", testTenant, user.ID, mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			user.RecoveryCodeHashes, _ = args.Get(3).([]string)
+		}).
+		Return(true, nil)
+
+	return usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig), user
+}
+
+func enableTOTP(t *testing.T, usecase *usecases.AuthUsecase, user *domain.User) []string {
+	enrollment, err := usecase.EnrollTOTP(testTenant, user.ID)
+	require.NoError(t, err)
+	code, err := auth.TOTPCode(enrollment.Secret, time.Now())
+	require.NoError(t, err)
+
+	recovery, err := usecase.ConfirmTOTP(testTenant, user.ID, &dto.MFACodeDTO{Code: code})
+	require.NoError(t, err)
+
+	return recovery.RecoveryCodes
+}
+
+func TestConfirmTOTP_EnablesMFA(t *testing.T) {
+	// Arrange
+	usecase, user := newMFAUsecase(t)
+
+	// Act
+	enrollment, err := usecase.EnrollTOTP(testTenant, user.ID)
+	require.NoError(t, err)
+	_, wrongErr := usecase.ConfirmTOTP(testTenant, user.ID, &dto.MFACodeDTO{Code: "000000"})
+	code, _ := auth.TOTPCode(enrollment.Secret, time.Now())
+	recovery, err := usecase.ConfirmTOTP(testTenant, user.ID, &dto.MFACodeDTO{Code: code})
+
+	// Assert
+	assert.ErrorIs(t, wrongErr, domain.ErrInvalidMFACode)
+	assert.NoError(t, err)
+	assert.Contains(t, enrollment.OTPAuthURI, "otpauth://totp/")
+	assert.True(t, user.MFAEnabled)
+	assert.Len(t, recovery.RecoveryCodes, 10)
+	assert.NotContains(t, user.RecoveryCodeHashes, recovery.RecoveryCodes[0])
+
+	_, err = usecase.EnrollTOTP(testTenant, user.ID)
+	assert.ErrorIs(t, err, domain.ErrMFAAlreadyEnabled)
+}
+
+func TestLogin_WithMFAReturnsPendingToken(t *testing.T) {
+	// Arrange
+	usecase, user := newMFAUsecase(t)
+	enableTOTP(t, usecase, user)
+
+	// Act
+	response, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, response.MFARequired)
+	assert.Empty(t, response.AccessToken)
+	assert.Empty(t, response.RefreshToken)
+	_, err = usecase.AuthenticateToken(response.MFAToken)
+	assert.ErrorIs(t, err, domain.ErrMFARequired)
+}
+
+func TestLoginMFA_WithTOTPCode(t *testing.T) {
+	// Arrange
+	usecase, user := newMFAUsecase(t)
+	enableTOTP(t, usecase, user)
+	// The confirmation burned the current step, so use the next one.
+	code, _ := auth.TOTPCode(user.TOTPSecret, time.Now().Add(30*time.Second))
+	pending, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+
+	// Act
+	tokens, err := usecase.LoginMFA(&dto.MFALoginDTO{MFAToken: pending.MFAToken, Code: code}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	claims, err := usecase.AuthenticateToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	_, err = usecase.LoginMFA(&dto.MFALoginDTO{MFAToken: pending.MFAToken, Code: code}, testClient)
+	assert.ErrorIs(t, err, domain.ErrInvalidMFACode, "a TOTP code is accepted only once")
+	previous, _ := auth.TOTPCode(user.TOTPSecret, time.Now().Add(-30*time.Second))
+	_, err = usecase.LoginMFA(&dto.MFALoginDTO{MFAToken: pending.MFAToken, Code: previous}, testClient)
+	assert.ErrorIs(t, err, domain.ErrInvalidMFAToken, "an MFA token is exchanged only once")
+}
+
+func TestLoginMFA_RecoveryCodeIsSingleUse(t *testing.T) {
+	// Arrange
+	usecase, user := newMFAUsecase(t)
+	codes := enableTOTP(t, usecase, user)
+
+	login := func() error {
+		pending, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+		require.NoError(t, err)
+		_, err = usecase.LoginMFA(&dto.MFALoginDTO{MFAToken: pending.MFAToken, Code: codes[3]}, testClient)
+		return err
+	}
+
+	// Act
+	first := login()
+	second := login()
+
+	// Assert
+	assert.NoError(t, first)
+	assert.ErrorIs(t, second, domain.ErrInvalidMFACode)
+	assert.Len(t, user.RecoveryCodeHashes, 9)
+}
+
+func TestLoginMFA_RecoveryCodeLostRaceIsRejected(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "s3cret-pass")
+	user.MFAEnabled = true
+	user.TOTPSecret = "JBSWY3DPEHPK3PXP"
+	user.RecoveryCodeHashes = []string{auth.HashToken(auth.NormalizeRecoveryCode("aaaa-bbbb"))}
+
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	// Another login spent the code between the read and the swap.
+	userRepoMock.On("ConsumeRecoveryCode", testTenant, user.ID, user.RecoveryCodeHashes, []string{}).Return(false, nil)
+	usecase := usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig)
+
+	pending, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+
+	// Act
+	tokens, err := usecase.LoginMFA(&dto.MFALoginDTO{MFAToken: pending.MFAToken, Code: "aaaa-bbbb"}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidMFACode)
+	assert.Nil(t, tokens)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestLoginMFA_RejectsAccessToken(t *testing.T) {
+	// Arrange
+	usecase, user := newMFAUsecase(t)
+	codes := enableTOTP(t, usecase, user)
+	accessToken, err := auth.GenerateJWT(auth.Claims{UserID: user.ID, Role: user.Role}, testKeys, testConfig)
+	require.NoError(t, err)
+
+	// Act
+	_, err = usecase.LoginMFA(&dto.MFALoginDTO{MFAToken: accessToken, Code: codes[0]}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidMFAToken)
+}
@@ -0,0 +1,207 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/src/config"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	oidcMocks "github.com/ThailanTec/challenger/pousada/test/mocks/oidc"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newOIDCUsecase(t *testing.T) (*usecases.OIDCUsecase, *oidcMocks.FakeOIDCServer, *mocks.UserRepositoryMock) {
+	server := oidcMocks.NewFakeOIDCServer(t)
+	server.Claims["preferred_username"] = "123.456.789-09"
+	server.Claims["name"] = "Jane Staff"
+	server.Claims["phone_number"] = "+5511999990000"
+
+	cfg := testConfig
+	cfg.OIDCIssuer = server.URL
+	cfg.OIDCClientID = server.ClientID
+	cfg.OIDCClientSecret = server.ClientSecret
+	cfg.OIDCRedirectURL = "http://localhost/login/oidc/callback"
+	cfg.OIDCScopes = "openid profile"
+	cfg.OIDCDocumentClaim = "preferred_username"
+	cfg.OIDCDefaultRole = string(domain.RoleStaff)
+
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, cfg)
+
+	return usecases.NewOIDCUsecase(auth.NewOIDCProvider(cfg), userRepoMock, redisRepo, authUsecase, cfg), server, userRepoMock
+}
+
+func TestOIDCLogin_ProvisionsUser(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(nil, domain.ErrUserNotFound)
+	userRepoMock.On("GetUserByIdentityDocument", testTenant, domain.DocumentCPF, "BR", "12345678909").Return(nil, domain.ErrGetUserByData)
+	var created *domain.User
+	userRepoMock.On("CreateUser", mock.Anything).Run(func(args mock.Arguments) {
+		created = args.Get(0).(*domain.User)
+		created.ID = uuid.New()
+	}).Return(nil)
+
+	// Act
+	authURL, err := usecase.BeginLogin(testTenant)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	tokens, err := usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "12345678909", created.Document)
+	assert.Equal(t, "subject-1", created.OIDCSubject)
+	claims, err := auth.ValidateJWT(tokens.AccessToken, testKeys)
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, claims.UserID)
+	assert.Equal(t, domain.RoleStaff, claims.Role)
+}
+
+func TestOIDCLogin_RefusesToLinkByDocument(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	user := &domain.User{ID: uuid.New(), Document: "12345678909", Role: domain.RoleAdmin}
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(nil, domain.ErrUserNotFound)
+	userRepoMock.On("GetUserByIdentityDocument", testTenant, domain.DocumentCPF, "BR", "12345678909").Return(user, nil)
+
+	// Act
+	authURL, err := usecase.BeginLogin(testTenant)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	tokens, err := usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrOIDCLinkRequired)
+	assert.Nil(t, tokens)
+	userRepoMock.AssertNotCalled(t, "LinkOIDCSubject", mock.Anything, mock.Anything, mock.Anything)
+	userRepoMock.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
+
+func TestOIDCLink_LinksSignedInUser(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	user := &domain.User{ID: uuid.New(), TenantID: testTenant, Document: "11144477735", Role: domain.RoleStaff}
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(nil, domain.ErrUserNotFound)
+	userRepoMock.On("LinkOIDCSubject", testTenant, user.ID, "subject-1").Return(nil)
+
+	// Act
+	authURL, err := usecase.BeginLink(testTenant, user.ID)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	tokens, err := usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	claims, err := auth.ValidateJWT(tokens.AccessToken, testKeys)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestOIDCLink_RefusesSubjectLinkedElsewhere(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	user := &domain.User{ID: uuid.New(), TenantID: testTenant, Role: domain.RoleGuest}
+	other := &domain.User{ID: uuid.New(), TenantID: testTenant, OIDCSubject: "subject-1", Role: domain.RoleAdmin}
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(other, nil)
+
+	// Act
+	authURL, err := usecase.BeginLink(testTenant, user.ID)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	_, err = usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrOIDCAlreadyLinked)
+	userRepoMock.AssertNotCalled(t, "LinkOIDCSubject", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCLogin_AsksForSecondFactor(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	user := &domain.User{ID: uuid.New(), TenantID: testTenant, OIDCSubject: "subject-1", Role: domain.RoleAdmin, MFAEnabled: true}
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(user, nil)
+
+	// Act
+	authURL, err := usecase.BeginLogin(testTenant)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	tokens, err := usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, tokens.MFARequired)
+	assert.NotEmpty(t, tokens.MFAToken)
+	assert.Empty(t, tokens.AccessToken)
+}
+
+func TestOIDCLogin_StateIsSingleUse(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	user := &domain.User{ID: uuid.New(), Document: "12345678909", OIDCSubject: "subject-1", Role: domain.RoleStaff}
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(user, nil)
+
+	authURL, err := usecase.BeginLogin(testTenant)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+
+	// Act
+	_, first := usecase.CompleteLogin(state, code, testClient)
+	_, replay := usecase.CompleteLogin(state, code, testClient)
+	_, forged := usecase.CompleteLogin("forged-state", code, testClient)
+
+	// Assert
+	assert.NoError(t, first)
+	assert.ErrorIs(t, replay, domain.ErrInvalidOIDCState)
+	assert.ErrorIs(t, forged, domain.ErrInvalidOIDCState)
+}
+
+func TestOIDCLogin_RequiresDocumentClaim(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	delete(server.Claims, "preferred_username")
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(nil, domain.ErrUserNotFound)
+
+	// Act
+	authURL, err := usecase.BeginLogin(testTenant)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	_, err = usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrOIDCMissingClaim)
+}
+
+func TestOIDCLogin_Disabled(t *testing.T) {
+	usecase := usecases.NewOIDCUsecase(auth.NewOIDCProvider(config.Config{}), nil, nil, nil, config.Config{})
+
+	_, err := usecase.BeginLogin(testTenant)
+
+	assert.ErrorIs(t, err, auth.ErrOIDCDisabled)
+}
+
+func TestOIDCLogin_RejectsInvalidDocumentClaim(t *testing.T) {
+	// Arrange
+	usecase, server, userRepoMock := newOIDCUsecase(t)
+	server.Claims["preferred_username"] = "123.456.789-00"
+	userRepoMock.On("GetUserByOIDCSubject", testTenant, "subject-1").Return(nil, domain.ErrUserNotFound)
+
+	// Act
+	authURL, err := usecase.BeginLogin(testTenant)
+	require.NoError(t, err)
+	code, state := server.Authorize(t, authURL)
+	_, err = usecase.CompleteLogin(state, code, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidCPF)
+	userRepoMock.AssertNotCalled(t, "CreateUser", mock.Anything)
+}
@@ -0,0 +1,176 @@
+package usecases
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	notifierMocks "github.com/ThailanTec/challenger/pousada/test/mocks/notifier"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var resetTokenPattern = regexp.MustCompile(`[A-Za-z0-9_-]{43}$`)
+
+func TestResetPassword_ChangesPasswordAndRevokesSessions(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "old-password")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("UpdatePassword", testTenant, user.ID, mock.Anything).Run(func(args mock.Arguments) {
+		user.PasswordHash = args.String(2)
+	}).Return(nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	notify := &notifierMocks.RecordingNotifier{}
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPasswordResetUsecase(userRepoMock, redisRepo, notify, authUsecase, testConfig)
+	requestToken := func() string {
+		require.NoError(t, usecase.ForgotPassword(testTenant, &dto.ForgotPasswordDTO{Document: user.Document}))
+		token := resetTokenPattern.FindString(notify.Last().Body)
+		require.NotEmpty(t, token)
+		return token
+	}
+	session, err := authUsecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "old-password"}, testClient)
+	require.NoError(t, err)
+	token := requestToken()
+
+	// Act
+	err = usecase.ResetPassword(&dto.ResetPasswordDTO{Token: token, NewPassword: "new-password"})
+
+	// Assert
+	require.NoError(t, err)
+	_, err = authUsecase.AuthenticateToken(session.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+	_, err = authUsecase.RefreshToken(session.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+	assert.Equal(t, user.ID, notify.Last().UserID)
+}
+
+func TestResetPassword_TokenIsSingleUse(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "old-password")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("UpdatePassword", testTenant, user.ID, mock.Anything).Run(func(args mock.Arguments) {
+		user.PasswordHash = args.String(2)
+	}).Return(nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	notify := &notifierMocks.RecordingNotifier{}
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPasswordResetUsecase(userRepoMock, redisRepo, notify, authUsecase, testConfig)
+	requestToken := func() string {
+		require.NoError(t, usecase.ForgotPassword(testTenant, &dto.ForgotPasswordDTO{Document: user.Document}))
+		token := resetTokenPattern.FindString(notify.Last().Body)
+		require.NotEmpty(t, token)
+		return token
+	}
+	token := requestToken()
+
+	// Act
+	first := usecase.ResetPassword(&dto.ResetPasswordDTO{Token: token, NewPassword: "new-password"})
+	second := usecase.ResetPassword(&dto.ResetPasswordDTO{Token: token, NewPassword: "other-password"})
+
+	// Assert
+	assert.NoError(t, first)
+	assert.ErrorIs(t, second, domain.ErrInvalidResetToken)
+	userRepoMock.AssertNumberOfCalls(t, "UpdatePassword", 1)
+}
+
+func TestForgotPassword_UnknownDocumentIsSilent(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "old-password")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	notify := &notifierMocks.RecordingNotifier{}
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPasswordResetUsecase(userRepoMock, redisRepo, notify, authUsecase, testConfig)
+	userRepoMock.On("GetUserByData", testTenant, "NOBODY").Return(nil, domain.ErrGetUserByData)
+
+	// Act
+	err := usecase.ForgotPassword(testTenant, &dto.ForgotPasswordDTO{Document: "nobody"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, notify.Messages)
+}
+
+func TestForgotPassword_FindsPassportByType(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "old-password")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	notify := &notifierMocks.RecordingNotifier{}
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPasswordResetUsecase(userRepoMock, redisRepo, notify, authUsecase, testConfig)
+	userRepoMock.On("GetUserByIdentityDocument", testTenant, domain.DocumentPassport, "AR", "AB123456").Return(user, nil)
+
+	// Act
+	err := usecase.ForgotPassword(testTenant, &dto.ForgotPasswordDTO{Document: "ab-123456", DocumentType: "passport", DocumentCountry: "AR"})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Len(t, notify.Messages, 1)
+}
+
+func TestForgotPassword_AmbiguousDocumentIsSilent(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "old-password")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	notify := &notifierMocks.RecordingNotifier{}
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPasswordResetUsecase(userRepoMock, redisRepo, notify, authUsecase, testConfig)
+	userRepoMock.On("GetUserByData", testTenant, "X1234567").Return(nil, domain.ErrAmbiguousDocument)
+
+	// Act
+	err := usecase.ForgotPassword(testTenant, &dto.ForgotPasswordDTO{Document: "X1234567"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Empty(t, notify.Messages)
+}
+
+func TestForgotPassword_NewRequestInvalidatesPreviousToken(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "old-password")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("UpdatePassword", testTenant, user.ID, mock.Anything).Run(func(args mock.Arguments) {
+		user.PasswordHash = args.String(2)
+	}).Return(nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	notify := &notifierMocks.RecordingNotifier{}
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPasswordResetUsecase(userRepoMock, redisRepo, notify, authUsecase, testConfig)
+	requestToken := func() string {
+		require.NoError(t, usecase.ForgotPassword(testTenant, &dto.ForgotPasswordDTO{Document: user.Document}))
+		token := resetTokenPattern.FindString(notify.Last().Body)
+		require.NotEmpty(t, token)
+		return token
+	}
+	old := requestToken()
+	// Skip the per-user throttle between two requests.
+	require.NoError(t, redisRepo.Delete("password_reset_throttle:"+user.ID.String()))
+	current := requestToken()
+
+	// Act
+	oldErr := usecase.ResetPassword(&dto.ResetPasswordDTO{Token: old, NewPassword: "new-password"})
+	currentErr := usecase.ResetPassword(&dto.ResetPasswordDTO{Token: current, NewPassword: "new-password"})
+
+	// Assert
+	assert.ErrorIs(t, oldErr, domain.ErrInvalidResetToken)
+	assert.NoError(t, currentErr)
+}
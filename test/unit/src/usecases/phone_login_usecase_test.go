@@ -0,0 +1,161 @@
+package usecases
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/infra/auth"
+	"github.com/ThailanTec/challenger/pousada/infra/sms"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	smsMocks "github.com/ThailanTec/challenger/pousada/test/mocks/sms"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const guestPhone = "+5511988887777"
+
+func newPhoneLoginUsecase(t *testing.T) (*usecases.PhoneLoginUsecase, *smsMocks.RecordingSender, *domain.User, *mocks.UserRepositoryMock) {
+	user := newUserWithPassword(t, "unused-pass")
+	user.Phone = guestPhone
+
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByPhone", testTenant, guestPhone).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+
+	redisRepo := mocks.NewFakeRedisRepository()
+	sender := &smsMocks.RecordingSender{}
+	cfg := testConfig
+	cfg.OTPMaxAttempts = 3
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, cfg)
+
+	return usecases.NewPhoneLoginUsecase(userRepoMock, redisRepo, sender, authUsecase, cfg), sender, user, userRepoMock
+}
+
+// wrongCode returns a valid-looking code different from code.
+func wrongCode(code string) string {
+	var n int
+	_, _ = fmt.Sscanf(code, "%d", &n)
+	return fmt.Sprintf("%06d", (n+1)%1000000)
+}
+
+func TestPhoneLogin_Success(t *testing.T) {
+	// Arrange
+	usecase, sender, user, _ := newPhoneLoginUsecase(t)
+	require.NoError(t, usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: guestPhone, Channel: "whatsapp"}))
+	code := sender.LastCode()
+
+	// Act
+	tokens, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: code}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	claims, err := auth.ValidateJWT(tokens.AccessToken, testKeys)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, sms.ChannelWhatsApp, sender.Messages[0].Channel)
+
+	_, err = usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: code}, testClient)
+	assert.ErrorIs(t, err, domain.ErrInvalidOTP, "a code is accepted only once")
+}
+
+func TestPhoneLogin_AcceptsAnyFormatting(t *testing.T) {
+	// Arrange
+	usecase, sender, user, _ := newPhoneLoginUsecase(t)
+	require.NoError(t, usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: "(11) 98888-7777"}))
+	code := sender.LastCode()
+
+	// Act
+	tokens, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: "+55 11 98888-7777", Code: code}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	claims, err := auth.ValidateJWT(tokens.AccessToken, testKeys)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, claims.UserID)
+	assert.Equal(t, guestPhone, sender.Messages[0].Phone)
+}
+
+func TestPhoneLogin_DiscardsCodeAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	usecase, sender, _, _ := newPhoneLoginUsecase(t)
+	require.NoError(t, usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: guestPhone}))
+	code := sender.LastCode()
+
+	// Act
+	for i := 0; i < 3; i++ {
+		_, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: wrongCode(code)}, testClient)
+		assert.ErrorIs(t, err, domain.ErrInvalidOTP)
+	}
+	_, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: code}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidOTP)
+}
+
+func TestPhoneLogin_UnknownPhoneAndThrottle(t *testing.T) {
+	// Arrange
+	usecase, sender, _, userRepoMock := newPhoneLoginUsecase(t)
+	userRepoMock.On("GetUserByPhone", testTenant, "+5511000000000").Return(nil, domain.ErrUserNotFound)
+
+	// Act
+	unknownErr := usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: "+5511000000000"})
+	firstErr := usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: guestPhone})
+	secondErr := usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: guestPhone})
+
+	// Assert
+	assert.NoError(t, unknownErr)
+	assert.NoError(t, firstErr)
+	assert.NoError(t, secondErr)
+	assert.Len(t, sender.Messages, 1)
+}
+
+func TestPhoneLogin_MFAUserGetsPendingToken(t *testing.T) {
+	// Arrange
+	usecase, sender, user, _ := newPhoneLoginUsecase(t)
+	user.MFAEnabled = true
+	require.NoError(t, usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: guestPhone}))
+
+	// Act
+	response, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: sender.LastCode()}, testClient)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, response.MFARequired)
+	assert.Empty(t, response.AccessToken)
+}
+
+func TestPhoneLogin_LocksPhoneAcrossCodes(t *testing.T) {
+	// Arrange
+	user := newUserWithPassword(t, "unused-pass")
+	user.Phone = guestPhone
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByPhone", testTenant, guestPhone).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+	redisRepo := mocks.NewFakeRedisRepository()
+	sender := &smsMocks.RecordingSender{}
+	cfg := testConfig
+	cfg.OTPMaxAttempts = 3
+	cfg.LoginMaxAttempts = 4
+	cfg.LoginAttemptWindow = time.Hour
+	cfg.LoginLockoutDuration = time.Hour
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, cfg)
+	usecase := usecases.NewPhoneLoginUsecase(userRepoMock, redisRepo, sender, authUsecase, cfg)
+
+	// Act
+	for round := 0; round < 2; round++ {
+		require.NoError(t, redisRepo.Delete(fmt.Sprintf("otp_throttle:%s:%s", testTenant, guestPhone)))
+		require.NoError(t, usecase.RequestCode(testTenant, &dto.RequestOTPDTO{Phone: guestPhone}))
+		for i := 0; i < 2; i++ {
+			_, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: wrongCode(sender.LastCode())}, testClient)
+			assert.ErrorIs(t, err, domain.ErrInvalidOTP)
+		}
+	}
+	_, err := usecase.VerifyCode(testTenant, &dto.VerifyOTPDTO{Phone: guestPhone, Code: sender.LastCode()}, testClient)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrAccountLocked, "a new code does not grant new guesses")
+}
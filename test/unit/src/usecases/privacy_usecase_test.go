@@ -0,0 +1,169 @@
+package usecases
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivacyExport_GathersRelatedRecords(t *testing.T) {
+	// Arrange
+	admin := uuid.New()
+	guest := newUserWithPassword(t, "s3cret-pass")
+	guest.Phone = "+5548999990000"
+	guest.DocumentType = domain.DocumentCPF
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByData", testTenant, guest.Document).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	apiKeyRepoMock := new(mocks.APIKeyRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPrivacyUsecase(userRepoMock, auditRepoMock, apiKeyRepoMock, redisRepo, authUsecase, usecases.NewAuditUsecase(auditRepoMock))
+	_, err := authUsecase.Login(testTenant, &dto.LoginDTO{Document: guest.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+	entries := []*domain.AuditEntry{{ID: uuid.New(), ActorID: admin, UserID: guest.ID, Action: domain.AuditImpersonationStart}}
+	auditRepoMock.On("GetAuditEntriesInvolving", testTenant, guest.ID).Return(entries, nil)
+	ownKey := &domain.APIKey{ID: uuid.New(), CreatedBy: guest.ID}
+	apiKeyRepoMock.On("GetAPIKeys", testTenant).Return([]*domain.APIKey{ownKey, {ID: uuid.New(), CreatedBy: admin}}, nil)
+
+	// Act
+	export, err := usecase.Export(testTenant, admin, guest.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, guest, export.User)
+	assert.Equal(t, entries, export.AuditEntries)
+	assert.Len(t, export.Sessions, 1)
+	assert.Equal(t, testClient.IP, export.Sessions[0].IP)
+	assert.Equal(t, []*domain.APIKey{ownKey}, export.APIKeys)
+	auditRepoMock.AssertCalled(t, "CreateAuditEntry", mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == domain.AuditUserExported && entry.ActorID == admin && entry.UserID == guest.ID
+	}))
+}
+
+func TestPrivacyAnonymize_ScrubsUserAndEvictsRedis(t *testing.T) {
+	// Arrange
+	admin := uuid.New()
+	guest := newUserWithPassword(t, "s3cret-pass")
+	guest.Phone = "+5548999990000"
+	guest.DocumentType = domain.DocumentCPF
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByData", testTenant, guest.Document).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	apiKeyRepoMock := new(mocks.APIKeyRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPrivacyUsecase(userRepoMock, auditRepoMock, apiKeyRepoMock, redisRepo, authUsecase, usecases.NewAuditUsecase(auditRepoMock))
+	tokens, err := authUsecase.Login(testTenant, &dto.LoginDTO{Document: guest.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+	cacheKey := fmt.Sprintf("tenant:%s:user:cpf:%s", testTenant, guest.Document)
+	otpKey := fmt.Sprintf("otp_code:%s:%s", testTenant, guest.Phone)
+	lockKey := fmt.Sprintf("login_lock:%s:%s", testTenant, guest.Document)
+	for _, key := range []string{cacheKey, otpKey, lockKey} {
+		require.NoError(t, redisRepo.Set(key, "cached", time.Hour))
+	}
+	userRepoMock.On("AnonymizeUser", mock.MatchedBy(func(user *domain.User) bool {
+		return user.ID == guest.ID && user.Name == domain.AnonymizedName && user.PasswordHash == "" &&
+			user.Document == "anon-"+guest.ID.String() && user.DeletedAt.Valid && user.AnonymizedAt != nil
+	})).Return(nil)
+	auditRepoMock.On("ScrubAuditEntries", testTenant, guest.ID).Return(nil)
+
+	// Act
+	user, err := usecase.Anonymize(testTenant, admin, guest.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, domain.DocumentCPF, user.DocumentType, "the document type is kept for statistics")
+	for _, key := range []string{cacheKey, otpKey, lockKey} {
+		value, _ := redisRepo.Get(key)
+		assert.Empty(t, value, key)
+	}
+	sessions, err := authUsecase.ListSessions(testTenant, guest.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+	_, err = authUsecase.AuthenticateToken(tokens.AccessToken)
+	assert.Error(t, err, "tokens issued before the anonymization are revoked")
+	userRepoMock.AssertExpectations(t)
+	auditRepoMock.AssertExpectations(t)
+	auditRepoMock.AssertCalled(t, "CreateAuditEntry", mock.MatchedBy(func(entry *domain.AuditEntry) bool {
+		return entry.Action == domain.AuditUserAnonymized && entry.ActorID == admin
+	}))
+}
+
+func TestPrivacyAnonymize_AlreadyAnonymized(t *testing.T) {
+	// Arrange
+	admin := uuid.New()
+	guest := newUserWithPassword(t, "s3cret-pass")
+	guest.Phone = "+5548999990000"
+	guest.DocumentType = domain.DocumentCPF
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByData", testTenant, guest.Document).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	apiKeyRepoMock := new(mocks.APIKeyRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPrivacyUsecase(userRepoMock, auditRepoMock, apiKeyRepoMock, redisRepo, authUsecase, usecases.NewAuditUsecase(auditRepoMock))
+	_, err := authUsecase.Login(testTenant, &dto.LoginDTO{Document: guest.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+	guest.Anonymize(time.Now())
+	auditRepoMock.On("ScrubAuditEntries", testTenant, guest.ID).Return(nil)
+
+	// Act
+	user, err := usecase.Anonymize(testTenant, admin, guest.ID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, guest, user)
+	sessions, err := authUsecase.ListSessions(testTenant, guest.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "a retry still drops what an earlier attempt left behind")
+	userRepoMock.AssertNotCalled(t, "AnonymizeUser", mock.Anything)
+	auditRepoMock.AssertCalled(t, "ScrubAuditEntries", testTenant, guest.ID)
+	auditRepoMock.AssertNotCalled(t, "CreateAuditEntry", mock.Anything)
+}
+
+func TestPrivacyAnonymize_ScrubFailureKeepsUser(t *testing.T) {
+	// Arrange
+	admin := uuid.New()
+	guest := newUserWithPassword(t, "s3cret-pass")
+	guest.Phone = "+5548999990000"
+	guest.DocumentType = domain.DocumentCPF
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByID", testTenant, guest.ID).Return(guest, nil)
+	userRepoMock.On("GetUserByData", testTenant, guest.Document).Return(guest, nil)
+	auditRepoMock := new(mocks.AuditRepositoryMock)
+	auditRepoMock.On("CreateAuditEntry", mock.Anything).Return(nil)
+	apiKeyRepoMock := new(mocks.APIKeyRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	authUsecase := usecases.NewAuthUsecase(userRepoMock, redisRepo, testKeys, testConfig)
+	usecase := usecases.NewPrivacyUsecase(userRepoMock, auditRepoMock, apiKeyRepoMock, redisRepo, authUsecase, usecases.NewAuditUsecase(auditRepoMock))
+	auditRepoMock.On("ScrubAuditEntries", testTenant, guest.ID).Return(errors.New("database down"))
+
+	// Act
+	_, err := usecase.Anonymize(testTenant, admin, guest.ID)
+
+	// Assert
+	assert.Error(t, err)
+	assert.Nil(t, guest.AnonymizedAt, "the user can be anonymized again")
+	userRepoMock.AssertNotCalled(t, "AnonymizeUser", mock.Anything)
+}
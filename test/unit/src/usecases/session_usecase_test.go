@@ -0,0 +1,125 @@
+package usecases
+
+import (
+	"testing"
+
+	"github.com/ThailanTec/challenger/pousada/domain"
+	"github.com/ThailanTec/challenger/pousada/src/dto"
+	"github.com/ThailanTec/challenger/pousada/src/usecases"
+	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSessionUsecase(t *testing.T) (*usecases.AuthUsecase, *domain.User) {
+	user := newUserWithPassword(t, "s3cret-pass")
+	userRepoMock := new(mocks.UserRepositoryMock)
+	userRepoMock.On("GetUserByData", testTenant, user.Document).Return(user, nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+
+	return usecases.NewAuthUsecase(userRepoMock, mocks.NewFakeRedisRepository(), testKeys, testConfig), user
+}
+
+func TestListSessions_ReturnsOneSessionPerLogin(t *testing.T) {
+	// Arrange
+	usecase, user := newSessionUsecase(t)
+	_, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, domain.ClientInfo{IP: "10.0.0.1", UserAgent: "laptop"})
+	require.NoError(t, err)
+	_, err = usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, domain.ClientInfo{IP: "10.0.0.2", UserAgent: "phone"})
+	require.NoError(t, err)
+
+	// Act
+	sessions, err := usecase.ListSessions(testTenant, user.ID)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	agents := []string{sessions[0].UserAgent, sessions[1].UserAgent}
+	assert.ElementsMatch(t, []string{"laptop", "phone"}, agents)
+	for _, session := range sessions {
+		assert.Equal(t, user.ID, session.UserID)
+		assert.NotEmpty(t, session.IP)
+		assert.False(t, session.CreatedAt.IsZero())
+	}
+}
+
+func TestAuthenticateToken_CarriesSessionID(t *testing.T) {
+	// Arrange
+	usecase, user := newSessionUsecase(t)
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+
+	// Act
+	claims, err := usecase.AuthenticateToken(tokens.AccessToken)
+
+	// Assert
+	require.NoError(t, err)
+	sessions, err := usecase.ListSessions(testTenant, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, sessions[0].ID, claims.SessionID)
+}
+
+func TestRevokeSession_EndsOnlyThatSession(t *testing.T) {
+	// Arrange
+	usecase, user := newSessionUsecase(t)
+	laptop, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, domain.ClientInfo{IP: "10.0.0.1", UserAgent: "laptop"})
+	require.NoError(t, err)
+	phone, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, domain.ClientInfo{IP: "10.0.0.2", UserAgent: "phone"})
+	require.NoError(t, err)
+	laptopClaims, err := usecase.AuthenticateToken(laptop.AccessToken)
+	require.NoError(t, err)
+
+	// Act
+	err = usecase.RevokeSession(testTenant, user.ID, laptopClaims.SessionID)
+
+	// Assert
+	require.NoError(t, err)
+	_, err = usecase.AuthenticateToken(laptop.AccessToken)
+	assert.ErrorIs(t, err, domain.ErrTokenRevoked)
+	_, err = usecase.RefreshToken(laptop.RefreshToken)
+	assert.ErrorIs(t, err, domain.ErrInvalidRefreshToken)
+
+	_, err = usecase.AuthenticateToken(phone.AccessToken)
+	assert.NoError(t, err)
+	sessions, err := usecase.ListSessions(testTenant, user.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "phone", sessions[0].UserAgent)
+}
+
+func TestRevokeSession_UnknownOrForeignSession(t *testing.T) {
+	// Arrange
+	usecase, user := newSessionUsecase(t)
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+	claims, err := usecase.AuthenticateToken(tokens.AccessToken)
+	require.NoError(t, err)
+
+	// Act
+	unknownErr := usecase.RevokeSession(testTenant, user.ID, uuid.New())
+	foreignErr := usecase.RevokeSession(testTenant, uuid.New(), claims.SessionID)
+
+	// Assert
+	assert.ErrorIs(t, unknownErr, domain.ErrSessionNotFound)
+	assert.ErrorIs(t, foreignErr, domain.ErrSessionNotFound)
+	_, err = usecase.AuthenticateToken(tokens.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestLogout_RemovesSession(t *testing.T) {
+	// Arrange
+	usecase, user := newSessionUsecase(t)
+	tokens, err := usecase.Login(testTenant, &dto.LoginDTO{Document: user.Document, Password: "s3cret-pass"}, testClient)
+	require.NoError(t, err)
+
+	// Act
+	err = usecase.Logout(tokens.AccessToken, tokens.RefreshToken)
+
+	// Assert
+	require.NoError(t, err)
+	sessions, err := usecase.ListSessions(testTenant, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
@@ -2,26 +2,31 @@ package usecases
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/ThailanTec/challenger/pousada/domain"
 	"github.com/ThailanTec/challenger/pousada/src/dto"
 	"github.com/ThailanTec/challenger/pousada/src/usecases"
 	mocks "github.com/ThailanTec/challenger/pousada/test/mocks/repositories"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_CreateUser_Success(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userDTO := &dto.UserDTO{
 		Name:     "Belo",
 		Phone:    "31994416221",
-		Document: "12345678900",
+		Document: "123.456.789-09",
+		Password: "s3cret-pass",
 	}
 
 	_ = &domain.User{
@@ -34,21 +39,21 @@ func Test_CreateUser_Success(t *testing.T) {
 	userRepoMock.On("CreateUser", mock.AnythingOfType("*domain.User")).Return(nil)
 
 	// Act
-	createdUser, err := usecase.CreateUser(userDTO)
+	createdUser, err := usecase.CreateUser(testTenant, userDTO)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.NotNil(t, createdUser)
 	assert.Equal(t, userDTO.Name, createdUser.Name)
-	assert.Equal(t, userDTO.Phone, createdUser.Phone)
-	assert.Equal(t, userDTO.Document, createdUser.Document)
+	assert.Equal(t, "+5531994416221", createdUser.Phone)
+	assert.Equal(t, "12345678909", createdUser.Document)
 	userRepoMock.AssertExpectations(t)
 }
 
 func TestCreateUser_Failure(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userDTO := &dto.UserDTO{
 		Name:  "Test User",
@@ -64,7 +69,7 @@ func TestCreateUser_Failure(t *testing.T) {
 	userRepoMock.On("CreateUser", expectedUser).Return(errors.New("failed to create user"))
 
 	// Act
-	createdUser, err := usecase.CreateUser(userDTO)
+	createdUser, err := usecase.CreateUser(testTenant, userDTO)
 
 	// Assert
 	assert.Error(t, err)
@@ -74,18 +79,19 @@ func TestCreateUser_Failure(t *testing.T) {
 func TestCreateUser_MissingField(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userDTO := &dto.UserDTO{
 		Phone:    "1234567890",
 		Document: "123456789",
+		Password: "s3cret-pass",
 	}
 	e := "Key: 'UserDTO.Name' Error:Field validation for 'Name' failed on the 'required' tag"
 
 	userRepoMock.On("CreateUser", mock.AnythingOfType("*domain.User")).Return(nil).Maybe()
 
 	// Act
-	createdUser, err := usecase.CreateUser(userDTO)
+	createdUser, err := usecase.CreateUser(testTenant, userDTO)
 
 	// Assert
 	assert.Error(t, err)
@@ -97,48 +103,117 @@ func TestCreateUser_MissingField(t *testing.T) {
 func TestGetUsers_Success(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	users := []*domain.User{
 		{
 			ID:       uuid.MustParse("af430404-e5ea-4752-9d89-0c371ec0d9fc"),
 			Name:     "user1",
-			Document: "doc1",
+			Document: "DOC1",
 			Phone:    "phone1",
 		},
 		{
 			ID:       uuid.MustParse("07cfb203-30f7-4cec-b75b-f523822795fb"),
 			Name:     "user2",
-			Document: "doc2",
+			Document: "DOC2",
 			Phone:    "phone2",
 		},
 	}
+	page := &domain.UserPage{Users: users}
 
 	// Act
-	userRepoMock.On("GetUsers").Return(users, nil)
-	getusers, err := usecase.GetUsers()
+	userRepoMock.On("GetUsers", domain.UserFilter{TenantID: testTenant, Sort: domain.UserSortCreatedAt, Limit: 20}).Return(page, nil)
+	getusers, err := usecase.GetUsers(domain.UserFilter{TenantID: testTenant})
 
 	// Assert
 	assert.NoError(t, err)
 	assert.NotNil(t, getusers)
-	assert.Equal(t, users, getusers)
+	assert.Equal(t, page, getusers)
 	userRepoMock.AssertExpectations(t)
 }
 
+func TestGetUsers_CapsLimit(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	userRepoMock.On("GetUsers", domain.UserFilter{TenantID: testTenant, Sort: domain.UserSortName, Limit: 100}).Return(&domain.UserPage{}, nil)
+
+	// Act
+	_, err := usecase.GetUsers(domain.UserFilter{TenantID: testTenant, Sort: domain.UserSortName, Limit: 5000})
+
+	// Assert
+	assert.NoError(t, err)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestExportUsers_UsesListingFilter(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	users := []*domain.User{{Name: "user1"}, {Name: "user2"}}
+	userRepoMock.On("StreamUsers", domain.UserFilter{TenantID: testTenant, Phone: "+5548999990000", Sort: domain.UserSortCreatedAt}).Return(users, nil)
+	var names []string
+
+	// Act
+	err := usecase.ExportUsers(domain.UserFilter{
+		TenantID:  testTenant,
+		Phone:     "(48) 99999-0000",
+		Limit:     10,
+		After:     &domain.UserCursor{Sort: domain.UserSortName},
+		WithTotal: true,
+	}, func(user *domain.User) error {
+		names = append(names, user.Name)
+		return nil
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user1", "user2"}, names)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestGetUsers_RejectsCursorOfAnotherSort(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	cursor := domain.NewUserCursor(&domain.User{ID: uuid.New(), Name: "Ana"}, domain.UserSortName, false)
+
+	// Act
+	_, err := usecase.GetUsers(domain.UserFilter{TenantID: testTenant, Sort: domain.UserSortCreatedAt, After: cursor})
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+	userRepoMock.AssertNotCalled(t, "GetUsers", mock.Anything)
+}
+
+func TestUserCursor_RoundTrip(t *testing.T) {
+	user := &domain.User{ID: uuid.New(), CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123, time.UTC)}
+	cursor := domain.NewUserCursor(user, domain.UserSortCreatedAt, true)
+
+	decoded, err := domain.DecodeUserCursor(cursor.Encode())
+
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, decoded)
+	assert.Equal(t, "2024-05-01T12:30:00.000000123Z", decoded.Value)
+
+	_, err = domain.DecodeUserCursor("not-a-cursor")
+	assert.ErrorIs(t, err, domain.ErrInvalidCursor)
+}
+
 func TestGetUserByDocument_Success(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 	user := &domain.User{
 		ID:       uuid.MustParse("af430404-e5ea-4752-9d89-0c371ec0d9fc"),
 		Name:     "user1",
-		Document: "doc1",
+		Document: "DOC1",
 		Phone:    "phone1",
 	}
 
 	// Act
-	userRepoMock.On("GetUserByData", "doc1").Return(user, nil)
-	getuser, err := usecase.GetUserByDocument("doc1")
+	userRepoMock.On("GetUserByData", testTenant, "DOC1").Return(user, nil)
+	getuser, err := usecase.GetUserByDocument(testTenant, "", "", "DOC1")
 
 	// Assert
 	assert.NoError(t, err)
@@ -150,12 +225,12 @@ func TestGetUserByDocument_Success(t *testing.T) {
 func TestGetUserByDocument_Failure(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
-	userRepoMock.On("GetUserByData", "doc2").Return(nil, domain.ErrGetUserByData)
+	userRepoMock.On("GetUserByData", testTenant, "DOC2").Return(nil, domain.ErrGetUserByData)
 
 	// Act
-	user, err := usecase.GetUserByDocument("doc2")
+	user, err := usecase.GetUserByDocument(testTenant, "", "", "DOC2")
 
 	// Assert
 	assert.Error(t, err)
@@ -167,13 +242,14 @@ func TestGetUserByDocument_Failure(t *testing.T) {
 func TestDeleteUser_Success(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
-	userRepoMock.On("DeleteUser", userID).Return(nil)
+	userRepoMock.On("GetUserByID", testTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.On("DeleteUser", testTenant, userID, int64(0)).Return(nil)
 
 	// Act
-	err := usecase.DeleteUser(userID)
+	err := usecase.DeleteUser(testTenant, userID, 0)
 
 	// Assert
 	assert.NoError(t, err)
@@ -183,13 +259,14 @@ func TestDeleteUser_Success(t *testing.T) {
 func TestDeleteUser_Failure(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
-	userRepoMock.On("DeleteUser", userID).Return(errors.New("Erro ao deletar usuário"))
+	userRepoMock.On("GetUserByID", testTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.On("DeleteUser", testTenant, userID, int64(0)).Return(errors.New("Erro ao deletar usuário"))
 
 	// Act
-	err := usecase.DeleteUser(userID)
+	err := usecase.DeleteUser(testTenant, userID, 0)
 
 	// Assert
 	assert.Error(t, err)
@@ -199,26 +276,27 @@ func TestDeleteUser_Failure(t *testing.T) {
 func TestUpdateUser_Success(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
 	userDTO := &dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "529.982.247-25",
 	}
 
 	updatedUser := &domain.User{
 		ID:       userID,
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "DOC1",
 	}
 
-	userRepoMock.On("UpdateUser", userID, mock.AnythingOfType("*domain.User")).Return(updatedUser, nil)
+	userRepoMock.On("GetUserByID", testTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.On("UpdateUser", testTenant, userID, mock.AnythingOfType("*domain.User"), int64(0)).Return(updatedUser, nil)
 
 	// Act
-	result, err := usecase.UpdateUser(userID, userDTO)
+	result, err := usecase.UpdateUser(testTenant, userID, userDTO, 0)
 
 	// Assert
 	assert.NoError(t, err)
@@ -229,24 +307,378 @@ func TestUpdateUser_Success(t *testing.T) {
 func TestUpdateUser_ErrorUpdatingUserInRepository(t *testing.T) {
 	// Arrange
 	userRepoMock := new(mocks.UserRepositoryMock)
-	usecase := usecases.NewUserUsecase(userRepoMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
 
 	userID := uuid.New()
 	userDTO := &dto.UserDTO{
 		Name:     "John Doe",
-		Phone:    "123456789",
-		Document: "doc1",
+		Phone:    "+5548999990000",
+		Document: "529.982.247-25",
 	}
 
 	// Simula um erro na atualização do usuário no repositório
 	mockError := errors.New("repository error")
-	userRepoMock.On("UpdateUser", userID, mock.AnythingOfType("*domain.User")).Return(nil, mockError)
+	userRepoMock.On("GetUserByID", testTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.On("UpdateUser", testTenant, userID, mock.AnythingOfType("*domain.User"), int64(0)).Return(nil, mockError)
 
 	// Act
-	result, err := usecase.UpdateUser(userID, userDTO)
+	result, err := usecase.UpdateUser(testTenant, userID, userDTO, 0)
 
 	// Assert
 	assert.Nil(t, result)
 	assert.EqualError(t, err, mockError.Error())
 	userRepoMock.AssertExpectations(t)
 }
+
+func TestCreateUser_SameDocumentInTwoTenants(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewFakeRedisRepository(), time.Minute, "BR")
+	userRepoMock.On("CreateUser", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	input := func(name string) *dto.UserDTO {
+		return &dto.UserDTO{Name: name, Phone: "31994416221", Document: "123.456.789-09", Password: "s3cret-pass"}
+	}
+
+	// Act
+	north, err := usecase.CreateUser("pousada-norte", input("Norte"))
+	assert.NoError(t, err)
+	south, err := usecase.CreateUser("pousada-sul", input("Sul"))
+	assert.NoError(t, err)
+
+	cachedNorth, err := usecase.GetUserByDocument("pousada-norte", "", "", "12345678909")
+	assert.NoError(t, err)
+	cachedSouth, err := usecase.GetUserByDocument("pousada-sul", "", "", "12345678909")
+	assert.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "pousada-norte", north.TenantID)
+	assert.Equal(t, "pousada-sul", south.TenantID)
+	assert.Equal(t, north.ID, cachedNorth.ID)
+	assert.Equal(t, south.ID, cachedSouth.ID)
+	userRepoMock.AssertNotCalled(t, "GetUserByData", mock.Anything, mock.Anything)
+}
+
+func TestGetUserByDocument_Passport(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewFakeRedisRepository(), time.Minute, "BR")
+	user := &domain.User{ID: uuid.New(), Document: "AB123456", DocumentType: domain.DocumentPassport, DocumentCountry: "AR"}
+	userRepoMock.On("GetUserByIdentityDocument", testTenant, domain.DocumentPassport, "AR", "AB123456").Return(user, nil).Once()
+
+	// Act
+	found, err := usecase.GetUserByDocument(testTenant, domain.DocumentPassport, "ar", "ab-123456")
+	assert.NoError(t, err)
+	cached, err := usecase.GetUserByDocument(testTenant, domain.DocumentPassport, "AR", "AB123456")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+	assert.Equal(t, user.ID, cached.ID)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestGetUserByDocument_PassportWithoutCountry(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	usecase := usecases.NewUserUsecase(userRepoMock, redisRepo, time.Minute, "BR")
+	userRepoMock.On("GetUserByIdentityDocument", testTenant, domain.DocumentPassport, "", "AB123456").Return(nil, domain.ErrAmbiguousDocument)
+
+	// Act
+	_, err := usecase.GetUserByDocument(testTenant, domain.DocumentPassport, "", "AB123456")
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrAmbiguousDocument)
+	cached, _ := redisRepo.Get(fmt.Sprintf("tenant:%s:user:passport::AB123456", testTenant))
+	assert.Empty(t, cached)
+}
+
+func TestCreateUser_ForeignGuest(t *testing.T) {
+	// Arrange
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	userRepoMock.On("CreateUser", mock.AnythingOfType("*domain.User")).Return(nil)
+
+	// Act
+	user, err := usecase.CreateUser(testTenant, &dto.UserDTO{
+		Name:            "Lucía",
+		Phone:           "+54 9 11 5555-0000",
+		Document:        "aaa123456",
+		DocumentType:    "passport",
+		DocumentCountry: "AR",
+		Password:        "s3cret-pass",
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "AAA123456", user.Document)
+	assert.Equal(t, domain.DocumentPassport, user.DocumentType)
+	assert.Equal(t, "AR", user.DocumentCountry)
+	assert.Equal(t, "+5491155550000", user.Phone)
+}
+
+func newPatchableUser() *domain.User {
+	return &domain.User{
+		ID:              uuid.New(),
+		TenantID:        testTenant,
+		Name:            "Ana",
+		Phone:           "+5548999990000",
+		Document:        "12345678909",
+		DocumentType:    domain.DocumentCPF,
+		DocumentCountry: domain.Brazil,
+		Version:         3,
+		CreatedAt:       time.Now(),
+	}
+}
+
+func TestPatchUser_MergePatchChangesOnlyPhone(t *testing.T) {
+	// Arrange
+	current := newPatchableUser()
+	stored := *current
+	stored.Phone = "+5511988887777"
+
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	usecase := usecases.NewUserUsecase(userRepoMock, redisRepo, time.Minute, "BR")
+	userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil)
+	userRepoMock.On("UpdateUser", testTenant, current.ID, &domain.User{
+		Name:            "Ana",
+		Phone:           "+5511988887777",
+		Document:        "12345678909",
+		DocumentType:    domain.DocumentCPF,
+		DocumentCountry: domain.Brazil,
+	}, int64(3)).Return(&stored, nil)
+	cacheKey := fmt.Sprintf("tenant:%s:user:cpf:12345678909", testTenant)
+	require.NoError(t, redisRepo.Set(cacheKey, "{}", time.Minute))
+
+	// Act
+	result, err := usecase.PatchUser(testTenant, current.ID, domain.MergePatchType, []byte(`{"phone":"(11) 98888-7777"}`), 3)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, &stored, result)
+	cached, _ := redisRepo.Get(cacheKey)
+	assert.Empty(t, cached, "the cached user is evicted")
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestPatchUser_JSONPatch(t *testing.T) {
+	// Arrange
+	current := newPatchableUser()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewFakeRedisRepository(), time.Minute, "BR")
+	userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil)
+	userRepoMock.On("UpdateUser", testTenant, current.ID, mock.MatchedBy(func(u *domain.User) bool {
+		return u.Name == "Ana Maria" && u.Phone == current.Phone
+	}), int64(3)).Return(current, nil)
+
+	// Act
+	_, err := usecase.PatchUser(testTenant, current.ID, domain.JSONPatchType,
+		[]byte(`[{"op":"test","path":"/name","value":"Ana"},{"op":"replace","path":"/name","value":"Ana Maria"}]`), 0)
+
+	// Assert
+	assert.NoError(t, err)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestPatchUser_Rejected(t *testing.T) {
+	tests := map[string]struct {
+		patchType string
+		patch     string
+		err       error
+		field     string
+	}{
+		"failed test":        {patchType: domain.JSONPatchType, patch: `[{"op":"test","path":"/name","value":"Bia"}]`, err: domain.ErrPatchTestFailed},
+		"read-only field":    {patchType: domain.MergePatchType, patch: `{"role":"admin"}`, err: domain.ErrInvalidPatch},
+		"malformed patch":    {patchType: domain.MergePatchType, patch: `{"name":`, err: domain.ErrInvalidPatch},
+		"invalid phone":      {patchType: domain.MergePatchType, patch: `{"phone":"123"}`, field: "phone"},
+		"type without match": {patchType: domain.MergePatchType, patch: `{"document_type":"cnpj"}`, field: "document"},
+		"unsupported type":   {patchType: "text/plain", patch: `{}`, err: domain.ErrUnsupportedPatchType},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			current := newPatchableUser()
+			userRepoMock := new(mocks.UserRepositoryMock)
+			usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewFakeRedisRepository(), time.Minute, "BR")
+			userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil).Maybe()
+
+			_, err := usecase.PatchUser(testTenant, current.ID, tt.patchType, []byte(tt.patch), 0)
+
+			if tt.field != "" {
+				var fieldErr *domain.FieldError
+				require.ErrorAs(t, err, &fieldErr)
+				assert.Equal(t, tt.field, fieldErr.Field)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+			userRepoMock.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestPatchUser_RequiredFieldRemoved(t *testing.T) {
+	// Arrange
+	current := newPatchableUser()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewFakeRedisRepository(), time.Minute, "BR")
+	userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil)
+
+	// Act
+	_, err := usecase.PatchUser(testTenant, current.ID, domain.MergePatchType, []byte(`{"name":null}`), 0)
+
+	// Assert
+	var validationErrs validator.ValidationErrors
+	assert.ErrorAs(t, err, &validationErrs)
+	userRepoMock.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPatchUser_StaleVersion(t *testing.T) {
+	// Arrange
+	current := newPatchableUser()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewFakeRedisRepository(), time.Minute, "BR")
+	userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil)
+
+	// Act
+	_, err := usecase.PatchUser(testTenant, current.ID, domain.MergePatchType, []byte(`{"name":"Bia"}`), 2)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	userRepoMock.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUpdateUser_VersionMismatchKeepsCache(t *testing.T) {
+	// Arrange
+	current := newPatchableUser()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	usecase := usecases.NewUserUsecase(userRepoMock, redisRepo, time.Minute, "BR")
+	userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil)
+	userRepoMock.On("UpdateUser", testTenant, current.ID, mock.AnythingOfType("*domain.User"), int64(2)).Return(nil, domain.ErrVersionMismatch)
+	cacheKey := fmt.Sprintf("tenant:%s:user:cpf:12345678909", testTenant)
+	require.NoError(t, redisRepo.Set(cacheKey, "{}", time.Minute))
+
+	// Act
+	_, err := usecase.UpdateUser(testTenant, current.ID, &dto.UserDTO{
+		Name:     "Bia",
+		Phone:    current.Phone,
+		Document: current.Document,
+	}, 2)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrVersionMismatch)
+	cached, _ := redisRepo.Get(cacheKey)
+	assert.Equal(t, "{}", cached)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestDeleteUser_EvictsCache(t *testing.T) {
+	// Arrange
+	current := newPatchableUser()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	redisRepo := mocks.NewFakeRedisRepository()
+	usecase := usecases.NewUserUsecase(userRepoMock, redisRepo, time.Minute, "BR")
+	userRepoMock.On("GetUserByID", testTenant, current.ID).Return(current, nil)
+	userRepoMock.On("DeleteUser", testTenant, current.ID, int64(3)).Return(nil)
+	cacheKey := fmt.Sprintf("tenant:%s:user:cpf:12345678909", testTenant)
+	require.NoError(t, redisRepo.Set(cacheKey, "{}", time.Minute))
+
+	// Act
+	err := usecase.DeleteUser(testTenant, current.ID, 3)
+
+	// Assert
+	assert.NoError(t, err)
+	cached, _ := redisRepo.Get(cacheKey)
+	assert.Empty(t, cached, "the cached user is evicted")
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestRestoreUser_ReturnsRestoredUser(t *testing.T) {
+	// Arrange
+	user := newPatchableUser()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, user.ID).Return(user, nil)
+	userRepoMock.On("RestoreUser", testTenant, user.ID).Return(nil)
+	userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+
+	// Act
+	restored, err := usecase.RestoreUser(testTenant, user.ID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, user, restored)
+	userRepoMock.AssertExpectations(t)
+}
+
+func TestRestoreUser_Conflict(t *testing.T) {
+	// Arrange
+	userID := uuid.New()
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, userID).Return(&domain.User{ID: userID}, nil)
+	userRepoMock.On("RestoreUser", testTenant, userID).Return(domain.ErrUserConflict)
+
+	// Act
+	_, err := usecase.RestoreUser(testTenant, userID)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrUserConflict)
+	userRepoMock.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything)
+}
+
+func TestRestoreUser_RefusesAnonymizedUser(t *testing.T) {
+	// Arrange
+	user := newPatchableUser()
+	user.Anonymize(time.Now())
+	userRepoMock := new(mocks.UserRepositoryMock)
+	usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+	userRepoMock.On("GetUserByIDWithDeleted", testTenant, user.ID).Return(user, nil)
+
+	// Act
+	_, err := usecase.RestoreUser(testTenant, user.ID)
+
+	// Assert
+	assert.ErrorIs(t, err, domain.ErrUserAnonymized)
+	userRepoMock.AssertNotCalled(t, "RestoreUser", mock.Anything, mock.Anything)
+}
+
+func TestPurgeUser(t *testing.T) {
+	tests := map[string]struct {
+		purgeErr error
+		active   bool
+		err      error
+	}{
+		"deleted user":  {},
+		"active user":   {purgeErr: domain.ErrIDNotFound, active: true, err: domain.ErrUserNotDeleted},
+		"unknown user":  {purgeErr: domain.ErrIDNotFound, err: domain.ErrIDNotFound},
+		"database down": {purgeErr: errors.New("db down"), err: errors.New("db down")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Arrange
+			user := newPatchableUser()
+			userRepoMock := new(mocks.UserRepositoryMock)
+			usecase := usecases.NewUserUsecase(userRepoMock, mocks.NewCacheMissRedisRepositoryMock(), time.Minute, "BR")
+			userRepoMock.On("PurgeUser", testTenant, user.ID).Return(tt.purgeErr)
+			if tt.active {
+				userRepoMock.On("GetUserByID", testTenant, user.ID).Return(user, nil)
+			} else {
+				userRepoMock.On("GetUserByID", testTenant, user.ID).Return(nil, domain.ErrIDNotFound).Maybe()
+			}
+
+			// Act
+			err := usecase.PurgeUser(testTenant, user.ID)
+
+			// Assert
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err.Error())
+			}
+		})
+	}
+}